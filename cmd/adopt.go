@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultManagedTopic is the topic adopt adds when --topic is not set.
+const defaultManagedTopic = "furca-managed"
+
+// adoptOptions holds the flags of a single adopt invocation.
+type adoptOptions struct {
+	all       bool
+	dryRun    bool
+	discovery discoveryOptions
+}
+
+// newAdoptOptions reads the options of an adopt invocation from its flags.
+func newAdoptOptions(flags *pflag.FlagSet) (*adoptOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &adoptOptions{
+		all:       r.bool("all"),
+		dryRun:    r.bool("dry-run"),
+		discovery: r.discovery(),
+	}
+	return o, r.err
+}
+
+// adoptCmd represents the adopt command
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [PATTERN...]",
+	Short: "Tag forks with the topic that marks them as managed by Furca",
+	Long: `The adopt command adds the --topic topic (furca-managed if unset) to the
+forks whose name or owner/name matches any of the given glob patterns, or to
+every fork with --all.
+
+With --topic or TOPIC set, every command only manages forks that have the
+topic, so forks can be adopted or released in the GitHub UI instead of being
+listed in config.`,
+	Example: `  furca adopt 'duckdb-*' weaviate
+  furca adopt --all --dry-run
+  furca sync --topic furca-managed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newAdoptOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if len(args) == 0 && !o.all {
+			return errors.New("name the forks to adopt, or pass --all")
+		}
+		topic := o.discovery.topic
+		if topic == "" {
+			topic = defaultManagedTopic
+		}
+		if !topicPattern.MatchString(topic) {
+			return fmt.Errorf("invalid --topic %q: GitHub topics consist of lowercase letters, digits, and hyphens", topic)
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+
+		// Look at every fork, not only those already carrying the topic
+		log.Info("Fetching forked repositories...")
+		forks, complete, err := discoverAllForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+		if !complete {
+			log.Warn("Fork discovery was incomplete; only the forks found so far can be adopted")
+		}
+
+		var selected []github.Repository
+		for _, fork := range forks {
+			if o.all || github.MatchesAny(args, fork) {
+				selected = append(selected, fork)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no forks match %v", args)
+		}
+
+		var adopted, failed int
+		for _, fork := range selected {
+			if o.dryRun {
+				fmt.Printf("%s %s Would add topic %s to %s\n", dryRunIcon, syncIcon, topic, fork.FullName)
+				adopted++
+				continue
+			}
+			added, err := client.AddTopic(ctx, fork, topic)
+			switch {
+			case err != nil:
+				failed++
+				fmt.Printf("%s Error adopting %s: %v\n", errorIcon, fork.FullName, err)
+			case added:
+				adopted++
+				fmt.Printf("%s Added topic %s to %s\n", syncIcon, topic, fork.FullName)
+			default:
+				fmt.Printf("%s %s already has topic %s\n", successIcon, fork.FullName, topic)
+			}
+		}
+
+		fmt.Printf("\n%s Summary:\n", summaryIcon)
+		if o.dryRun {
+			fmt.Printf("%s Would adopt forks: %d\n", syncIcon, adopted)
+		} else {
+			fmt.Printf("%s Adopted forks: %d\n", syncIcon, adopted)
+			fmt.Printf("%s Already adopted: %d\n", successIcon, len(selected)-adopted-failed)
+			fmt.Printf("%s Errors encountered: %d\n", errorIcon, failed)
+		}
+		if o.discovery.topic == "" {
+			fmt.Printf("\n%s Set TOPIC=%s (or pass --topic %s) so that Furca only manages adopted forks\n", infoIcon, topic, topic)
+		}
+		if failed > 0 {
+			return &ExitError{Code: ExitFailure}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+
+	adoptCmd.Flags().Bool("all", false, "Adopt every fork")
+	adoptCmd.Flags().Bool("dry-run", false, "Show which forks would be adopted without changing them")
+
+	addDiscoveryFlags(adoptCmd)
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/viper"
+)
+
+// Incident management endpoints
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// alertClient is used for all alerting requests.
+var alertClient = &http.Client{Timeout: 15 * time.Second}
+
+// alerter opens and closes alerts in an incident management service. Alerts
+// are identified by the fork name, so repeated triggers are deduplicated.
+type alerter interface {
+	Name() string
+	Trigger(ctx context.Context, fork, summary string) error
+	Resolve(ctx context.Context, fork string) error
+}
+
+// configuredAlerters returns an alerter for every service with credentials configured.
+func configuredAlerters() []alerter {
+	var alerters []alerter
+	if key := viper.GetString("PAGERDUTY_ROUTING_KEY"); key != "" {
+		alerters = append(alerters, pagerDuty{routingKey: key})
+	}
+	if key := viper.GetString("OPSGENIE_API_KEY"); key != "" {
+		alerters = append(alerters, opsgenie{apiKey: key})
+	}
+	return alerters
+}
+
+// updateAlerts triggers an alert for every fork that has been failing for at
+// least alertAfter (0 to never alert) and was not alerted yet, and resolves the alerts of forks in
+// alerted that no longer fail. Forks whose alert could not be sent are retried
+// on the next run.
+func updateAlerts(ctx context.Context, failures map[string]failureRecord, alerted []string, alertAfter time.Duration) {
+	alerters := configuredAlerters()
+	if alertAfter <= 0 || len(alerters) == 0 {
+		return
+	}
+	log := logger.FromContext(ctx)
+
+	for _, name := range alerted {
+		if _, failing := failures[name]; failing {
+			continue
+		}
+		for _, a := range alerters {
+			if err := a.Resolve(ctx, name); err != nil {
+				log.Warnf("Failed to resolve %s alert for %s: %v", a.Name(), name, err)
+			}
+		}
+	}
+
+	for name, record := range failures {
+		if record.Alerted || record.FirstFailure.IsZero() || time.Since(record.FirstFailure) < alertAfter {
+			continue
+		}
+		summary := fmt.Sprintf("furca: %s has failed to sync for %s (%d runs in a row): %s",
+			name, time.Since(record.FirstFailure).Round(time.Minute), record.Count, record.LastError)
+
+		sent := true
+		for _, a := range alerters {
+			if err := a.Trigger(ctx, name, summary); err != nil {
+				log.Warnf("Failed to send %s alert for %s: %v", a.Name(), name, err)
+				sent = false
+			}
+		}
+		if sent {
+			log.Infof("Alerted on-call about %s", name)
+			record.Alerted = true
+			failures[name] = record
+		}
+	}
+}
+
+// alertedForks returns the forks with an open alert.
+func alertedForks(failures map[string]failureRecord) []string {
+	var alerted []string
+	for name, record := range failures {
+		if record.Alerted {
+			alerted = append(alerted, name)
+		}
+	}
+	return alerted
+}
+
+// pagerDuty sends alerts through the PagerDuty Events API v2.
+type pagerDuty struct {
+	routingKey string
+}
+
+// Name returns the service name.
+func (pagerDuty) Name() string { return "PagerDuty" }
+
+// Trigger opens (or updates) an incident for the fork.
+func (p pagerDuty) Trigger(ctx context.Context, fork, summary string) error {
+	return p.send(ctx, map[string]any{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    "furca/" + fork,
+		"payload": map[string]any{
+			"summary":  summary,
+			"source":   "furca",
+			"severity": "error",
+		},
+	})
+}
+
+// Resolve resolves the fork's incident.
+func (p pagerDuty) Resolve(ctx context.Context, fork string) error {
+	return p.send(ctx, map[string]any{
+		"routing_key":  p.routingKey,
+		"event_action": "resolve",
+		"dedup_key":    "furca/" + fork,
+	})
+}
+
+func (p pagerDuty) send(ctx context.Context, event map[string]any) error {
+	return postAlert(ctx, pagerDutyEventsURL, "", event)
+}
+
+// opsgenie sends alerts through the Opsgenie Alert API.
+type opsgenie struct {
+	apiKey string
+}
+
+// Name returns the service name.
+func (opsgenie) Name() string { return "Opsgenie" }
+
+// Trigger creates an alert for the fork. Opsgenie deduplicates open alerts by alias.
+func (o opsgenie) Trigger(ctx context.Context, fork, summary string) error {
+	message := summary
+	if len(message) > 130 {
+		message = message[:127] + "..."
+	}
+	return postAlert(ctx, opsgenieAlertsURL, "GenieKey "+o.apiKey, map[string]any{
+		"message":     message,
+		"alias":       "furca/" + fork,
+		"description": summary,
+		"source":      "furca",
+		"priority":    "P2",
+	})
+}
+
+// Resolve closes the fork's alert.
+func (o opsgenie) Resolve(ctx context.Context, fork string) error {
+	endpoint := fmt.Sprintf("%s/%s/close?identifierType=alias", opsgenieAlertsURL, url.PathEscape("furca/"+fork))
+	return postAlert(ctx, endpoint, "GenieKey "+o.apiKey, map[string]any{"source": "furca"})
+}
+
+// postAlert posts a JSON body to an alerting endpoint.
+func postAlert(ctx context.Context, endpoint, authorization string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := alertClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ApplyResult is the outcome of one planned sync.
+type ApplyResult struct {
+	Fork   string `json:"fork"`
+	Action string `json:"action"`
+	Status string `json:"status"` // applied, up_to_date, stale, or error
+	Detail string `json:"detail,omitempty"`
+	SHA    string `json:"sha,omitempty"` // Upstream commit the fork branch was brought to
+}
+
+// applyOptions holds the flags of a single apply invocation.
+type applyOptions struct {
+	refresh        bool
+	jsonOutput     bool
+	fields         fieldSet // Fields of the JSON output to keep, all if nil
+	ignoreBlackout bool
+	times          timestamps
+
+	audit *auditLog // Where changes made by this run are recorded, if anywhere
+}
+
+// newApplyOptions reads the options of an apply invocation from its flags.
+func newApplyOptions(flags *pflag.FlagSet) (*applyOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &applyOptions{
+		refresh:        r.bool("refresh"),
+		jsonOutput:     r.jsonOutput(),
+		fields:         r.fields(),
+		ignoreBlackout: r.bool("ignore-blackout"),
+		times:          r.timestamps(),
+	}
+	return o, r.err
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply PLAN",
+	Short: "Make exactly the syncs recorded by furca plan",
+	Long: `The apply command makes the syncs listed in a plan file written by furca
+plan, and nothing else.
+
+Before each sync it checks that the fork branch and the upstream are still at
+the commits recorded in the plan. If either has moved, the sync is refused as
+stale, so that nothing is synced that was not reviewed. With --refresh, stale
+syncs are made against the current upstream commit instead.
+
+Fast-forwards move the fork branch to exactly the planned upstream commit.
+Merges use GitHub's merge-upstream API right after confirming that upstream
+has not moved.
+
+During a blackout window from the config, nothing is applied unless
+--ignore-blackout is set.
+
+It exits with a non-zero status code if any sync was refused or failed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newApplyOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if err := o.fields.check([]ApplyResult(nil)); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+		if offline {
+			return errors.New("a plan cannot be applied offline")
+		}
+
+		plan, err := readPlanFile(args[0])
+		if err != nil {
+			return err
+		}
+		if err := verifyPlan(plan); err != nil {
+			return err
+		}
+		if !o.ignoreBlackout {
+			name, next, err := activeBlackout(time.Now(), o.times.location())
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				return fmt.Errorf("deferred_blackout: blackout window %s is in effect; apply the plan at %s or later, or pass --ignore-blackout", name, o.times.format(next))
+			}
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, runID := startRun(context.Background())
+		log := logger.FromContext(ctx)
+
+		// Make sure no other run is syncing the same forks
+		release, err := acquireRunLock(ctx, "apply")
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		if o.audit, err = openAuditLog(runID, "apply", client.User(), o.times); err != nil {
+			return err
+		}
+
+		log.Infof("Applying %d planned syncs from %s (plan run %s)", len(plan.Syncs), args[0], plan.RunID)
+		results := make([]ApplyResult, 0, len(plan.Syncs))
+		var applied, failed int
+		for _, planned := range plan.Syncs {
+			result := o.apply(ctx, client, planned)
+			switch result.Status {
+			case "applied":
+				applied++
+			case "stale", "error":
+				failed++
+			}
+			results = append(results, result)
+
+			if o.jsonOutput {
+				continue
+			}
+			switch result.Status {
+			case "applied":
+				fmt.Printf("%s Applied %s to %s: %s\n", syncIcon, result.Action, result.Fork, result.Detail)
+			case "up_to_date":
+				fmt.Printf("%s %s is already at %s\n", successIcon, result.Fork, shortSHA(result.SHA))
+			case "stale":
+				fmt.Printf("%s Refused %s: %s\n", skipIcon, result.Fork, result.Detail)
+			case "error":
+				fmt.Printf("%s Error applying %s: %s\n", errorIcon, result.Fork, result.Detail)
+			}
+		}
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, results, o.fields); err != nil {
+				return fmt.Errorf("failed to generate JSON output: %w", err)
+			}
+		} else {
+			fmt.Printf("\n%s Summary:\n", summaryIcon)
+			fmt.Printf("%s Applied syncs: %d\n", syncIcon, applied)
+			fmt.Printf("%s Already up to date: %d\n", successIcon, len(results)-applied-failed)
+			fmt.Printf("%s Refused or failed syncs: %d\n", errorIcon, failed)
+		}
+		if failed > 0 {
+			return &ExitError{Code: ExitFailure}
+		}
+		return nil
+	},
+}
+
+// apply makes a single planned sync, refusing it if the fork branch or the
+// upstream moved since the plan was made, unless --refresh is set.
+func (o *applyOptions) apply(ctx context.Context, client *github.Client, planned PlannedSync) ApplyResult {
+	result := ApplyResult{Fork: planned.Fork, Action: planned.Action}
+	fail := func(format string, args ...any) ApplyResult {
+		result.Status = "error"
+		result.Detail = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	owner, name, ok := strings.Cut(planned.Fork, "/")
+	if !ok {
+		return fail("fork must be in owner/name form, got %q", planned.Fork)
+	}
+	parentOwner, parentName, ok := strings.Cut(planned.Upstream, "/")
+	if !ok {
+		return fail("upstream must be in owner/name form, got %q", planned.Upstream)
+	}
+	if planned.Action != "merge_upstream" && planned.Action != "fast_forward" {
+		return fail("unknown action %q", planned.Action)
+	}
+	ctx = logger.WithFields(ctx, "repo", name, "owner", owner, "branch", planned.Branch)
+	log := logger.FromContext(ctx)
+
+	forkSHA, err := client.HeadSHA(ctx, owner, name, planned.Branch)
+	if err != nil {
+		return fail("%v", err)
+	}
+	upstreamSHA, err := client.HeadSHA(ctx, parentOwner, parentName, planned.UpstreamRef)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if forkSHA == planned.UpstreamSHA {
+		result.Status = "up_to_date"
+		result.SHA = forkSHA
+		return result
+	}
+
+	target := planned.UpstreamSHA
+	var moved []string
+	if forkSHA != planned.ForkSHA {
+		moved = append(moved, fmt.Sprintf("%s moved from %s to %s", planned.Branch, shortSHA(planned.ForkSHA), shortSHA(forkSHA)))
+	}
+	if upstreamSHA != planned.UpstreamSHA {
+		moved = append(moved, fmt.Sprintf("upstream %s moved from %s to %s", planned.UpstreamRef, shortSHA(planned.UpstreamSHA), shortSHA(upstreamSHA)))
+	}
+	if len(moved) > 0 {
+		if !o.refresh {
+			result.Status = "stale"
+			result.Detail = strings.Join(moved, "; ") + " since the plan was made; plan again or apply with --refresh"
+			return result
+		}
+		log.Infof("Refreshing the planned sync of %s: %s", planned.Fork, strings.Join(moved, "; "))
+		target = upstreamSHA
+	}
+
+	repo := github.Repository{
+		Owner:       owner,
+		Name:        name,
+		FullName:    planned.Fork,
+		ParentOwner: parentOwner,
+		ParentName:  parentName,
+		Branch:      planned.Branch,
+	}
+	if planned.Action == "fast_forward" {
+		if _, err := client.FastForwardToUpstreamRef(ctx, repo, planned.Branch, target); err != nil {
+			return fail("%v", err)
+		}
+		result.Detail = fmt.Sprintf("fast-forwarded %s to %s", planned.Branch, shortSHA(target))
+	} else {
+		if err := client.SyncRepositoryWithUpstream(ctx, repo); err != nil {
+			return fail("%v", err)
+		}
+		result.Detail = fmt.Sprintf("merged upstream %s at %s into %s", planned.UpstreamRef, shortSHA(target), planned.Branch)
+	}
+	o.audit.record(ctx, client, repo, planned.Branch, planned.Action, forkSHA)
+	result.Status = "applied"
+	result.SHA = target
+	return result
+}
+
+// shortSHA abbreviates a commit SHA for display.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().Bool("refresh", false, "Sync forks to the current upstream commit when upstream or the fork moved since the plan was made")
+
+	// JSON output flag with default from environment
+	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
+	applyCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	applyCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. fork,status (implies --json)")
+	applyCmd.Flags().Bool("ignore-blackout", false, "Apply the plan even during a blackout window from the config")
+}
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AuditRecord is one change made to a fork, as written to the AUDIT_LOG file.
+// Records form a chain: each holds the SHA-256 of the line before it, so that
+// editing or removing a record breaks every record after it.
+type AuditRecord struct {
+	Seq     int    `json:"seq"`
+	Time    string `json:"time"`
+	RunID   string `json:"run_id"`
+	Command string `json:"command"`
+	Actor   string `json:"actor"`  // GitHub user whose token made the change
+	Runner  string `json:"runner"` // Host or GitHub Actions run that ran furca
+	Fork    string `json:"fork"`
+	Branch  string `json:"branch,omitempty"`
+	Action  string `json:"action"`           // merge_upstream, fast_forward, rollback, or upstream_moved
+	Before  string `json:"before,omitempty"` // Commit, or the old upstream for upstream_moved
+	After   string `json:"after,omitempty"`  // Commit, or the new upstream for upstream_moved
+	Prev    string `json:"prev"`
+
+	// Signature is the base64 Ed25519 signature of the record without it,
+	// made with AUDIT_KEY
+	Signature string `json:"signature,omitempty"`
+}
+
+// auditLog appends records of the changes made by a run to AUDIT_LOG. A nil
+// auditLog records nothing.
+type auditLog struct {
+	mu      sync.Mutex
+	path    string
+	key     ed25519.PrivateKey
+	seq     int
+	prev    string
+	runID   string
+	command string
+	actor   string
+	runner  string
+	times   timestamps
+}
+
+// openAuditLog prepares to append to AUDIT_LOG, continuing its chain, with
+// record times formatted by times. It returns nil if no audit log is
+// configured.
+func openAuditLog(runID, command, actor string, times timestamps) (*auditLog, error) {
+	path := viper.GetString("AUDIT_LOG")
+	if path == "" {
+		return nil, nil
+	}
+	key, err := loadAuditKey()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &auditLog{path: path, key: key, runID: runID, command: command, actor: actor, runner: auditRunner(), times: times}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")); len(lines[len(lines)-1]) > 0 {
+		last := lines[len(lines)-1]
+		var record AuditRecord
+		if err := json.Unmarshal(last, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse the last record of %s: %w", path, err)
+		}
+		a.seq = record.Seq
+		a.prev = lineHash(last)
+	}
+	return a, nil
+}
+
+// head returns the commit a fork branch is at before a change, or "" if no
+// audit log is configured.
+func (a *auditLog) head(ctx context.Context, client *github.Client, fork github.Repository, branch string) string {
+	if a == nil {
+		return ""
+	}
+	sha, err := client.HeadSHA(ctx, fork.Owner, fork.Name, branch)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("Failed to resolve %s of %s for the audit log: %v", branch, fork.FullName, err)
+	}
+	return sha
+}
+
+// record appends a change made to a fork branch that was at before.
+func (a *auditLog) record(ctx context.Context, client *github.Client, fork github.Repository, branch, action, before string) {
+	if a == nil {
+		return
+	}
+	after := a.head(ctx, client, fork, branch)
+	a.append(ctx, AuditRecord{Fork: fork.FullName, Branch: branch, Action: action, Before: before, After: after})
+}
+
+// upstreamMoved appends the rename or transfer of a fork's upstream from one
+// owner/name to another.
+func (a *auditLog) upstreamMoved(ctx context.Context, fork github.Repository, from, to string) {
+	if a == nil {
+		return
+	}
+	a.append(ctx, AuditRecord{Fork: fork.FullName, Action: "upstream_moved", Before: from, After: to})
+}
+
+// append numbers, chains, signs, and writes a record.
+func (a *auditLog) append(ctx context.Context, record AuditRecord) {
+	log := logger.FromContext(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	record.Seq = a.seq + 1
+	record.Time = a.times.format(time.Now())
+	record.RunID = a.runID
+	record.Command = a.command
+	record.Actor = a.actor
+	record.Runner = a.runner
+	record.Prev = a.prev
+	line, err := json.Marshal(record)
+	if err == nil && a.key != nil {
+		record.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(a.key, line))
+		line, err = json.Marshal(record)
+	}
+	if err != nil {
+		log.Errorf("Failed to encode audit record: %v", err)
+		return
+	}
+
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		log.Errorf("Failed to open audit log: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Errorf("Failed to write audit log: %v", err)
+		return
+	}
+	a.seq = record.Seq
+	a.prev = lineHash(line)
+}
+
+// lineHash returns the hex SHA-256 of an audit log line, without its newline.
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditRunner describes where furca is running: the GitHub Actions run when
+// running in Actions, otherwise the host name.
+func auditRunner() string {
+	if server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && id != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+// loadAuditKey reads the Ed25519 private key at AUDIT_KEY, in PKCS #8 PEM
+// form as written by "openssl genpkey -algorithm ed25519". It returns nil if
+// no key is configured.
+func loadAuditKey() (ed25519.PrivateKey, error) {
+	path := viper.GetString("AUDIT_KEY")
+	if path == "" {
+		return nil, nil
+	}
+	block, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AUDIT_KEY: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AUDIT_KEY: %w", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("AUDIT_KEY must be an Ed25519 key, got %T", key)
+	}
+	return private, nil
+}
+
+// loadPublicKey reads an Ed25519 public key in PEM form, as written by
+// "openssl pkey -pubout".
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s must hold an Ed25519 key, got %T", path, key)
+	}
+	return public, nil
+}
+
+// readPEM reads the first PEM block of the given type from a file.
+func readPEM(path, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not hold a PEM %s block", path, blockType)
+	}
+	return block, nil
+}
+
+// keyID returns a short fingerprint of a public key for display.
+func keyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// verifyKey returns the key to verify signatures with: the --public-key file
+// if given, otherwise the public half of AUDIT_KEY. It returns nil if neither
+// is configured.
+func verifyKey(publicKeyFile string) (ed25519.PublicKey, error) {
+	if publicKeyFile != "" {
+		return loadPublicKey(publicKeyFile)
+	}
+	private, err := loadAuditKey()
+	if err != nil || private == nil {
+		return nil, err
+	}
+	return private.Public().(ed25519.PublicKey), nil
+}
+
+// auditCmd groups the commands that work with the audit log.
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Work with the audit log of changes made to forks",
+}
+
+// auditVerifyCmd represents the audit verify command
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [FILE]",
+	Short: "Check that the audit log is intact and correctly signed",
+	Long: `The audit verify command reads the audit log (AUDIT_LOG unless a file is
+given) and checks that its records are numbered consecutively, that each one
+holds the hash of the record before it, and that each one is signed by the
+expected key.
+
+Signatures are checked with the --public-key file, or with the public half of
+AUDIT_KEY. Without either, only the chain is checked.
+
+It exits with a non-zero status code if any problems are found.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := viper.GetString("AUDIT_LOG")
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if path == "" {
+			return errors.New("no audit log given and AUDIT_LOG is not set")
+		}
+		publicKeyFile, err := cmd.Flags().GetString("public-key")
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		key, err := verifyKey(publicKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load verification key: %w", err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer file.Close()
+
+		var problems []string
+		var count int
+		prev := ""
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			count++
+			problems = append(problems, verifyAuditRecord(line, count, prev, key)...)
+			prev = lineHash(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				fmt.Printf("%s %s\n", errorIcon, problem)
+			}
+			fmt.Printf("\n%s Found %d problems in %d audit records\n", errorIcon, len(problems), count)
+			return &ExitError{Code: ExitFailure}
+		}
+		if key == nil {
+			fmt.Printf("%s Verified the chain of %d audit records; signatures were not checked (set AUDIT_KEY or --public-key)\n", warnIcon, count)
+			return nil
+		}
+		fmt.Printf("%s Verified %d audit records, all signed by key %s\n", successIcon, count, keyID(key))
+		return nil
+	},
+}
+
+// verifyAuditRecord checks the record on line n of the audit log against the
+// hash of the line before it and, if key is set, its signature.
+func verifyAuditRecord(line []byte, n int, prev string, key ed25519.PublicKey) []string {
+	var record AuditRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return []string{fmt.Sprintf("line %d: not a valid record: %v", n, err)}
+	}
+
+	var problems []string
+	if record.Seq != n {
+		problems = append(problems, fmt.Sprintf("line %d: record is numbered %d; records were removed or reordered", n, record.Seq))
+	}
+	if record.Prev != prev {
+		problems = append(problems, fmt.Sprintf("line %d: hash of the previous record does not match; the log was modified before this record", n))
+	}
+	if key == nil {
+		return problems
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(record.Signature)
+	if record.Signature == "" || err != nil {
+		return append(problems, fmt.Sprintf("line %d: record is not signed", n))
+	}
+	record.Signature = ""
+	unsigned, err := json.Marshal(record)
+	if err != nil || !ed25519.Verify(key, unsigned, signature) {
+		problems = append(problems, fmt.Sprintf("line %d: signature is not valid for key %s", n, keyID(key)))
+	}
+	return problems
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+
+	auditVerifyCmd.Flags().String("public-key", "", "Ed25519 public key (PEM) to check signatures with instead of AUDIT_KEY")
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"html"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// badgeOptions holds the flags of a single badge invocation.
+type badgeOptions struct {
+	out   string
+	label string
+}
+
+// newBadgeOptions reads the options of a badge invocation from its flags.
+func newBadgeOptions(flags *pflag.FlagSet) (*badgeOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &badgeOptions{
+		out:   r.string("out"),
+		label: r.string("label"),
+	}
+	return o, r.err
+}
+
+// badgeTemplate is a shields.io-style flat badge. Its arguments are the total
+// width, label width, message width, message color, label center, label text,
+// message center, and message text.
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[6]s: %[8]s">
+  <title>%[6]s: %[8]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[3]d" height="20" fill="%[4]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[5]d" y="15" fill="#010101" fill-opacity=".3">%[6]s</text>
+    <text x="%[5]d" y="14">%[6]s</text>
+    <text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[8]s</text>
+    <text x="%[7]d" y="14">%[8]s</text>
+  </g>
+</svg>
+`
+
+// badgeCmd represents the badge command
+var badgeCmd = &cobra.Command{
+	Use:   "badge",
+	Short: "Generate an SVG badge showing how many forks are up to date",
+	Long: `The badge command generates a shields-style SVG badge such as
+"forks fresh: 42/45" from the fork states saved by the last sync or ci-check
+run. It makes no API calls, so it can run right after a scheduled check.
+
+Example:
+  furca ci-check && furca badge --out badge.svg`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newBadgeOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+
+		snap, err := loadSnapshot()
+		if err != nil {
+			return fmt.Errorf("failed to load fork snapshot: %w", err)
+		}
+		if len(snap.Forks) == 0 {
+			return errors.New("no saved fork data; run sync or ci-check first")
+		}
+
+		var fresh int
+		for _, fork := range snap.Forks {
+			if fork.BehindBy == 0 {
+				fresh++
+			}
+		}
+		svg := renderBadge(o.label, fmt.Sprintf("%d/%d", fresh, len(snap.Forks)), badgeColor(fresh, len(snap.Forks)))
+
+		if o.out == "" || o.out == "-" {
+			fmt.Print(svg)
+			return nil
+		}
+		if err := writeFileAtomic(o.out, []byte(svg), 0o644); err != nil {
+			return fmt.Errorf("failed to write badge: %w", err)
+		}
+		fmt.Printf("%s Wrote badge to %s\n", successIcon, o.out)
+		return nil
+	},
+}
+
+// badgeColor returns green when every fork is fresh, yellow when at least 80%
+// are, and red otherwise.
+func badgeColor(fresh, total int) string {
+	switch {
+	case fresh == total:
+		return "#4c1"
+	case fresh*5 >= total*4:
+		return "#dfb317"
+	default:
+		return "#e05d44"
+	}
+}
+
+// renderBadge returns the SVG for a badge. Text widths are estimated from the
+// character count, which is close enough for the short texts used here.
+func renderBadge(label, message, color string) string {
+	labelWidth := 10 + 7*len(label)
+	messageWidth := 10 + 7*len(message)
+	return fmt.Sprintf(badgeTemplate,
+		labelWidth+messageWidth, labelWidth, messageWidth, color,
+		labelWidth/2, html.EscapeString(label),
+		labelWidth+messageWidth/2, html.EscapeString(message))
+}
+
+func init() {
+	rootCmd.AddCommand(badgeCmd)
+
+	badgeCmd.Flags().String("out", "", "Write the SVG to this file instead of standard output")
+	badgeCmd.Flags().String("label", "forks fresh", "Text on the left side of the badge")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fleetSizes are the numbers of forks the rendering benchmarks simulate.
+var fleetSizes = []int{1000, 5000}
+
+// renderer renders the results of a fleet of forks in one output format.
+type renderer struct {
+	name   string
+	render func(w io.Writer, fleet *fleetResults) error
+}
+
+// fleetResults are canned results of a run over a fleet of forks.
+type fleetResults struct {
+	summary   *SyncSummary
+	ci        *CICheckResult
+	inventory []InventoryEntry
+}
+
+// newFleetResults returns results for the given number of forks: a third
+// synced, one in a hundred failing, and the rest up to date.
+func newFleetResults(forks int) *fleetResults {
+	r := &fleetResults{
+		summary: &SyncSummary{
+			Skipped: map[string]string{}, NoWriteAccess: map[string]string{}, Quarantined: map[string]string{},
+			VerifyFailed: map[string]string{}, Errors: map[string]string{}, Warnings: map[string][]string{},
+			Workflows: map[string][]string{}, WorkflowRisks: map[string][]string{}, PendingReview: map[string]string{},
+			SSORequired: map[string]string{},
+		},
+		ci: &CICheckResult{Errors: map[string]string{}, TotalRepos: forks},
+	}
+	checked := time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)
+	for i := 0; i < forks; i++ {
+		name := fmt.Sprintf("fork-%05d", i)
+		behind := 0
+		switch {
+		case i%100 == 99:
+			r.summary.Errors[name] = "merge conflict"
+			r.ci.Errors[name] = "merge conflict"
+			r.ci.TotalErrors++
+		case i%3 == 0:
+			behind = i%7 + 1
+			r.summary.Synced = append(r.summary.Synced, name)
+			r.summary.Verified = append(r.summary.Verified, name)
+			r.summary.Plan = append(r.summary.Plan, PlannedAction{Fork: name, Action: "merge_upstream", Detail: fmt.Sprintf("merge %d upstream commits into main", behind)})
+			r.ci.BehindRepos = append(r.ci.BehindRepos, name)
+			r.ci.TotalBehind++
+		default:
+			r.summary.UpToDate = append(r.summary.UpToDate, name)
+			r.ci.UpToDateRepos = append(r.ci.UpToDateRepos, name)
+			r.ci.TotalUpToDate++
+		}
+		r.inventory = append(r.inventory, InventoryEntry{
+			Fork: "octocat/" + name, Parent: fmt.Sprintf("upstream-%02d/%s", i%50, name), Visibility: "public",
+			DefaultBranch: "main", BehindBy: behind, LastChecked: checked, LastSynced: checked,
+			Topics: []string{"furca-managed"}, Language: "Go", Stars: i % 10, PushedAt: checked,
+			ParentLanguage: "Go", ParentStars: i, ParentPushedAt: checked,
+		})
+	}
+	return r
+}
+
+var renderers = []renderer{
+	{"sync text", func(w io.Writer, r *fleetResults) error {
+		return (&syncOptions{dryRun: true}).writeSummary(w, r.summary, 0)
+	}},
+	{"sync json", func(w io.Writer, r *fleetResults) error {
+		return (&syncOptions{jsonOutput: true}).writeSummary(w, r.summary, 0)
+	}},
+	{"ci-check json", func(w io.Writer, r *fleetResults) error {
+		return (&ciCheckOptions{jsonOutput: true}).writeSummary(w, r.ci, false, false)
+	}},
+	{"inventory json", func(w io.Writer, r *fleetResults) error { return writeInventory(w, "json", r.inventory, timestamps{}) }},
+	{"inventory csv", func(w io.Writer, r *fleetResults) error { return writeInventory(w, "csv", r.inventory, timestamps{}) }},
+}
+
+// BenchmarkRender measures rendering the results of large fleets in every
+// output format.
+func BenchmarkRender(b *testing.B) {
+	plainOutput(b)
+	for _, forks := range fleetSizes {
+		results := newFleetResults(forks)
+		for _, r := range renderers {
+			b.Run(fmt.Sprintf("%s/forks=%d", r.name, forks), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := r.render(io.Discard, results); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// renderBudget is the most rendering the results of a fleet may allocate per
+// fork, in any format. Keep it in sync with README.md.
+const renderBudget = 10
+
+// TestRenderBudget checks that rendering stays within its allocation budget
+// per fork, so that output for large fleets does not grow costlier unnoticed.
+func TestRenderBudget(t *testing.T) {
+	plainOutput(t)
+	const forks = 1000
+	results := newFleetResults(forks)
+	for _, r := range renderers {
+		t.Run(r.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			if err := r.render(io.Discard, results); err != nil {
+				t.Fatal(err)
+			}
+			runtime.ReadMemStats(&after)
+			allocs := float64(after.Mallocs-before.Mallocs) / forks
+			if allocs > renderBudget {
+				t.Errorf("%.1f allocations per fork, over the budget of %d", allocs, renderBudget)
+			}
+			t.Logf("%.1f allocations per fork", allocs)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// blackoutWindow is a period defined under "blackouts" in the YAML config
+// during which forks must not be changed, such as a release freeze. It either
+// recurs on days of the week or is a one-off period between two dates.
+type blackoutWindow struct {
+	Name  string   `mapstructure:"name"`
+	Days  []string `mapstructure:"days"`  // Days of the week, such as [fri, sat, sun] or [fri-sun]
+	From  string   `mapstructure:"from"`  // Time of day the window starts on each day, 00:00 by default
+	To    string   `mapstructure:"to"`    // Time of day it ends, 24:00 by default; before From to run past midnight
+	Start string   `mapstructure:"start"` // Date (2006-01-02) or RFC 3339 time a one-off window starts
+	End   string   `mapstructure:"end"`   // Date (inclusive) or RFC 3339 time it ends
+}
+
+// blackout is a parsed blackoutWindow, in the configured time zone.
+type blackout struct {
+	name     string
+	days     [7]bool // Indexed by time.Weekday
+	from, to int     // Minutes after midnight
+	start    time.Time
+	end      time.Time
+	zone     *time.Location // Of the days and times of day
+}
+
+// weekdays maps day names and their abbreviations to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// loadBlackouts returns the blackout windows defined in the config, with
+// times of day and dates read in zone, the --timezone time zone.
+func loadBlackouts(zone *time.Location) ([]blackout, error) {
+	var windows []blackoutWindow
+	if err := viper.UnmarshalKey("blackouts", &windows, viper.DecodeHook(datesAsText)); err != nil {
+		return nil, fmt.Errorf("invalid blackouts: %w", err)
+	}
+	blackouts := make([]blackout, 0, len(windows))
+	for i, window := range windows {
+		b, err := parseBlackout(window, zone)
+		if err != nil {
+			name := window.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("invalid blackout %s: %w", name, err)
+		}
+		if b.name == "" {
+			b.name = fmt.Sprintf("#%d", i+1)
+		}
+		blackouts = append(blackouts, b)
+	}
+	return blackouts, nil
+}
+
+// datesAsText turns the dates and times YAML decodes for unquoted start and
+// end values back into text for parseBlackoutTime.
+func datesAsText(from, to reflect.Type, data any) (any, error) {
+	t, ok := data.(time.Time)
+	if !ok || to.Kind() != reflect.String {
+		return data, nil
+	}
+	if t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)) {
+		return t.Format(time.DateOnly), nil
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// parseBlackout parses a blackout window, reading its times in loc.
+func parseBlackout(window blackoutWindow, loc *time.Location) (blackout, error) {
+	b := blackout{name: window.Name, zone: loc}
+	if window.Start != "" || window.End != "" {
+		if len(window.Days) > 0 {
+			return blackout{}, fmt.Errorf("set either days or start and end, not both")
+		}
+		var err error
+		if b.start, err = parseBlackoutTime(window.Start, loc, false); err != nil {
+			return blackout{}, fmt.Errorf("start: %w", err)
+		}
+		if b.end, err = parseBlackoutTime(window.End, loc, true); err != nil {
+			return blackout{}, fmt.Errorf("end: %w", err)
+		}
+		if !b.end.After(b.start) {
+			return blackout{}, fmt.Errorf("ends before it starts")
+		}
+		return b, nil
+	}
+
+	if len(window.Days) == 0 {
+		return blackout{}, fmt.Errorf("set days, or start and end")
+	}
+	for _, day := range window.Days {
+		first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(day)), "-")
+		from, ok := weekdays[first]
+		if !ok {
+			return blackout{}, fmt.Errorf("unknown day %q", day)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[last]; !ok {
+				return blackout{}, fmt.Errorf("unknown day %q", day)
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			b.days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+
+	var err error
+	if b.from, err = parseTimeOfDay(window.From, 0); err != nil {
+		return blackout{}, fmt.Errorf("from: %w", err)
+	}
+	if b.to, err = parseTimeOfDay(window.To, 24*60); err != nil {
+		return blackout{}, fmt.Errorf("to: %w", err)
+	}
+	if b.to == b.from {
+		return blackout{}, fmt.Errorf("from and to are the same time")
+	}
+	return b, nil
+}
+
+// parseBlackoutTime parses the start or end of a one-off blackout: a date, or
+// an RFC 3339 time. An end date includes the whole day.
+func parseBlackoutTime(value string, loc *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing")
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date such as 2006-01-02 nor an RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+// parseTimeOfDay parses a time of day such as 18:30 into minutes after
+// midnight, returning def for an empty value. 24:00 is accepted as the end of
+// the day.
+func parseTimeOfDay(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("invalid time of day %q; use HH:MM", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+// activeUntil returns when the blackout ends if it covers t.
+func (b blackout) activeUntil(t time.Time) (time.Time, bool) {
+	if !b.start.IsZero() {
+		return b.end, !t.Before(b.start) && t.Before(b.end)
+	}
+
+	// A window past midnight may have started the day before
+	t = t.In(b.zone)
+	for _, offset := range []int{0, -1} {
+		day := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, t.Location())
+		if !b.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, b.from, 0, 0, day.Location())
+		end := time.Date(day.Year(), day.Month(), day.Day(), 0, b.to, 0, 0, day.Location())
+		if b.to < b.from {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// activeBlackout returns the name of the blackout window in effect at t, if
+// any, and the first time after it when forks may be changed again, following
+// back-to-back windows. The windows are read in zone.
+func activeBlackout(t time.Time, zone *time.Location) (string, time.Time, error) {
+	blackouts, err := loadBlackouts(zone)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var name string
+	next := t
+	// Windows covering every day never end; stop looking after a year
+	for next.Before(t.AddDate(1, 0, 0)) {
+		moved := false
+		for _, b := range blackouts {
+			if until, ok := b.activeUntil(next); ok {
+				if name == "" {
+					name = b.name
+				}
+				next, moved = until, true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return name, next, nil
+}
+
+// deferForBlackout reports whether a blackout window is in effect and the run
+// must not sync anything. A deferred run reports the status deferred_blackout
+// and when syncs may resume instead of processing forks. Dry runs and runs with
+// --ignore-blackout go ahead, after a warning.
+func (o *syncOptions) deferForBlackout(health *runHealth) (bool, error) {
+	name, next, err := activeBlackout(time.Now(), o.times.location())
+	if err != nil {
+		return false, err
+	}
+	if name == "" {
+		return false, nil
+	}
+	if o.dryRun || o.ignoreBlackout {
+		if o.jsonOutput {
+			return false, nil
+		}
+		fmt.Printf(tr("%s Blackout window %s is in effect until %s\n"), warnIcon, name, o.times.format(next))
+		return false, nil
+	}
+
+	_, runID := startRun(context.Background())
+	summary := o.newSummary(runID)
+	summary.Status = "deferred_blackout"
+	summary.Blackout = name
+	summary.NextEligible = o.times.format(next)
+	health.report(false, fmt.Sprintf("furca %s: deferred by blackout window %s until %s", health.command, name, summary.NextEligible))
+	summary.sort(o.stableOutput)
+
+	if o.outFile != "" {
+		if err := writeJSONFile(o.outFile, summary, o.appendOut); err != nil {
+			return true, fmt.Errorf("failed to write results: %w", err)
+		}
+	}
+	if err := o.writeDeferred(os.Stdout, &summary); err != nil {
+		return true, fmt.Errorf("failed to generate JSON output: %w", err)
+	}
+	return true, nil
+}
+
+// writeDeferred writes the summary of a run deferred by a blackout window to
+// w, as JSON with --json or as a single line otherwise.
+func (o *syncOptions) writeDeferred(w io.Writer, summary *SyncSummary) error {
+	if o.jsonOutput {
+		return writeJSON(w, summary, o.fields)
+	}
+	_, err := fmt.Fprintf(w, tr("%s Deferred: blackout window %s is in effect; the next eligible time is %s\n"), skipIcon, summary.Blackout, summary.NextEligible)
+	return err
+}
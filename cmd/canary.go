@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+)
+
+// selectCanaries splits the forks into the canaries a sync run processes first
+// and the rest. --canary takes a percentage such as 10% or a number of forks,
+// picked by a hash of their full name so that the same forks lead every run;
+// --canary-group takes the members of a group from the config instead.
+func (o *syncOptions) selectCanaries(ctx context.Context, forks []github.Repository) ([]github.Repository, []github.Repository, error) {
+	if o.canaryGroup != "" {
+		if o.canary != "" {
+			return nil, nil, errors.New("--canary and --canary-group cannot be combined")
+		}
+		group, err := loadGroup(o.canaryGroup)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load canary group: %w", err)
+		}
+		var canaries, rest []github.Repository
+		for _, fork := range forks {
+			if github.MatchesAny(group.Repos, fork) {
+				canaries = append(canaries, fork)
+			} else {
+				rest = append(rest, fork)
+			}
+		}
+		if len(canaries) == 0 {
+			logger.FromContext(ctx).Warnf("No forks of this run are in canary group %s", o.canaryGroup)
+		}
+		return canaries, rest, nil
+	}
+	if o.canary == "" {
+		return nil, forks, nil
+	}
+
+	count, err := canaryCount(o.canary, len(forks))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --canary value: %w", err)
+	}
+	ordered := slices.Clone(forks)
+	slices.SortStableFunc(ordered, func(a, b github.Repository) int {
+		return cmp.Compare(canaryRank(a), canaryRank(b))
+	})
+	canaries := ordered[:count]
+	var rest []github.Repository
+	for _, fork := range forks {
+		if !slices.ContainsFunc(canaries, func(c github.Repository) bool { return c.FullName == fork.FullName }) {
+			rest = append(rest, fork)
+		}
+	}
+	return canaries, rest, nil
+}
+
+// canaryCount returns how many of total forks a --canary value of N% or N
+// selects. A percentage selects at least one fork.
+func canaryCount(value string, total int) (int, error) {
+	number, percent := strings.CutSuffix(strings.TrimSpace(value), "%")
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n <= 0 || (percent && n > 100) {
+		return 0, fmt.Errorf("%q is neither a percentage such as 10%% nor a number of forks", value)
+	}
+	count := int(n)
+	if percent {
+		count = int(float64(total)*n/100 + 0.999999)
+	} else if float64(count) != n {
+		return 0, fmt.Errorf("%q is not a whole number of forks", value)
+	}
+	return min(max(count, 1), total), nil
+}
+
+// canaryRank orders forks for canary selection by a hash of their full name.
+func canaryRank(fork github.Repository) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte("canary:" + strings.ToLower(fork.FullName)))
+	return h.Sum32()
+}
+
+// syncAll processes the forks concurrently, up to --concurrency at a time,
+// sending each result to results and also returning them.
+func (o *syncOptions) syncAll(ctx context.Context, client *github.Client, policy *github.Policy, forks []github.Repository, results chan<- SyncResult) []SyncResult {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		collected []SyncResult
+		slots     = newLimiter(o.concurrency)
+	)
+	send := func(result SyncResult) {
+		mu.Lock()
+		collected = append(collected, result)
+		mu.Unlock()
+		results <- result
+	}
+	for _, fork := range forks {
+		wg.Add(1)
+		go func(fork github.Repository) {
+			defer wg.Done()
+			slots.acquire()
+			defer slots.release()
+
+			if o.branchPattern != "" {
+				for _, result := range o.syncBranches(ctx, client, policy, fork) {
+					send(result)
+				}
+				return
+			}
+			send(o.syncForkWithTimeout(ctx, client, policy, fork))
+		}(fork)
+	}
+	wg.Wait()
+	return collected
+}
+
+// checkCanaries decides whether the rest of the forks may be synced after the
+// canaries, returning why not if they may not. Canaries that failed halt the
+// run. Once any canary was synced, the run waits for --canary-soak and then
+// asks --canary-check, a command that must exit successfully or an http(s)
+// URL that must answer with a 2xx status.
+func (o *syncOptions) checkCanaries(ctx context.Context, runID string, canaries []SyncResult) string {
+	log := logger.FromContext(ctx)
+
+	var synced, failed []string
+	for _, result := range canaries {
+		switch result.Status {
+		case "synced", "would_sync":
+			synced = append(synced, result.Name)
+		case "error", "timed_out", "verify_failed":
+			failed = append(failed, result.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Sprintf("canary forks failed: %s", strings.Join(failed, ", "))
+	}
+	if len(synced) == 0 {
+		log.Info("No canary fork needed a sync; continuing with the remaining forks")
+		return ""
+	}
+	if o.dryRun {
+		if o.canarySoak > 0 {
+			log.Infof("Dry run: would wait %s for the canary forks to soak", o.canarySoak)
+		}
+		if o.canaryCheck != "" {
+			log.Infof("Dry run: would ask %s whether the canary forks are healthy", o.canaryCheck)
+		}
+		return ""
+	}
+
+	if o.canarySoak > 0 {
+		log.Infof("Synced %d canary forks; waiting %s before syncing the rest", len(synced), o.canarySoak)
+		select {
+		case <-time.After(o.canarySoak):
+		case <-ctx.Done():
+			return fmt.Sprintf("canary soak interrupted: %v", ctx.Err())
+		}
+	}
+	if o.canaryCheck == "" {
+		return ""
+	}
+	log.Infof("Checking the health of the canary forks with %s", o.canaryCheck)
+	if err := runCanaryCheck(ctx, o.canaryCheck, runID, synced); err != nil {
+		return fmt.Sprintf("canary health check failed: %v", err)
+	}
+	return ""
+}
+
+// runCanaryCheck asks the --canary-check command or webhook whether the synced
+// canary forks are healthy. A command gets the forks in FURCA_CANARY_FORKS and
+// the run ID in FURCA_RUN_ID; a webhook gets them as forks and run_id query
+// parameters.
+func runCanaryCheck(ctx context.Context, check, runID string, forks []string) error {
+	if strings.HasPrefix(check, "http://") || strings.HasPrefix(check, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, check, nil)
+		if err != nil {
+			return err
+		}
+		query := req.URL.Query()
+		query.Set("run_id", runID)
+		query.Set("forks", strings.Join(forks, ","))
+		req.URL.RawQuery = query.Encode()
+		req.Header.Set("User-Agent", userAgent())
+
+		resp, err := alertClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("%s answered %s", check, resp.Status)
+		}
+		return nil
+	}
+
+	cmd := shellCommand(ctx, check)
+	cmd.Env = append(os.Environ(), "FURCA_CANARY_FORKS="+strings.Join(forks, ","), "FURCA_RUN_ID="+runID)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", check, err)
+	}
+	return nil
+}
@@ -4,8 +4,11 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,26 +16,107 @@ import (
 	"github.com/TFMV/furca/logger"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // CICheckResult represents the result of a CI check operation
 type CICheckResult struct {
-	BehindRepos    []string          `json:"behind_repos"`
-	UpToDateRepos  []string          `json:"up_to_date_repos"`
-	Errors         map[string]string `json:"errors"`
-	Timestamp      string            `json:"timestamp"`
+	RunID         string            `json:"run_id,omitempty"`
+	BehindRepos   []string          `json:"behind_repos"`
+	UpToDateRepos []string          `json:"up_to_date_repos"`
+	SLABreaches   []string          `json:"sla_breaches"`
+	Errors        map[string]string `json:"errors"`
+	// TimedOut lists the repositories whose check took longer than
+	// --repo-timeout
+	TimedOut []string `json:"timed_out"`
+	// RequestIDs holds GitHub's request ID for each error that came from an
+	// API response, for support escalations
+	RequestIDs     map[string]string `json:"request_ids,omitempty"`
+	Timestamp      string            `json:"timestamp,omitempty"`
 	TotalBehind    int               `json:"total_behind"`
 	TotalUpToDate  int               `json:"total_up_to_date"`
 	TotalErrors    int               `json:"total_errors"`
+	TotalTimedOut  int               `json:"total_timed_out"`
 	TotalRepos     int               `json:"total_repos"`
 	OutdatedStatus bool              `json:"outdated_status"`
+	// DiscoveryIncomplete is set when fork discovery stopped early and only
+	// the forks found so far were checked
+	DiscoveryIncomplete bool `json:"discovery_incomplete,omitempty"`
+	// NewlyBehind and Recovered list the repositories whose state changed
+	// since the --baseline result, if one was given
+	NewlyBehind []string `json:"newly_behind,omitempty"`
+	Recovered   []string `json:"recovered,omitempty"`
+	// OutsidePaths lists the up-to-date repositories that are behind upstream
+	// only in files outside --paths
+	OutsidePaths []string `json:"behind_outside_paths,omitempty"`
+	// SSORequired lists the repositories that could not be checked because the
+	// token is not authorized for their organization's SAML SSO, with the reason
+	SSORequired map[string]string `json:"sso_required,omitempty"`
+	// Group is the group the check was limited to with --group
+	Group string `json:"group,omitempty"`
 }
 
-var (
+// ciRepoStatus is the outcome of checking a single fork in ci-check.
+type ciRepoStatus struct {
+	Name        string
+	IsBehind    bool
+	BehindBy    int
+	Capped      bool
+	BreachesSLA bool
+	Error       string
+	TimedOut    bool // The check took longer than --repo-timeout; Error says how long
+	RequestID   string
+	SSORequired string // Why the token could not access the fork, if SSO blocked it
+
+	// OutsidePaths is set when the fork is behind, but none of the missing
+	// commits touch the --paths patterns
+	OutsidePaths bool
+}
+
+// ciCheckOptions holds the flags of a single ci-check invocation.
+type ciCheckOptions struct {
 	failOnOutdated bool
-	ciJsonOutput   bool
-)
+	jsonOutput     bool
+	fields         fieldSet // Fields of the JSON output to keep, all if nil
+	repoTimeout    time.Duration
+	setStatus      bool
+	outFile        string
+	appendOut      bool
+	upstreamRef    string
+	baseline       string
+	shard          string
+	group          string
+	paths          string
+	times          timestamps
+	discovery      discoveryOptions
+	stableOutput   bool
+	concurrency    int
+}
+
+// newCICheckOptions reads the options of a ci-check invocation from its flags.
+func newCICheckOptions(flags *pflag.FlagSet) (*ciCheckOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &ciCheckOptions{
+		failOnOutdated: r.bool("fail-on-outdated"),
+		jsonOutput:     r.jsonOutput(),
+		fields:         r.fields(),
+		repoTimeout:    r.duration("repo-timeout"),
+		setStatus:      r.bool("set-status"),
+		outFile:        r.string("out"),
+		appendOut:      r.bool("append"),
+		upstreamRef:    r.string("upstream-ref"),
+		baseline:       r.string("baseline"),
+		shard:          r.string("shard"),
+		group:          r.string("group"),
+		paths:          r.string("paths"),
+		times:          r.timestamps(),
+		discovery:      r.discovery(),
+		stableOutput:   r.bool("stable-output"),
+		concurrency:    r.int("concurrency"),
+	}
+	return o, r.err
+}
 
 // ciCheckCmd represents the ci-check command
 var ciCheckCmd = &cobra.Command{
@@ -68,78 +152,109 @@ Example usage in CI/CD pipelines:
       container:
         image: your-image-with-furca
         command: [furca, ci-check, --fail-on-outdated]`,
-	Run: func(cmd *cobra.Command, args []string) {
-		log := logger.GetLogger()
-
-		// Get GitHub token from environment
-		token := viper.GetString("GITHUB_TOKEN")
-		if token == "" {
-			fmt.Println("\n❌ ERROR: GitHub token not found")
-			fmt.Println("\nTo use Furca, you need to provide a GitHub personal access token with 'repo' scope.")
-			fmt.Println("\nYou can set it in one of these ways:")
-			fmt.Println("  1. Create a .env file in the current directory with:")
-			fmt.Println("     GITHUB_TOKEN=your_github_token_here")
-			fmt.Println("  2. Set an environment variable:")
-			fmt.Println("     export GITHUB_TOKEN=your_github_token_here")
-			fmt.Println("\nTo create a token, visit: https://github.com/settings/tokens")
-			os.Exit(1)
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		o, err := newCICheckOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+
+		// Tell the cron monitor how the run went, even if it ended early
+		health := &runHealth{command: "ci-check"}
+		if o.group != "" {
+			health.command += " --group " + o.group
+		}
+		defer func() { health.ping(err) }()
+
+		// Report from saved data without contacting GitHub
+		if offline {
+			return printOfflineReport(o.jsonOutput, o.fields)
+		}
+		if err := o.fields.check(CICheckResult{}); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+		if err := checkPatterns(o.paths, github.ValidatePathPattern); err != nil {
+			return fmt.Errorf("invalid --paths: %w", err)
+		}
+
+		// Load the earlier result to compare with before doing any work
+		var baseline *CICheckResult
+		if o.baseline != "" {
+			var err error
+			baseline, err = loadBaseline(o.baseline)
+			if err != nil {
+				return fmt.Errorf("failed to load baseline: %w", err)
+			}
 		}
 
 		// Create GitHub client
-		client, err := github.NewClient(token)
+		client, err := newGitHubClient()
 		if err != nil {
-			log.Fatalf("Failed to create GitHub client: %v", err)
+			return err
 		}
 
-		// Create a context for all operations
-		ctx := context.Background()
+		// Create a context for all operations, tagged with this run's ID
+		ctx, runID := startRun(context.Background())
+		log := logger.FromContext(ctx)
 
 		// Get forked repositories
 		log.Info("Fetching forked repositories...")
-		forks, err := client.GetForkedRepositories(ctx)
+		forks, discoveryComplete, err := discoverForks(ctx, client, o.discovery)
 		if err != nil {
-			log.Fatalf("Failed to fetch forked repositories: %v", err)
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
 		}
 
 		if len(forks) == 0 {
 			log.Info("No forked repositories found with parent information.")
-			return
+			return nil
 		}
 
 		log.Infof("Found %d forked repositories with parent information", len(forks))
 
+		// Apply the organization policy, if any
+		policy, forks, err := applyPolicy(ctx, client, forks)
+		if err != nil {
+			return fmt.Errorf("failed to apply policy: %w", err)
+		}
+
+		// Keep only the members of the --group group
+		if forks, err = selectGroup(ctx, forks, o.group); err != nil {
+			return err
+		}
+
+		// Keep only this runner's shard, in random order
+		shard, err := parseShard(o.shard)
+		if err != nil {
+			return fmt.Errorf("invalid --shard value: %w", err)
+		}
+		if shard != nil {
+			forks = selectShard(forks, shard)
+			log.Infof("Processing shard %d/%d: %d forks", shard.Index, shard.Count, len(forks))
+		}
+		shuffleForks(forks)
+
 		// Process repositories concurrently
 		var wg sync.WaitGroup
-		type repoStatus struct {
-			Name     string
-			IsBehind bool
-			BehindBy int
-			Error    string
-		}
-		results := make(chan repoStatus, len(forks))
+		results := make(chan ciRepoStatus, len(forks))
 
+		slots := newLimiter(o.concurrency)
 		for _, fork := range forks {
 			wg.Add(1)
 			go func(fork github.Repository) {
 				defer wg.Done()
-
-				log.Debugf("Checking repository: %s", fork.Name)
-
-				// Check if fork is behind upstream
-				behind, behindBy, err := client.IsRepositoryBehindUpstream(ctx, fork)
-				if err != nil {
-					results <- repoStatus{
-						Name:  fork.Name,
-						Error: fmt.Sprintf("failed to compare commits: %v", err),
+				slots.acquire()
+				defer slots.release()
+
+				result, ok := runWithTimeout(ctx, o.repoTimeout, func(ctx context.Context) ciRepoStatus {
+					return o.checkFork(ctx, client, policy, fork)
+				})
+				if !ok {
+					result = ciRepoStatus{
+						Name:     fork.Name,
+						Error:    fmt.Sprintf("timed out after %s", o.repoTimeout),
+						TimedOut: true,
 					}
-					return
-				}
-
-				results <- repoStatus{
-					Name:     fork.Name,
-					IsBehind: behind,
-					BehindBy: behindBy,
 				}
+				results <- result
 			}(fork)
 		}
 
@@ -151,77 +266,337 @@ Example usage in CI/CD pipelines:
 
 		// Process results
 		ciResult := CICheckResult{
+			RunID:         runID,
 			BehindRepos:   []string{},
 			UpToDateRepos: []string{},
+			SLABreaches:   []string{},
 			Errors:        make(map[string]string),
-			Timestamp:     time.Now().Format(time.RFC3339),
+			TimedOut:      []string{},
+			RequestIDs:    make(map[string]string),
+			SSORequired:   make(map[string]string),
+			Group:         o.group,
+			Timestamp:     o.times.format(time.Now()),
+
+			DiscoveryIncomplete: !discoveryComplete,
 		}
 
-		for result := range results {
-			if result.Error != "" {
+		snap := newSnapshot(ctx, runID)
+		snap.trackUpstreams(ctx, forks)
+		usage := newRunUsage("ci-check")
+		for result := range inOrder(results, func(r ciRepoStatus) string { return r.Name }, o.stableOutput) {
+			switch {
+			case result.TimedOut:
+				usage.count("timed_out")
+			case result.Error != "":
+				usage.count("error")
+			case result.SSORequired != "":
+				usage.count("sso_required")
+			default:
+				snap.record(result.Name, "checked", result.BehindBy)
+				usage.count("checked")
+			}
+			if result.SSORequired != "" {
+				ciResult.SSORequired[result.Name] = result.SSORequired
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s Cannot access %s: %s\n"), skipIcon, result.Name, result.SSORequired)
+				}
+			} else if result.TimedOut {
+				ciResult.TimedOut = append(ciResult.TimedOut, result.Name)
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s Timed out processing %s: %s\n"), errorIcon, result.Name, result.Error)
+				}
+			} else if result.Error != "" {
 				ciResult.Errors[result.Name] = result.Error
-				if !ciJsonOutput {
-					fmt.Printf("%s Error checking %s: %s\n", errorIcon, result.Name, result.Error)
+				if result.RequestID != "" {
+					ciResult.RequestIDs[result.Name] = result.RequestID
+				}
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s Error checking %s: %s\n"), errorIcon, result.Name, result.Error)
 				}
 			} else if result.IsBehind {
 				ciResult.BehindRepos = append(ciResult.BehindRepos, result.Name)
-				if !ciJsonOutput {
-					fmt.Printf("%s %s is behind upstream by %d commits\n", syncIcon, result.Name, result.BehindBy)
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s %s is behind upstream by %s commits\n"), syncIcon, result.Name, formatBehind(result.BehindBy, result.Capped))
+				}
+				if result.BreachesSLA {
+					ciResult.SLABreaches = append(ciResult.SLABreaches, result.Name)
+					if !o.jsonOutput {
+						fmt.Printf(tr("%s %s exceeds the policy limit of %d commits behind\n"), errorIcon, result.Name, policy.SLA.MaxBehind)
+					}
+				}
+			} else if result.OutsidePaths {
+				ciResult.UpToDateRepos = append(ciResult.UpToDateRepos, result.Name)
+				ciResult.OutsidePaths = append(ciResult.OutsidePaths, result.Name)
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s %s is behind upstream by %s commits, none touching %s\n"), successIcon, result.Name, formatBehind(result.BehindBy, result.Capped), o.paths)
 				}
 			} else {
 				ciResult.UpToDateRepos = append(ciResult.UpToDateRepos, result.Name)
-				if !ciJsonOutput {
-					fmt.Printf("%s %s is up to date with upstream\n", successIcon, result.Name)
+				if !o.jsonOutput {
+					fmt.Printf(tr("%s %s is up to date with upstream\n"), successIcon, result.Name)
 				}
 			}
 		}
 
+		snap.save(ctx)
+		usage.save(ctx)
+		writeMetrics(ctx, client, o.times)
+
 		// Set count fields
 		ciResult.TotalBehind = len(ciResult.BehindRepos)
 		ciResult.TotalUpToDate = len(ciResult.UpToDateRepos)
 		ciResult.TotalErrors = len(ciResult.Errors)
-		ciResult.TotalRepos = ciResult.TotalBehind + ciResult.TotalUpToDate + ciResult.TotalErrors
+		ciResult.TotalTimedOut = len(ciResult.TimedOut)
+		ciResult.TotalRepos = ciResult.TotalBehind + ciResult.TotalUpToDate + ciResult.TotalErrors + ciResult.TotalTimedOut + len(ciResult.SSORequired)
 		ciResult.OutdatedStatus = ciResult.TotalBehind > 0
+		health.report(ciResult.TotalErrors+ciResult.TotalTimedOut > 0, fmt.Sprintf("furca %s: %d behind, %d up to date, %d errors, %d timed out",
+			health.command, ciResult.TotalBehind, ciResult.TotalUpToDate, ciResult.TotalErrors, ciResult.TotalTimedOut))
+		if baseline != nil {
+			ciResult.NewlyBehind, ciResult.Recovered = compareBaseline(baseline, ciResult)
+		}
 
-		// Print JSON output if requested
-		if ciJsonOutput {
-			jsonData, err := json.MarshalIndent(ciResult, "", "  ")
-			if err != nil {
-				log.Errorf("Failed to generate JSON output: %v", err)
-			} else {
-				fmt.Println(string(jsonData))
+		// Write results to a file if requested
+		ciResult.sort(o.stableOutput)
+		if o.outFile != "" {
+			if err := writeJSONFile(o.outFile, ciResult, o.appendOut); err != nil {
+				log.Errorf("Failed to write results: %v", err)
 			}
-		} else {
-			// Print summary
-			fmt.Println("\n📊 Summary:")
-			fmt.Printf("%s Repositories behind upstream: %d\n", syncIcon, ciResult.TotalBehind)
-			fmt.Printf("%s Repositories up to date: %d\n", successIcon, ciResult.TotalUpToDate)
-			fmt.Printf("%s Errors encountered: %d\n", errorIcon, ciResult.TotalErrors)
-			fmt.Printf("%s Total repositories checked: %d\n", color.CyanString("ℹ️"), ciResult.TotalRepos)
-
-			if ciResult.TotalBehind > 0 {
-				fmt.Println("\n" + color.YellowString("⚠️  Some repositories are behind their upstream sources"))
-				if failOnOutdated {
-					fmt.Println(color.RedString("❌ Exiting with non-zero status code due to --fail-on-outdated flag"))
-				}
+		}
+
+		// Print summary or JSON output
+		if err := o.writeSummary(os.Stdout, &ciResult, policy != nil, baseline != nil); err != nil {
+			log.Errorf("Failed to generate JSON output: %v", err)
+		}
+
+		// With a baseline, only regressions fail the check
+		if baseline != nil {
+			if len(ciResult.NewlyBehind) > 0 {
+				return &ExitError{Code: ExitFailure}
 			}
+			return nil
 		}
 
 		// Exit with non-zero status code if any forks are behind and --fail-on-outdated is specified
-		if failOnOutdated && ciResult.TotalBehind > 0 {
-			os.Exit(1)
+		if o.failOnOutdated && ciResult.TotalBehind > 0 {
+			return &ExitError{Code: ExitFailure}
 		}
+		return nil
 	},
 }
 
+// writeSummary writes the result of a ci-check run to w, as JSON with --json
+// or as the console summary otherwise, which reports SLA breaches if a policy
+// applies and changes since the baseline if one was given.
+func (o *ciCheckOptions) writeSummary(w io.Writer, result *CICheckResult, withPolicy, withBaseline bool) error {
+	if o.jsonOutput {
+		return writeJSON(w, result, o.fields)
+	}
+
+	// Print summary
+	writeSummaryHeader(w, o.group)
+	fmt.Fprintf(w, tr("%s Repositories behind upstream: %d\n"), syncIcon, result.TotalBehind)
+	fmt.Fprintf(w, tr("%s Repositories up to date: %d\n"), successIcon, result.TotalUpToDate)
+	fmt.Fprintf(w, tr("%s Errors encountered: %d\n"), errorIcon, result.TotalErrors)
+	if result.TotalTimedOut > 0 {
+		fmt.Fprintf(w, tr("%s Timed out repositories: %d\n"), errorIcon, result.TotalTimedOut)
+	}
+	if len(result.SSORequired) > 0 {
+		fmt.Fprintf(w, tr("%s Repositories needing SSO authorization: %d\n"), skipIcon, len(result.SSORequired))
+	}
+	if withPolicy {
+		fmt.Fprintf(w, tr("%s Policy SLA breaches: %d\n"), errorIcon, len(result.SLABreaches))
+	}
+	fmt.Fprintf(w, tr("%s Total repositories checked: %d\n"), infoIcon, result.TotalRepos)
+	if withBaseline {
+		fmt.Fprintf(w, tr("%s Newly behind since baseline: %d\n"), warnIcon, len(result.NewlyBehind))
+		for _, name := range result.NewlyBehind {
+			fmt.Fprintf(w, "   %s\n", name)
+		}
+		fmt.Fprintf(w, tr("%s Caught up since baseline: %d\n"), successIcon, len(result.Recovered))
+	}
+
+	if result.DiscoveryIncomplete {
+		fmt.Fprintf(w, "\n%s %s\n", warnIcon, color.YellowString(tr("Fork discovery was incomplete; run again with --resume to cover the remaining forks")))
+	}
+
+	if withBaseline {
+		if len(result.NewlyBehind) > 0 {
+			fmt.Fprintf(w, "\n%s %s\n", errorIcon, color.RedString(tr("Exiting with non-zero status code: repositories fell behind since the baseline")))
+		}
+	} else if result.TotalBehind > 0 {
+		fmt.Fprintf(w, "\n%s %s\n", warnIcon, color.YellowString(tr("Some repositories are behind their upstream sources")))
+		if o.failOnOutdated {
+			fmt.Fprintf(w, "%s %s\n", errorIcon, color.RedString(tr("Exiting with non-zero status code due to --fail-on-outdated flag")))
+		}
+	}
+	return nil
+}
+
+// loadBaseline reads an earlier ci-check result written by --out or --json.
+// If the file holds several results (--append), the last one is used.
+func loadBaseline(path string) (*CICheckResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var baseline *CICheckResult
+	dec := json.NewDecoder(file)
+	for {
+		var result CICheckResult
+		if err := dec.Decode(&result); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		baseline = &result
+	}
+	if baseline == nil {
+		return nil, fmt.Errorf("%s contains no results", path)
+	}
+	return baseline, nil
+}
+
+// compareBaseline returns the repositories that are behind now but were not in
+// the baseline, and those that were behind in the baseline but are up to date now.
+// Repositories that could not be checked in either run are not counted.
+func compareBaseline(baseline *CICheckResult, current CICheckResult) (newlyBehind, recovered []string) {
+	wasBehind := make(map[string]bool, len(baseline.BehindRepos))
+	for _, name := range baseline.BehindRepos {
+		wasBehind[name] = true
+	}
+	for _, name := range current.BehindRepos {
+		if !wasBehind[name] {
+			newlyBehind = append(newlyBehind, name)
+		}
+	}
+	for _, name := range current.UpToDateRepos {
+		if wasBehind[name] {
+			recovered = append(recovered, name)
+		}
+	}
+	sort.Strings(newlyBehind)
+	sort.Strings(recovered)
+	return newlyBehind, recovered
+}
+
+// checkFork checks whether a single fork is behind its upstream, using the
+// branch preferred by the fork's repository config.
+func (o *ciCheckOptions) checkFork(ctx context.Context, client *github.Client, policy *github.Policy, fork github.Repository) ciRepoStatus {
+	// Attach per-repository fields to every log entry from this worker
+	ctx = logger.WithFields(ctx, "repo", fork.Name, "owner", fork.Owner)
+	log := logger.FromContext(ctx)
+	log.Debugf("Checking repository: %s", fork.Name)
+
+	// Use the branch preferred by the fork's .github/furca.yml, if any
+	repoConfig, err := client.GetRepoConfig(ctx, fork)
+	if err != nil {
+		return ciErrorStatus(ctx, fork.Name, fmt.Sprintf("failed to read repository config: %v", err), err)
+	}
+	fork.Branch = repoConfig.Branch
+	if fork.Branch != "" {
+		ctx = logger.WithFields(ctx, "branch", fork.Branch)
+	}
+	fork.UpstreamRef = repoConfig.UpstreamRef
+	if fork.UpstreamRef == "" {
+		fork.UpstreamRef = o.upstreamRef
+	}
+
+	// Check if fork is behind upstream
+	comparison, err := client.CompareWithUpstream(ctx, fork)
+	if err != nil {
+		return ciErrorStatus(ctx, fork.Name, fmt.Sprintf("failed to compare commits: %v", err), err)
+	}
+	behindBy := comparison.BehindBy
+
+	// Only count drift that touches the paths the fork's users care about
+	outsidePaths := false
+	if behindBy > 0 && o.paths != "" {
+		files, err := client.IncomingFiles(ctx, fork, comparison)
+		switch {
+		case errors.Is(err, github.ErrDetached):
+			logger.FromContext(ctx).Warnf("Cannot list upstream changes of detached fork %s; counting all drift", fork.FullName)
+		case err != nil:
+			return ciErrorStatus(ctx, fork.Name, fmt.Sprintf("failed to list upstream changes: %v", err), err)
+		case len(files) < github.IncomingFileLimit && len(github.MatchingFiles(files, parsePathPatterns(o.paths))) == 0:
+			outsidePaths = true
+		}
+	}
+
+	// Make the result visible in the fork's own UI
+	if o.setStatus {
+		if err := client.SetFreshnessStatus(ctx, fork, comparison.Branch, behindBy); err != nil {
+			logger.FromContext(ctx).Warnf("Failed to set commit status on %s: %v", fork.FullName, err)
+		}
+	}
+
+	return ciRepoStatus{
+		Name:         fork.Name,
+		IsBehind:     behindBy > 0 && !outsidePaths,
+		BehindBy:     behindBy,
+		Capped:       comparison.BehindCapped,
+		OutsidePaths: outsidePaths,
+		BreachesSLA:  !outsidePaths && policy.BreachesSLA(fork, behindBy),
+	}
+}
+
+// ciErrorStatus returns the status of a fork that could not be checked,
+// recording GitHub's request ID if the failure came from an API response.
+func ciErrorStatus(ctx context.Context, name, message string, err error) ciRepoStatus {
+	result := errorResult(ctx, name, message, err)
+	if result.Status == "sso_required" {
+		return ciRepoStatus{Name: name, SSORequired: result.Reason}
+	}
+	return ciRepoStatus{
+		Name:      name,
+		Error:     result.Error,
+		RequestID: result.RequestID,
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(ciCheckCmd)
 
 	// Add flags with default values from environment variables
 	defaultFailOnOutdated := viper.GetBool("CI_FAIL_ON_OUTDATED")
-	ciCheckCmd.Flags().BoolVar(&failOnOutdated, "fail-on-outdated", defaultFailOnOutdated, "Exit with non-zero status code if any repositories are behind upstream")
+	ciCheckCmd.Flags().Bool("fail-on-outdated", defaultFailOnOutdated, "Exit with non-zero status code if any repositories are behind upstream")
 
 	// JSON output flag with default from environment
 	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
-	ciCheckCmd.Flags().BoolVar(&ciJsonOutput, "json", defaultJsonOutput, "Output results in JSON format")
+	ciCheckCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	ciCheckCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. total_behind,behind_repos (implies --json)")
+
+	// Results file with default from environment
+	defaultOutFile := viper.GetString("OUT_FILE")
+	ciCheckCmd.Flags().String("out", defaultOutFile, "Also write JSON results to this file")
+	ciCheckCmd.Flags().Bool("append", false, "Append results to the --out file as JSON Lines instead of replacing it")
+	ciCheckCmd.Flags().String("baseline", "", "Compare with an earlier --out result and fail only on repositories that fell behind since")
+
+	// Per-repository time limit with default from environment
+	defaultRepoTimeout := viper.GetDuration("REPO_TIMEOUT")
+	ciCheckCmd.Flags().Duration("repo-timeout", defaultRepoTimeout, "Maximum time to spend checking a single repository (0 for no limit)")
+
+	// Upstream ref to track with default from environment
+	defaultUpstreamRef := viper.GetString("UPSTREAM_REF")
+	ciCheckCmd.Flags().String("upstream-ref", defaultUpstreamRef, "Compare with this upstream tag, branch, or SHA instead of the matching branch")
+
+	// Relevant paths with default from environment
+	defaultPaths := viper.GetString("CI_PATHS")
+	ciCheckCmd.Flags().String("paths", defaultPaths, "Only count forks as behind if the missing commits touch these comma-separated globs (e.g. 'docs/**,src/**')")
+
+	// Shard of the fork list with default from environment
+	defaultShard := viper.GetString("SHARD")
+	ciCheckCmd.Flags().String("shard", defaultShard, "Only check shard i of n (for example 2/4), splitting forks across runners by name")
+
+	// Repository group to limit the check to
+	ciCheckCmd.Flags().String("group", "", "Only check the forks in this group from the config")
+
+	// Commit status reporting with default from environment
+	defaultSetStatus := viper.GetBool("SET_STATUS")
+	ciCheckCmd.Flags().Bool("set-status", defaultSetStatus, "Set a furca/sync commit status on each fork's branch head")
+
+	addDiscoveryFlags(ciCheckCmd)
+	addStableOutputFlag(ciCheckCmd)
+	addConcurrencyFlag(ciCheckCmd)
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addConcurrencyFlag adds --concurrency, which limits how many forks sync and
+// ci-check process at once, to a command.
+func addConcurrencyFlag(cmd *cobra.Command) {
+	// Forks processed at once, with default from environment
+	defaultConcurrency := viper.GetInt("CONCURRENCY")
+	cmd.Flags().Int("concurrency", defaultConcurrency, "Process at most this many forks at once (0 for all at once)")
+}
+
+// limiter holds a slot for each fork being processed under --concurrency. A
+// nil limiter never blocks.
+type limiter chan struct{}
+
+// newLimiter returns a limiter with the given number of slots, or nil for 0,
+// which processes all forks at once.
+func newLimiter(concurrency int) limiter {
+	if concurrency <= 0 {
+		return nil
+	}
+	return make(limiter, concurrency)
+}
+
+// acquire waits for a free slot.
+func (l limiter) acquire() {
+	if l != nil {
+		l <- struct{}{}
+	}
+}
+
+// release frees the slot taken by acquire.
+func (l limiter) release() {
+	if l != nil {
+		<-l
+	}
+}
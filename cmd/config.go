@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/viper"
+)
+
+// configFile is a config file that was read at startup.
+type configFile struct {
+	Path   string
+	Format string // "env" or "yaml"
+}
+
+// loadedConfigFiles lists the config files read at startup, in load order.
+var loadedConfigFiles []configFile
+
+// loadStructuredConfig merges YAML configuration files into the global config.
+// These hold settings that cannot be expressed as flat environment variables,
+// such as repository groups. The legacy ~/.furca.yaml is read first, then
+// config.yaml in the platform config directory, then furca.yaml in the current
+// directory, so project settings override personal ones.
+func loadStructuredConfig() {
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".furca.yaml"))
+	}
+	if dir, err := configDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "config.yaml"))
+	}
+	paths = append(paths, "furca.yaml")
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := mergeConfigFile(path, "yaml"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		fmt.Fprintln(os.Stderr, "Using config file:", path)
+		loadedConfigFiles = append(loadedConfigFiles, configFile{Path: path, Format: "yaml"})
+	}
+}
+
+// mergeConfigFile reads a config file of the given format and merges it into
+// the global config. Keys may carry the FURCA_ prefix used for environment
+// variables, so env-style files can use the same names as the environment.
+// Files encrypted with SOPS are decrypted first; unencrypted files holding
+// secrets that other users can read are warned about.
+func mergeConfigFile(path, format string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	encrypted := sopsMetadata.Match(data)
+	if encrypted {
+		if data, err = decryptConfig(path, format); err != nil {
+			return err
+		}
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	config := v.AllSettings()
+	prefix := strings.ToLower(envPrefix)
+	keys := make([]string, 0, len(config))
+	for key, value := range config {
+		if trimmed, ok := strings.CutPrefix(key, prefix); ok {
+			delete(config, key)
+			config[trimmed] = value
+		}
+		keys = append(keys, key)
+	}
+	if !encrypted {
+		warnIfExposed(path, keys)
+	}
+	if err := viper.MergeConfigMap(config); err != nil {
+		return fmt.Errorf("failed to merge %s: %w", path, err)
+	}
+	return nil
+}
+
+// repoGroup is a named set of forks defined under "groups" in the YAML config,
+// either with its fields or as a plain list of repos.
+type repoGroup struct {
+	Ref   string   `mapstructure:"ref"`   // Upstream ref (tag, branch, or SHA) the members must track
+	Repos []string `mapstructure:"repos"` // Fork names or owner/name patterns
+}
+
+// loadGroup returns the named repository group from the config. Group names
+// are case-insensitive.
+func loadGroup(name string) (repoGroup, error) {
+	key := "groups." + strings.ToLower(name)
+	if !viper.IsSet(key) {
+		return repoGroup{}, fmt.Errorf("group %q is not defined in the config", name)
+	}
+
+	var group repoGroup
+	if _, ok := viper.Get(key).([]interface{}); ok {
+		group.Repos = viper.GetStringSlice(key)
+	} else if err := viper.UnmarshalKey(key, &group); err != nil {
+		return repoGroup{}, fmt.Errorf("invalid definition of group %q: %w", name, err)
+	}
+	if len(group.Repos) == 0 {
+		return repoGroup{}, fmt.Errorf("group %q has no repos", name)
+	}
+	for _, pattern := range group.Repos {
+		if err := github.ValidatePattern(pattern); err != nil {
+			return repoGroup{}, fmt.Errorf("invalid pattern %q in group %q: %w", pattern, name, err)
+		}
+	}
+	return group, nil
+}
+
+// selectGroup returns the forks that belong to the named group, or all forks
+// if name is empty.
+func selectGroup(ctx context.Context, forks []github.Repository, name string) ([]github.Repository, error) {
+	if name == "" {
+		return forks, nil
+	}
+	group, err := loadGroup(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load group: %w", err)
+	}
+
+	var members []github.Repository
+	for _, fork := range forks {
+		if github.MatchesAny(group.Repos, fork) {
+			members = append(members, fork)
+		}
+	}
+	logger.FromContext(ctx).Infof("Processing group %s: %d of %d forks", name, len(members), len(forks))
+	return members, nil
+}
+
+// loadUpstreams returns the manual upstream mapping defined under "upstreams"
+// in the YAML config, keyed by owner/name. Viper splits keys on dots, so names
+// containing dots come back nested and are joined again here.
+func loadUpstreams() map[string]string {
+	upstreams := make(map[string]string)
+	var flatten func(prefix string, m map[string]interface{})
+	flatten = func(prefix string, m map[string]interface{}) {
+		for key, value := range m {
+			switch value := value.(type) {
+			case map[string]interface{}:
+				flatten(prefix+key+".", value)
+			case string:
+				upstreams[prefix+key] = value
+			default:
+				fmt.Fprintf(os.Stderr, "Warning: ignoring upstream mapping for %s: expected owner/name\n", prefix+key)
+			}
+		}
+	}
+	flatten("", viper.GetStringMap("upstreams"))
+	return upstreams
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configDefaultsCmd represents the config defaults command
+var configDefaultsCmd = &cobra.Command{
+	Use:   "defaults",
+	Short: "List every setting with its environment variable, flag, and default",
+	Long: `The config defaults command lists every setting Furca reads, with the
+FURCA_ environment variable that sets it, the corresponding flag, and its
+built-in default. The unprefixed variable names are still accepted.
+
+With --env, the list is printed as an env-style config file that can be used
+as a starting point for .env or config.env.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asEnv, err := cmd.Flags().GetBool("env")
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if asEnv {
+			for _, s := range settings {
+				fmt.Printf("# %s\n%s=%s\n\n", s.Description, s.EnvName(), s.Default)
+			}
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VARIABLE\tFLAG\tDEFAULT\tDESCRIPTION")
+		for _, s := range settings {
+			flag, def := "-", "-"
+			if s.Flag != "" {
+				flag = "--" + s.Flag
+			}
+			if s.Default != "" {
+				def = s.Default
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.EnvName(), flag, def, s.Description)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configDefaultsCmd)
+
+	configDefaultsCmd.Flags().Bool("env", false, "Print the defaults as an env-style config file")
+}
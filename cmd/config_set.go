@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print the effective value of a setting",
+	Long: `The config get command prints the effective value of a setting, such as
+max_retries or log-level, or of a structured setting such as groups.mygroup.ref.
+Secret values are masked unless --show-secrets is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		showSecrets, err := cmd.Flags().GetBool("show-secrets")
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+
+		if s, ok := lookupSetting(settingKey(key)); ok {
+			value := s.Default
+			if viper.IsSet(s.Key) {
+				value = viper.GetString(s.Key)
+			}
+			if s.Secret && !showSecrets {
+				value = mask(value)
+			}
+			fmt.Println(value)
+			return nil
+		}
+
+		if !isStructuredKey(key) {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		value := viper.Get(key)
+		if value == nil {
+			return fmt.Errorf("%s is not set", key)
+		}
+		if s, ok := value.(string); ok {
+			fmt.Println(s)
+			return nil
+		}
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Store a setting in your config",
+	Long: `The config set command stores a setting in config.yaml in the platform
+config directory, so you don't have to edit config files by hand. Values are
+validated before they are written.
+
+Secret settings such as GITHUB_TOKEN are written to secrets.env next to it
+instead, which only you can read. The environment and other config files
+still take precedence over secrets stored this way.
+
+Examples:
+  furca config set max_retries 5
+  furca config set github_token ghp_xxx
+  furca config set groups.mygroup.ref v1.27.0
+  furca config set groups.mygroup.repos "[fork-a, fork-b]"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+
+		if s, ok := lookupSetting(settingKey(key)); ok {
+			if err := s.check(value); err != nil {
+				return fmt.Errorf("invalid value: %w", err)
+			}
+			if s.Secret {
+				path, err := saveSecret(s.Key, value)
+				if err != nil {
+					return fmt.Errorf("failed to store %s: %w", s.Key, err)
+				}
+				fmt.Printf("%s Stored %s in %s\n", successIcon, s.Key, path)
+				return nil
+			}
+			key = strings.ToLower(s.Key)
+		} else if !isStructuredKey(key) {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+
+		path, err := setConfigValue(key, value)
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		fmt.Printf("%s Set %s in %s\n", successIcon, key, path)
+		return nil
+	},
+}
+
+// settingKey converts a key as typed by the user (max-retries, max_retries)
+// to the name of the corresponding setting.
+func settingKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// isStructuredKey reports whether key is a dotted path into a structured setting.
+func isStructuredKey(key string) bool {
+	top, _, _ := strings.Cut(strings.ToLower(key), ".")
+	return slices.Contains(structuredKeys, top)
+}
+
+// setConfigValue sets the dotted key to value in the YAML file given with
+// --config, or in config.yaml in the platform config directory, and returns the
+// file's path. The value is parsed as YAML, so lists and numbers keep their type.
+func setConfigValue(key, value string) (string, error) {
+	path := cfgFile
+	if path == "" {
+		dir, err := configDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, "config.yaml")
+	} else if configFormat(path) != "yaml" {
+		return "", fmt.Errorf("config set can only write YAML files, not %s", path)
+	}
+	return path, updateConfigFile(path, map[string]string{key: value}, 0o644)
+}
+
+// updateConfigFile sets the dotted keys to their values in the YAML file at
+// path, keeping its other settings, and writes it with the given permissions.
+func updateConfigFile(path string, values map[string]string, perm os.FileMode) error {
+	config := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(value), &parsed); err != nil || parsed == nil {
+			parsed = value
+		}
+
+		parts := strings.Split(strings.ToLower(key), ".")
+		node := config
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = parsed
+	}
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return writeFileAtomic(path, data, perm)
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+
+	configGetCmd.Flags().Bool("show-secrets", false, "Print secret values in full")
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd groups the commands that inspect and manage Furca's configuration.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and manage Furca's configuration",
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration and show the effective settings",
+	Long: `The config validate command parses the environment and config files the
+same way every other command does, and reports unknown keys, invalid values,
+and conflicting settings. It then prints the effective value of every setting
+together with where it came from: a flag, the environment, a config file, or
+the built-in default.
+
+It exits with a non-zero status code if any problems are found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r := &flagReader{flags: cmd.Flags()}
+		times := r.timestamps()
+		if r.err != nil {
+			return fmt.Errorf("failed to read flags: %w", r.err)
+		}
+
+		var problems []string
+
+		// Find unknown keys and remember which file set each known one
+		fileSources := make(map[string]string)
+		for _, file := range loadedConfigFiles {
+			v := viper.New()
+			v.SetConfigFile(file.Path)
+			v.SetConfigType(file.Format)
+			if err := v.ReadInConfig(); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", file.Path, err))
+				continue
+			}
+			for _, key := range v.AllKeys() {
+				if isStructuredKey(key) {
+					continue
+				}
+				s, ok := lookupSetting(key)
+				if !ok {
+					problems = append(problems, fmt.Sprintf("%s: unknown key %q", file.Path, key))
+					continue
+				}
+				fileSources[s.Key] = file.Path
+			}
+		}
+
+		// Resolve and check the effective value of every setting
+		effective := make(map[string]string)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, s := range settings {
+			value, source := resolveSetting(s, fileSources)
+			effective[s.Key] = value
+			if value != "" && source != "default" {
+				if err := s.check(value); err != nil {
+					problems = append(problems, fmt.Sprintf("%v (from %s)", err, source))
+				}
+			}
+			if s.Secret && value != "" {
+				value = mask(value)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, value, source)
+		}
+		w.Flush()
+
+		problems = append(problems, validateStructuredConfig(times.location())...)
+		problems = append(problems, conflictingSettings(effective)...)
+
+		fmt.Println()
+		if len(problems) == 0 {
+			fmt.Printf("%s Configuration is valid\n", successIcon)
+			return nil
+		}
+		for _, problem := range problems {
+			fmt.Printf("%s %s\n", errorIcon, problem)
+		}
+		fmt.Printf("\n%s Found %d configuration problems\n", errorIcon, len(problems))
+		return &ExitError{Code: ExitFailure}
+	},
+}
+
+// resolveSetting returns the effective value of a setting and where it came
+// from, following the same precedence as the other commands: flag, then
+// environment, then config file, then the built-in default.
+func resolveSetting(s setting, fileSources map[string]string) (value, source string) {
+	if s.Flag != "" {
+		if flag := rootCmd.PersistentFlags().Lookup(s.Flag); flag != nil && flag.Changed {
+			return flag.Value.String(), "flag --" + s.Flag
+		}
+	}
+	if name, value, ok := s.envValue(); ok {
+		if name == s.Key {
+			return value, "env " + name + " (legacy name)"
+		}
+		return value, "env " + name
+	}
+	if path, ok := fileSources[s.Key]; ok {
+		return viper.GetString(s.Key), path
+	}
+	if value, ok := loadedSecrets[s.Key]; ok {
+		path, _ := secretsFile()
+		return value, path
+	}
+	return s.Default, "default"
+}
+
+// validateStructuredConfig checks the groups, upstreams, and blackout windows
+// defined in YAML config files, reading the windows in zone.
+func validateStructuredConfig(zone *time.Location) []string {
+	var problems []string
+	for name := range viper.GetStringMap("groups") {
+		if _, err := loadGroup(name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for repo, upstream := range loadUpstreams() {
+		if owner, name, ok := strings.Cut(upstream, "/"); !ok || owner == "" || name == "" {
+			problems = append(problems, fmt.Sprintf("upstream of %s must be in owner/name form, got %q", repo, upstream))
+		}
+	}
+	if _, err := loadBlackouts(zone); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// conflictingSettings reports combinations of settings that contradict each
+// other or have no effect.
+func conflictingSettings(effective map[string]string) []string {
+	enabled := func(key string) bool {
+		return strings.EqualFold(effective[key], "true") || effective[key] == "1"
+	}
+
+	var problems []string
+	if effective["GITHUB_TOKEN"] == "" {
+		problems = append(problems, "GITHUB_TOKEN is not set")
+	}
+	if enabled("VERIFY_RETRY") && !enabled("VERIFY_SYNC") {
+		problems = append(problems, "VERIFY_RETRY has no effect when VERIFY_SYNC is false")
+	}
+	if enabled("DRY_RUN") && enabled("SET_STATUS") {
+		problems = append(problems, "SET_STATUS has no effect when DRY_RUN is true; dry runs never post statuses")
+	}
+	if enabled("DRY_RUN") && enabled("FOLLOW_RENAMES") {
+		problems = append(problems, "FOLLOW_RENAMES has no effect when DRY_RUN is true; dry runs never rename branches")
+	}
+	if effective["BRANCH_PATTERN"] != "" && effective["UPSTREAM_REF"] != "" {
+		problems = append(problems, "BRANCH_PATTERN and UPSTREAM_REF cannot be combined; pattern branches are synced with the same-named upstream branch")
+	}
+	if effective["LOG_FILE"] == "" {
+		for _, key := range []string{"LOG_MAX_SIZE", "LOG_MAX_AGE", "LOG_MAX_BACKUPS"} {
+			if s, _ := lookupSetting(key); s.configured() && viper.GetString(key) != s.Default {
+				problems = append(problems, fmt.Sprintf("%s has no effect without LOG_FILE", key))
+			}
+		}
+	}
+	return problems
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ConsistencyResult represents where a single group member stands relative to
+// the group's target ref.
+type ConsistencyResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	BehindBy int    `json:"behind_by,omitempty"`
+	AheadBy  int    `json:"ahead_by,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ConsistencyReport represents the consistency of all members of a group.
+type ConsistencyReport struct {
+	Group      string              `json:"group"`
+	Ref        string              `json:"ref"`
+	Members    []ConsistencyResult `json:"members"`
+	Consistent bool                `json:"consistent"`
+}
+
+// consistencyOptions holds the flags of a single consistency invocation.
+type consistencyOptions struct {
+	group      string
+	sync       bool
+	jsonOutput bool
+	fields     fieldSet // Fields of the JSON output to keep, all if nil
+	discovery  discoveryOptions
+}
+
+// newConsistencyOptions reads the options of a consistency invocation from its flags.
+func newConsistencyOptions(flags *pflag.FlagSet) (*consistencyOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &consistencyOptions{
+		group:      r.string("group"),
+		sync:       r.bool("sync"),
+		jsonOutput: r.jsonOutput(),
+		fields:     r.fields(),
+		discovery:  r.discovery(),
+	}
+	return o, r.err
+}
+
+// consistencyCmd represents the consistency command which checks that a group
+// of forks tracks the same upstream ref.
+var consistencyCmd = &cobra.Command{
+	Use:   "consistency",
+	Short: "Check that a group of forks is on the same upstream ref",
+	Long: `The consistency command checks a group of forks that must all track the
+same upstream ref (typically a release tag) and reports which members are
+on the target, behind it, or ahead of it.
+
+Groups are defined in furca.yaml or ~/.furca.yaml:
+
+  groups:
+    mygroup:
+      ref: v1.27.0
+      repos: [fork-a, fork-b, myorg/fork-c]
+
+With --sync, members that are behind are fast-forwarded to exactly the
+target ref. Members with commits of their own are never rewritten.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newConsistencyOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if err := o.fields.check(ConsistencyReport{}); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+
+		group, err := loadGroup(o.group)
+		if err != nil {
+			return fmt.Errorf("failed to load group: %w", err)
+		}
+		if group.Ref == "" {
+			return fmt.Errorf("group %q has no target ref", o.group)
+		}
+
+		// Create GitHub client
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+
+		// Create a context for all operations, tagged with this run's ID
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+		if o.sync {
+			release, err := acquireRunLock(ctx, "consistency --sync")
+			if err != nil {
+				return err
+			}
+			defer release()
+		}
+
+		// Get forked repositories
+		log.Info("Fetching forked repositories...")
+		forks, _, err := discoverForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+
+		report := ConsistencyReport{
+			Group:      o.group,
+			Ref:        group.Ref,
+			Members:    []ConsistencyResult{},
+			Consistent: true,
+		}
+		matched := map[string]bool{}
+		for _, fork := range forks {
+			if !github.MatchesAny(group.Repos, fork) {
+				continue
+			}
+			matched[fork.FullName] = true
+
+			result := o.checkConsistency(logger.WithFields(ctx, "repo", fork.Name, "owner", fork.Owner), client, fork, group.Ref)
+			if result.Status != "at_target" && result.Status != "synced" {
+				report.Consistent = false
+			}
+			report.Members = append(report.Members, result)
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("none of your forks match group %q", o.group)
+		}
+		sort.Slice(report.Members, func(i, j int) bool {
+			return report.Members[i].Name < report.Members[j].Name
+		})
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, report, o.fields); err != nil {
+				log.Errorf("Failed to generate JSON output: %v", err)
+			}
+			return nil
+		}
+
+		fmt.Printf("Group %s, target %s:\n", report.Group, report.Ref)
+		for _, member := range report.Members {
+			switch member.Status {
+			case "at_target":
+				fmt.Printf("%s %s is on %s\n", successIcon, member.Name, report.Ref)
+			case "synced":
+				fmt.Printf("%s Moved %s to %s (was behind by %d commits)\n", syncIcon, member.Name, report.Ref, member.BehindBy)
+			case "behind":
+				fmt.Printf("%s %s is behind %s by %d commits\n", syncIcon, member.Name, report.Ref, member.BehindBy)
+			case "ahead":
+				fmt.Printf("%s %s has %d commits beyond %s\n", skipIcon, member.Name, member.AheadBy, report.Ref)
+			case "diverged":
+				fmt.Printf("%s %s has diverged from %s (behind %d, ahead %d)\n", errorIcon, member.Name, report.Ref, member.BehindBy, member.AheadBy)
+			case "error":
+				fmt.Printf("%s Error checking %s: %s\n", errorIcon, member.Name, member.Error)
+			}
+		}
+		return nil
+	},
+}
+
+// checkConsistency compares a group member with the target ref and, with --sync,
+// fast-forwards it to the ref when it is strictly behind.
+func (o *consistencyOptions) checkConsistency(ctx context.Context, client *github.Client, fork github.Repository, ref string) ConsistencyResult {
+	result := ConsistencyResult{Name: fork.Name}
+
+	comparison, err := client.CompareWithUpstreamRef(ctx, fork, ref)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	result.BehindBy = comparison.BehindBy
+	result.AheadBy = comparison.AheadBy
+
+	switch {
+	case comparison.BehindBy == 0 && comparison.AheadBy == 0:
+		result.Status = "at_target"
+	case comparison.BehindBy == 0:
+		result.Status = "ahead"
+	case comparison.AheadBy > 0:
+		result.Status = "diverged"
+	default:
+		result.Status = "behind"
+		if o.sync {
+			if _, err := client.FastForwardToUpstreamRef(ctx, fork, comparison.Branch, ref); err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			} else {
+				result.Status = "synced"
+			}
+		}
+	}
+	return result
+}
+
+func init() {
+	rootCmd.AddCommand(consistencyCmd)
+
+	consistencyCmd.Flags().String("group", "", "Name of the group to check (required)")
+	consistencyCmd.MarkFlagRequired("group")
+
+	consistencyCmd.Flags().Bool("sync", false, "Fast-forward members that are behind to exactly the target ref")
+
+	// JSON output flag with default from environment
+	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
+	consistencyCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	consistencyCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. consistent,members.name (implies --json)")
+
+	addDiscoveryFlags(consistencyCmd)
+}
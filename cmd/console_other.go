@@ -0,0 +1,28 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+)
+
+// prepareConsole is a no-op outside Windows, where terminals are UTF-8 and
+// understand ANSI escapes.
+func prepareConsole() {}
+
+// readHiddenLine reads a line from the terminal without echoing it, for
+// tokens and keys. When standard input is not a terminal, the line is read
+// as is.
+func readHiddenLine(in *bufio.Reader) (string, error) {
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		return cmd.Run()
+	}
+	if err := stty("-echo"); err == nil {
+		defer stty("echo")
+	}
+	return in.ReadString('\n')
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package cmd
+
+import (
+	"bufio"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// utf8CodePage is the Windows code page identifier for UTF-8.
+const utf8CodePage = 65001
+
+// prepareConsole switches the Windows console to UTF-8 and enables ANSI escape
+// processing, so icons and colors render instead of mojibake. If the console
+// cannot be switched (for example on legacy consoles), ASCII status markers
+// are used instead of emoji.
+func prepareConsole() {
+	handle := windows.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console (redirected to a file or pipe); output is plain bytes
+		return
+	}
+	windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+
+	setOutputCP := windows.NewLazySystemDLL("kernel32.dll").NewProc("SetConsoleOutputCP")
+	if ok, _, _ := setOutputCP.Call(utf8CodePage); ok == 0 {
+		noEmoji = true
+	}
+}
+
+// readHiddenLine reads a line from the console without echoing it, for tokens
+// and keys. When standard input is not a console, the line is read as is.
+func readHiddenLine(in *bufio.Reader) (string, error) {
+	handle := windows.Handle(os.Stdin.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err == nil {
+		windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT)
+		defer windows.SetConsoleMode(handle, mode)
+	}
+	return in.ReadString('\n')
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TFMV/furca/github"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// DiffFilesReport lists the files that syncing a fork would change.
+type DiffFilesReport struct {
+	Fork           string              `json:"fork"`
+	Upstream       string              `json:"upstream"`
+	Branch         string              `json:"branch"`
+	UpstreamBranch string              `json:"upstream_branch"`
+	BehindBy       int                 `json:"behind_by"`
+	Files          []github.FileChange `json:"files"`
+	Additions      int                 `json:"additions"`
+	Deletions      int                 `json:"deletions"`
+	// Truncated is set when GitHub listed the maximum number of files, so
+	// further changes may be missing
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// diffFilesOptions holds the flags of a single diff-files invocation.
+type diffFilesOptions struct {
+	paths      string
+	jsonOutput bool
+	fields     fieldSet // Fields of the JSON output to keep, all if nil
+}
+
+// newDiffFilesOptions reads the options of a diff-files invocation from its flags.
+func newDiffFilesOptions(flags *pflag.FlagSet) (*diffFilesOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &diffFilesOptions{
+		paths:      r.string("paths"),
+		jsonOutput: r.jsonOutput(),
+		fields:     r.fields(),
+	}
+	return o, r.err
+}
+
+// diffFilesCmd represents the diff-files command
+var diffFilesCmd = &cobra.Command{
+	Use:   "diff-files OWNER/REPO",
+	Short: "Preview the files that syncing a fork would change",
+	Long: `The diff-files command lists the files changed by the upstream commits a
+fork is missing, with the lines added and deleted in each, without syncing.
+The owner defaults to the authenticated user.
+
+With --paths, only files matching one of the comma-separated glob patterns are
+listed; "**" matches any number of directories.
+
+Example:
+  furca diff-files my-fork --paths 'src/**,go.mod'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newDiffFilesOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if err := o.fields.check(DiffFilesReport{}); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+		if err := checkPatterns(o.paths, github.ValidatePathPattern); err != nil {
+			return fmt.Errorf("invalid --paths: %w", err)
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+
+		owner, name, ok := strings.Cut(args[0], "/")
+		if !ok {
+			owner, name = client.User(), args[0]
+		}
+		fork, err := client.GetFork(ctx, owner, name)
+		if err != nil {
+			return err
+		}
+
+		// Compare the same branches sync would
+		repoConfig, err := client.GetRepoConfig(ctx, fork)
+		if err != nil {
+			return fmt.Errorf("failed to read repository config: %w", err)
+		}
+		fork.Branch = repoConfig.Branch
+		fork.UpstreamRef = repoConfig.UpstreamRef
+
+		comparison, err := client.CompareWithUpstream(ctx, fork)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s with upstream: %w", fork.FullName, err)
+		}
+
+		report := DiffFilesReport{
+			Fork:           fork.FullName,
+			Upstream:       fork.ParentOwner + "/" + fork.ParentName,
+			Branch:         comparison.Branch,
+			UpstreamBranch: comparison.UpstreamBranch,
+			BehindBy:       comparison.BehindBy,
+			Files:          []github.FileChange{},
+		}
+		if comparison.BehindBy > 0 {
+			changes, err := client.IncomingChanges(ctx, fork, comparison)
+			if err != nil {
+				return fmt.Errorf("failed to list upstream changes: %w", err)
+			}
+			report.Truncated = len(changes) >= github.IncomingFileLimit
+
+			patterns := parsePathPatterns(o.paths)
+			for _, change := range changes {
+				if len(patterns) > 0 && len(github.MatchingFiles([]string{change.Path}, patterns)) == 0 {
+					continue
+				}
+				report.Files = append(report.Files, change)
+				report.Additions += change.Additions
+				report.Deletions += change.Deletions
+			}
+		}
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, report, o.fields); err != nil {
+				return fmt.Errorf("failed to generate JSON output: %w", err)
+			}
+			return nil
+		}
+
+		if report.BehindBy == 0 {
+			fmt.Printf("%s %s is up to date with upstream\n", successIcon, report.Fork)
+			return nil
+		}
+		fmt.Printf("%s %s %s is behind %s %s by %s commits\n", syncIcon, report.Fork, report.Branch, report.Upstream, report.UpstreamBranch,
+			formatBehind(comparison.BehindBy, comparison.BehindCapped))
+		if len(report.Files) == 0 {
+			fmt.Printf("%s No incoming changes match %s\n", infoIcon, o.paths)
+			return nil
+		}
+
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, file := range report.Files {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", file.Status, file.Path,
+				color.GreenString("+%d", file.Additions), color.RedString("-%d", file.Deletions))
+		}
+		w.Flush()
+		fmt.Printf("\n%s %d files changed, %d additions, %d deletions\n", summaryIcon, len(report.Files), report.Additions, report.Deletions)
+		if report.Truncated {
+			fmt.Printf("%s %s\n", warnIcon, color.YellowString("GitHub lists at most %d changed files; more files may change", github.IncomingFileLimit))
+		}
+		return nil
+	},
+}
+
+// parsePathPatterns splits a comma-separated list of path globs.
+func parsePathPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// checkPatterns reports the first malformed glob in a comma-separated list,
+// which would otherwise silently match nothing.
+func checkPatterns(value string, validate func(string) error) error {
+	for _, pattern := range parsePathPatterns(value) {
+		if err := validate(pattern); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(diffFilesCmd)
+
+	diffFilesCmd.Flags().String("paths", "", "Only list files matching these comma-separated globs (e.g. 'src/**,docs/**')")
+
+	// JSON output flag with default from environment
+	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
+	diffFilesCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	diffFilesCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. behind_by,files (implies --json)")
+}
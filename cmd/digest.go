@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// digestListLimit is the number of forks listed in each section of a digest.
+const digestListLimit = 10
+
+// digestOptions holds the flags of a single digest invocation.
+type digestOptions struct {
+	period string
+	format string
+	out    string
+	times  timestamps
+}
+
+// newDigestOptions reads the options of a digest invocation from its flags.
+func newDigestOptions(flags *pflag.FlagSet) (*digestOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &digestOptions{
+		period: r.string("period"),
+		format: r.string("format"),
+		out:    r.string("out"),
+		times:  r.timestamps(),
+	}
+	return o, r.err
+}
+
+// digest summarizes what happened to the forks over a period.
+type digest struct {
+	From, To string
+
+	AuditLog    bool          // AUDIT_LOG is set, so syncs could be counted
+	Syncs       int           // Merges and fast-forwards made in the period
+	SyncedForks int           // Distinct forks synced in the period
+	Rollbacks   int           // Syncs rolled back in the period
+	MostSynced  []digestCount // Forks synced most often, at most digestListLimit
+
+	Forks         int     // Forks in the saved snapshot
+	Checked       int     // Forks checked in the period
+	Behind        int     // Checked forks that were behind when last checked
+	AverageBehind float64 // Commits the checked forks were behind on average
+	Stalest       []forkSnapshot
+
+	Problems []digestProblem // Forks whose last runs failed, at most digestListLimit
+	More     int             // Problems left out of the list
+}
+
+// digestCount is the number of syncs of a fork.
+type digestCount struct {
+	Fork  string
+	Count int
+}
+
+// digestProblem is a fork whose recent runs failed.
+type digestProblem struct {
+	Fork        string
+	Failures    int
+	LastError   string
+	Since       string
+	Quarantined bool
+}
+
+// digestMarkdown renders a digest as Markdown.
+var digestMarkdown = template.Must(template.New("digest").Parse(`# Furca digest
+
+{{.From}} to {{.To}}
+
+## Syncs
+
+{{if .AuditLog -}}
+{{.Syncs}} syncs of {{.SyncedForks}} forks{{if .Rollbacks}}, {{.Rollbacks}} rolled back{{end}}.
+{{range .MostSynced}}
+- {{.Fork}}: {{.Count}}
+{{- end}}
+{{- else -}}
+Not recorded; set AUDIT_LOG to count syncs.
+{{- end}}
+
+## Drift
+
+{{.Checked}} of {{.Forks}} forks checked, {{.Behind}} of them behind; the checked forks were {{printf "%.1f" .AverageBehind}} commits behind on average.
+{{range .Stalest}}
+- {{.Name}}: {{.BehindBy}} behind
+{{- end}}
+
+## Problems
+
+{{if .Problems -}}
+{{range .Problems -}}
+- {{.Fork}}: failed {{.Failures}} runs in a row since {{.Since}}{{if .Quarantined}} (quarantined){{end}}: {{.LastError}}
+{{end -}}
+{{if .More}}- and {{.More}} more
+{{end -}}
+{{else -}}
+No failures.
+{{end -}}
+`))
+
+// digestHTML renders a digest as an HTML page.
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Furca digest</title></head>
+<body>
+<h1>Furca digest</h1>
+<p>{{.From}} to {{.To}}</p>
+<h2>Syncs</h2>
+{{if .AuditLog -}}
+<p>{{.Syncs}} syncs of {{.SyncedForks}} forks{{if .Rollbacks}}, {{.Rollbacks}} rolled back{{end}}.</p>
+{{if .MostSynced}}<ul>
+{{range .MostSynced}}<li>{{.Fork}}: {{.Count}}</li>
+{{end}}</ul>
+{{end -}}
+{{else -}}
+<p>Not recorded; set AUDIT_LOG to count syncs.</p>
+{{end -}}
+<h2>Drift</h2>
+<p>{{.Checked}} of {{.Forks}} forks checked, {{.Behind}} of them behind; the checked forks were {{printf "%.1f" .AverageBehind}} commits behind on average.</p>
+{{if .Stalest}}<ul>
+{{range .Stalest}}<li>{{.Name}}: {{.BehindBy}} behind</li>
+{{end}}</ul>
+{{end -}}
+<h2>Problems</h2>
+{{if .Problems -}}
+<ul>
+{{range .Problems}}<li>{{.Fork}}: failed {{.Failures}} runs in a row since {{.Since}}{{if .Quarantined}} (quarantined){{end}}: {{.LastError}}</li>
+{{end}}{{if .More}}<li>and {{.More}} more</li>
+{{end}}</ul>
+{{else -}}
+<p>No failures.</p>
+{{end -}}
+</body>
+</html>
+`))
+
+// digestCmd represents the digest command
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize the syncs, drift, and failures of a period for email",
+	Long: `The digest command summarizes a period, a week by default, from Furca's
+own records: the syncs made, as recorded in the audit log (AUDIT_LOG); the
+drift of the forks checked, from the states saved by sync and ci-check runs;
+and the forks whose runs keep failing. It makes no API calls.
+
+The digest is written as Markdown, or as an HTML page with --format html,
+ready to be piped into mail from cron:
+
+  furca digest --format html | mail -a 'Content-Type: text/html' -s 'Fork digest' team@example.com`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newDigestOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if o.format != "markdown" && o.format != "html" {
+			return fmt.Errorf("invalid --format %q: must be markdown or html", o.format)
+		}
+		period, err := parseWindow(o.period)
+		if err != nil {
+			return fmt.Errorf("invalid --period: %w", err)
+		}
+
+		to := time.Now()
+		from := to.Add(-period)
+		d, err := buildDigest(from, to, o.times)
+		if err != nil {
+			return err
+		}
+
+		out := io.Writer(os.Stdout)
+		if o.out != "" && o.out != "-" {
+			file, err := os.Create(o.out)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", o.out, err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := d.write(out, o.format); err != nil {
+			return fmt.Errorf("failed to write digest: %w", err)
+		}
+		if o.out != "" && o.out != "-" {
+			fmt.Fprintf(os.Stderr, "%s Wrote the digest to %s\n", successIcon, o.out)
+		}
+		return nil
+	},
+}
+
+// write renders the digest to w in the format, markdown or html.
+func (d *digest) write(w io.Writer, format string) error {
+	if format == "html" {
+		return digestHTML.Execute(w, d)
+	}
+	return digestMarkdown.Execute(w, d)
+}
+
+// buildDigest gathers the digest of the period from the audit log, the fork
+// snapshot, and the failure records, with times formatted by times.
+func buildDigest(from, to time.Time, times timestamps) (*digest, error) {
+	d := &digest{From: times.format(from), To: times.format(to)}
+
+	if path := viper.GetString("AUDIT_LOG"); path != "" {
+		d.AuditLog = true
+		if err := d.countSyncs(path, from, times); err != nil {
+			return nil, err
+		}
+	}
+
+	snap, err := loadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fork snapshot: %w", err)
+	}
+	d.Forks = len(snap.Forks)
+	var totalBehind int
+	for _, fork := range snap.Forks {
+		if fork.CheckedAt.Before(from) {
+			continue
+		}
+		d.Checked++
+		totalBehind += fork.BehindBy
+		if fork.BehindBy > 0 {
+			d.Behind++
+			d.Stalest = append(d.Stalest, fork)
+		}
+	}
+	if d.Checked > 0 {
+		d.AverageBehind = float64(totalBehind) / float64(d.Checked)
+	}
+	slices.SortFunc(d.Stalest, func(a, b forkSnapshot) int {
+		return cmp.Or(cmp.Compare(b.BehindBy, a.BehindBy), cmp.Compare(a.Name, b.Name))
+	})
+	d.Stalest = d.Stalest[:min(len(d.Stalest), digestListLimit)]
+
+	failures, err := loadFailures()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load failure counts: %w", err)
+	}
+	after := viper.GetInt("QUARANTINE_AFTER")
+	for name, record := range failures {
+		if record.LastFailure.Before(from) && !record.quarantined(after) {
+			continue
+		}
+		d.Problems = append(d.Problems, digestProblem{
+			Fork:        name,
+			Failures:    record.Count,
+			LastError:   record.LastError,
+			Since:       times.format(record.FirstFailure),
+			Quarantined: record.quarantined(after),
+		})
+	}
+	slices.SortFunc(d.Problems, func(a, b digestProblem) int {
+		return cmp.Or(cmp.Compare(b.Failures, a.Failures), cmp.Compare(a.Fork, b.Fork))
+	})
+	if len(d.Problems) > digestListLimit {
+		d.More = len(d.Problems) - digestListLimit
+		d.Problems = d.Problems[:digestListLimit]
+	}
+	return d, nil
+}
+
+// countSyncs counts the syncs and rollbacks recorded in the audit log since
+// from, reading record times with times. Records whose time cannot be read,
+// for example because TIME_FORMAT changed since they were written, are left
+// out.
+func (d *digest) countSyncs(path string, from time.Time, times timestamps) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("failed to parse the audit log: %w", err)
+		}
+		if t, err := times.parse(record.Time); err != nil || t.Before(from) {
+			continue
+		}
+		switch record.Action {
+		case "merge_upstream", "fast_forward":
+			d.Syncs++
+			counts[record.Fork]++
+		case "rollback":
+			d.Rollbacks++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	d.SyncedForks = len(counts)
+	for fork, count := range counts {
+		d.MostSynced = append(d.MostSynced, digestCount{Fork: fork, Count: count})
+	}
+	slices.SortFunc(d.MostSynced, func(a, b digestCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Fork, b.Fork))
+	})
+	d.MostSynced = d.MostSynced[:min(len(d.MostSynced), digestListLimit)]
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(digestCmd)
+
+	digestCmd.Flags().String("period", "7d", "Period to summarize, ending now, such as 7d, 2w, or 36h")
+	digestCmd.Flags().String("format", "markdown", "Digest format (markdown or html)")
+	digestCmd.Flags().String("out", "", "Write the digest to this file instead of standard output")
+}
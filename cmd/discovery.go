@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/TFMV/furca/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// discoveryCursorFile is the state file holding progress of an interrupted discovery.
+const discoveryCursorFile = "discovery.json"
+
+// discoveryOptions holds the flags that choose the forks a command works on.
+type discoveryOptions struct {
+	method  string // How forks are found: list or search
+	exclude string // Comma-separated globs of forks to leave alone
+	topic   string // Only forks with this GitHub topic, if set
+	resume  bool   // Continue an interrupted discovery instead of restarting it
+}
+
+// addDiscoveryFlags adds the flags read into discoveryOptions to a command
+// that discovers forks.
+func addDiscoveryFlags(cmd *cobra.Command) {
+	// How forks are found, with default from environment
+	defaultDiscovery := viper.GetString("DISCOVERY")
+	if defaultDiscovery == "" {
+		defaultDiscovery = "list"
+	}
+	cmd.Flags().String("discovery", defaultDiscovery, "How to find forks: list every repository, or search for forks with the search API (faster for large accounts)")
+
+	// Forks to leave alone, with default from environment
+	defaultExclude := viper.GetString("EXCLUDE")
+	cmd.Flags().String("exclude", defaultExclude, "Leave alone forks matching these comma-separated globs, such as 'archive-*'")
+
+	// Forks to manage, by topic, with default from environment
+	defaultTopic := viper.GetString("TOPIC")
+	cmd.Flags().String("topic", defaultTopic, "Only manage forks with this GitHub topic (see furca adopt)")
+
+	// Resume an interrupted fork discovery
+	cmd.Flags().Bool("resume", false, "Continue an interrupted fork discovery instead of starting over")
+}
+
+// topicPattern matches the topic names GitHub accepts.
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// discoverForks lists the forks Furca manages: the authenticated user's forks,
+// except those matching --exclude, limited to those with the --topic topic if
+// one is set. See discoverAllForks.
+func discoverForks(ctx context.Context, client *github.Client, d discoveryOptions) ([]github.Repository, bool, error) {
+	if d.topic != "" && !topicPattern.MatchString(d.topic) {
+		return nil, false, fmt.Errorf("invalid --topic %q: GitHub topics consist of lowercase letters, digits, and hyphens", d.topic)
+	}
+	if err := checkPatterns(d.exclude, github.ValidatePattern); err != nil {
+		return nil, false, fmt.Errorf("invalid --exclude: %w", err)
+	}
+	forks, complete, err := discoverAllForks(ctx, client, d)
+	if patterns := parsePathPatterns(d.exclude); len(patterns) > 0 {
+		var kept []github.Repository
+		for _, fork := range forks {
+			if !github.MatchesAny(patterns, fork) {
+				kept = append(kept, fork)
+			}
+		}
+		logger.FromContext(ctx).Infof("Leaving out %d forks matching --exclude %s", len(forks)-len(kept), d.exclude)
+		forks = kept
+	}
+	if err != nil || d.topic == "" {
+		return forks, complete, err
+	}
+
+	var tagged []github.Repository
+	for _, fork := range forks {
+		if slices.Contains(fork.Topics, d.topic) {
+			tagged = append(tagged, fork)
+		}
+	}
+	logger.FromContext(ctx).Infof("Managing the %d forks with topic %s; ignoring %d others", len(tagged), d.topic, len(forks)-len(tagged))
+	return tagged, complete, nil
+}
+
+// discoverAllForks lists the authenticated user's forks, or searches for them
+// with --discovery search. If discovery fails partway, progress is saved so that
+// a later run with --resume can continue, and the forks found so far are returned
+// with complete set to false. An error is returned only if nothing at all could
+// be discovered.
+func discoverAllForks(ctx context.Context, client *github.Client, d discoveryOptions) (forks []github.Repository, complete bool, err error) {
+	log := logger.FromContext(ctx)
+	if d.method != "list" && d.method != "search" {
+		return nil, false, fmt.Errorf("invalid --discovery %q: use list or search", d.method)
+	}
+
+	cursor := &github.DiscoveryCursor{}
+	if d.resume {
+		found, err := state.Load(discoveryCursorFile, cursor)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			log.Info("No interrupted discovery to resume, starting from the beginning")
+		}
+	}
+
+	if d.method == "search" {
+		forks, err = client.SearchForks(ctx, cursor)
+	} else {
+		forks, err = client.DiscoverForks(ctx, cursor)
+	}
+	if err != nil {
+		if cursor.Page == 0 && len(forks) == 0 {
+			return nil, false, err
+		}
+		if saveErr := state.Save(discoveryCursorFile, cursor); saveErr != nil {
+			log.Warnf("Failed to save discovery progress: %v", saveErr)
+		}
+		log.Warnf("Fork discovery stopped early: %v", err)
+		log.Warnf("Continuing with the %d forks found so far; run again with --resume to continue discovery", len(forks))
+		return forks, false, nil
+	}
+
+	if err := state.Remove(discoveryCursorFile); err != nil {
+		log.Warnf("Failed to clear discovery progress: %v", err)
+	}
+	return forks, true, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// sopsMetadata matches the metadata SOPS adds to the files it encrypts, in
+// dotenv, YAML, and JSON form.
+var sopsMetadata = regexp.MustCompile(`(?m)^(sops_mac=|sops:\s*$|\s*"sops":\s*\{)`)
+
+// sopsTypes maps config file formats to the file types sops understands.
+var sopsTypes = map[string]string{
+	"env":  "dotenv",
+	"yaml": "yaml",
+	"json": "json",
+}
+
+// decryptConfig decrypts a config file encrypted with SOPS by running the sops
+// command, which finds the age, PGP, or cloud KMS key the file was encrypted
+// for, such as the age key in SOPS_AGE_KEY_FILE or SOPS_AGE_KEY.
+func decryptConfig(path, format string) ([]byte, error) {
+	fileType, ok := sopsTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("%s is encrypted with SOPS, which cannot decrypt %s files", path, format)
+	}
+	out, err := exec.Command("sops", "--decrypt", "--input-type", fileType, "--output-type", fileType, path).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is encrypted with SOPS, but the sops command is not installed", path)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("failed to decrypt %s with sops: %s", path, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s with sops: %w", path, err)
+	}
+	return out, nil
+}
+
+// warnIfExposed warns when a config file holding secret settings, stored
+// unencrypted, can be read by users other than its owner.
+func warnIfExposed(path string, keys []string) {
+	info, err := os.Stat(path)
+	if err != nil || !exposedToOthers(info) {
+		return
+	}
+	var secrets []string
+	for _, key := range keys {
+		if s, ok := lookupSetting(key); ok && s.Secret {
+			secrets = append(secrets, s.Key)
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s holds %s and is accessible to other users (mode %04o); run chmod 600 %s or encrypt it with sops\n",
+		path, strings.Join(secrets, ", "), info.Mode().Perm(), path)
+}
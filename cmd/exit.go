@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Process exit codes returned by Execute.
+const (
+	// ExitOK means the command succeeded
+	ExitOK = 0
+
+	// ExitFailure means the command failed, or a check it ran did not pass
+	ExitFailure = 1
+)
+
+// ExitError is returned by commands that need the process to exit with a
+// specific code. When Err is nil, the command has already reported the failure
+// and nothing more is printed.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
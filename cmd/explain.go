@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+)
+
+// Explanation is the decision trace of furca explain for a single fork.
+type Explanation struct {
+	Fork     string          `json:"fork"`
+	Upstream string          `json:"upstream"`
+	Filters  []FilterCheck   `json:"filters"`
+	Steps    []string        `json:"steps"`
+	Results  []SyncResult    `json:"results"`
+	Plan     []PlannedAction `json:"plan,omitempty"`
+	Selected bool            `json:"selected"` // The fork passes every filter of a sync run
+}
+
+// FilterCheck is the outcome of one of the filters a sync run applies before
+// checking forks.
+type FilterCheck struct {
+	Filter string `json:"filter"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// decisionTrace collects the steps of sync decisions for furca explain. A nil
+// decisionTrace records nothing.
+type decisionTrace struct {
+	steps []string
+}
+
+// step records a step of the decision, described by a format string.
+func (t *decisionTrace) step(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, fmt.Sprintf(format, args...))
+}
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain OWNER/REPO",
+	Short: "Explain step by step why a fork would or would not be synced",
+	Long: `The explain command walks through the decisions a sync run would make for a
+single fork right now, without changing anything: the filters that select forks
+(topic, group, policy, --since, --shard, quarantine), the repository config applied,
+the branches compared, the comparison result, the strategy chosen, and what
+would finally be done or what blocks it. The owner defaults to the
+authenticated user.
+
+It accepts the same flags as sync, so that the explanation matches the run
+being debugged.
+
+Example:
+  furca explain my-fork --only-if-paths 'src/**'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, r := readSyncOptions(cmd.Flags())
+		if r.err != nil {
+			return fmt.Errorf("failed to read flags: %w", r.err)
+		}
+		if err := o.fields.check(Explanation{}); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+		o.dryRun = true
+		o.trace = &decisionTrace{}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+
+		owner, name, ok := strings.Cut(args[0], "/")
+		if !ok {
+			owner, name = client.User(), args[0]
+		}
+		fork, err := client.GetFork(ctx, owner, name)
+		if err != nil {
+			return err
+		}
+
+		policy, filters, err := o.checkFilters(ctx, client, fork)
+		if err != nil {
+			return err
+		}
+		explanation := Explanation{
+			Fork:     fork.FullName,
+			Upstream: fork.ParentOwner + "/" + fork.ParentName,
+			Filters:  filters,
+			Selected: true,
+		}
+		for _, filter := range filters {
+			explanation.Selected = explanation.Selected && filter.Passed
+		}
+
+		if o.branchPattern != "" {
+			explanation.Results = o.syncBranches(ctx, client, policy, fork)
+		} else {
+			explanation.Results = []SyncResult{o.syncForkWithTimeout(ctx, client, policy, fork)}
+		}
+		explanation.Steps = o.trace.steps
+		for _, result := range explanation.Results {
+			for _, action := range result.Plan {
+				action.Fork = result.Name
+				explanation.Plan = append(explanation.Plan, action)
+			}
+		}
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, explanation, o.fields); err != nil {
+				return fmt.Errorf("failed to generate JSON output: %w", err)
+			}
+			return nil
+		}
+		printExplanation(explanation)
+		return nil
+	},
+}
+
+// checkFilters applies each filter of a sync run to the fork and returns the
+// policy along with the outcome of every filter.
+func (o *syncOptions) checkFilters(ctx context.Context, client *github.Client, fork github.Repository) (*github.Policy, []FilterCheck, error) {
+	var checks []FilterCheck
+	check := func(filter string, passed bool, format string, args ...any) {
+		checks = append(checks, FilterCheck{Filter: filter, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if fork.Detached {
+		check("discovery", true, "upstream %s/%s is mapped under upstreams in the config", fork.ParentOwner, fork.ParentName)
+	} else {
+		check("discovery", true, "GitHub reports %s/%s as the parent", fork.ParentOwner, fork.ParentName)
+	}
+
+	if o.discovery.topic != "" {
+		if slices.Contains(fork.Topics, o.discovery.topic) {
+			check("topic", true, "has topic %s", o.discovery.topic)
+		} else {
+			check("topic", false, "lacks topic %s; add it with furca adopt %s", o.discovery.topic, fork.Name)
+		}
+	}
+
+	if o.group != "" {
+		group, err := loadGroup(o.group)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load group: %w", err)
+		}
+		if github.MatchesAny(group.Repos, fork) {
+			check("group", true, "belongs to group %s", o.group)
+		} else {
+			check("group", false, "is not in group %s", o.group)
+		}
+	}
+
+	policy, allowed, err := applyPolicy(ctx, client, []github.Repository{fork})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply policy: %w", err)
+	}
+	switch {
+	case policy == nil:
+	case !policy.AppliesTo(fork):
+		check("policy", true, "the policy in %s only governs forks owned by %s", policyRepo, policy.Org)
+	case len(allowed) == 0:
+		check("policy", false, "excluded by the include and exclude patterns of the policy in %s", policyRepo)
+	default:
+		check("policy", true, "allowed by the policy in %s", policyRepo)
+	}
+
+	if o.since != "" {
+		window, err := parseWindow(o.since)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --since value: %w", err)
+		}
+		if _, dormant := filterActiveSince([]github.Repository{fork}, time.Now().Add(-window)); dormant > 0 {
+			check("since", false, "upstream was last pushed to at %s, before the --since window of %s", o.times.format(fork.ParentPushedAt), o.since)
+		} else {
+			check("since", true, "upstream was pushed to at %s, within the --since window of %s", o.times.format(fork.ParentPushedAt), o.since)
+		}
+	}
+
+	shard, err := parseShard(o.shard)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --shard value: %w", err)
+	}
+	switch {
+	case shard == nil:
+	case shard.contains(fork):
+		check("shard", true, "belongs to shard %d/%d", shard.Index, shard.Count)
+	default:
+		check("shard", false, "belongs to another shard than %d/%d", shard.Index, shard.Count)
+	}
+
+	failures, err := loadFailures()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load failure counts: %w", err)
+	}
+	if record := failures[fork.Name]; record.quarantined(o.quarantineAfter) {
+		check("quarantine", false, "failed %d runs in a row (last: %s); run 'furca quarantine clear %s' to retry", record.Count, record.LastError, fork.Name)
+	} else if record.Count > 0 {
+		check("quarantine", true, "failed %d runs in a row, fewer than --quarantine-after %d", record.Count, o.quarantineAfter)
+	}
+	return policy, checks, nil
+}
+
+// explainBranch describes which fork branch is compared with which upstream ref.
+func explainBranch(fork github.Repository, repoConfig *github.RepoConfig) string {
+	branch := fmt.Sprintf("the default branch %s", fork.DefaultBranch)
+	if repoConfig.Branch != "" {
+		branch = fmt.Sprintf("branch %s, set in %s", repoConfig.Branch, github.RepoConfigPath)
+	}
+	switch {
+	case repoConfig.UpstreamRef != "":
+		return fmt.Sprintf("Comparing %s with upstream %s, pinned in %s", branch, repoConfig.UpstreamRef, github.RepoConfigPath)
+	case fork.UpstreamRef != "":
+		return fmt.Sprintf("Comparing %s with upstream %s, pinned by --upstream-ref", branch, fork.UpstreamRef)
+	default:
+		return fmt.Sprintf("Comparing %s with the same branch upstream", branch)
+	}
+}
+
+// explainStrategy describes the sync strategy chosen and where it came from.
+func explainStrategy(configured, strategy string) string {
+	switch {
+	case configured != "":
+		return fmt.Sprintf("Strategy %s, set in %s", strategy, github.RepoConfigPath)
+	case strategy != "":
+		return fmt.Sprintf("Strategy %s, set by the policy in %s", strategy, policyRepo)
+	default:
+		return fmt.Sprintf("Strategy %s, the default", github.StrategyMerge)
+	}
+}
+
+// explainComparison describes the result of comparing the fork with upstream.
+func explainComparison(fork github.Repository, comparison *github.Comparison) string {
+	var notes []string
+	if comparison.Renamed {
+		notes = append(notes, fmt.Sprintf("upstream renamed %s to %s", comparison.Branch, comparison.UpstreamBranch))
+	}
+	if comparison.Mapped {
+		notes = append(notes, fmt.Sprintf("the fork's %s was compared with the upstream default branch %s", comparison.Branch, comparison.UpstreamBranch))
+	}
+	text := fmt.Sprintf("%s is behind upstream %s/%s %s by %s commits and ahead by %d",
+		comparison.Branch, fork.ParentOwner, fork.ParentName, comparison.UpstreamBranch, formatBehind(comparison.BehindBy, comparison.BehindCapped), comparison.AheadBy)
+	if len(notes) > 0 {
+		text += " (" + strings.Join(notes, "; ") + ")"
+	}
+	return text
+}
+
+// explainFiles describes the files changed by the incoming commits, as far as
+// they matter to the decision.
+func explainFiles(files []string, onlyIfPaths string) string {
+	text := fmt.Sprintf("The incoming commits change %d files", len(files))
+	if len(files) >= github.IncomingFileLimit {
+		text = fmt.Sprintf("The incoming commits change at least %d files (GitHub lists no more)", len(files))
+	}
+	if onlyIfPaths != "" {
+		text += fmt.Sprintf(", %d of them matching --only-if-paths %s", len(github.MatchingFiles(files, parsePathPatterns(onlyIfPaths))), onlyIfPaths)
+	}
+	return text
+}
+
+// printExplanation prints a decision trace for the console.
+func printExplanation(e Explanation) {
+	fmt.Printf("%s Explaining %s (upstream %s)\n", infoIcon, e.Fork, e.Upstream)
+
+	fmt.Printf("\nFilters:\n")
+	for _, filter := range e.Filters {
+		icon := successIcon
+		if !filter.Passed {
+			icon = skipIcon
+		}
+		fmt.Printf("%s %s: %s\n", icon, filter.Filter, filter.Detail)
+	}
+
+	fmt.Printf("\nDecision:\n")
+	for i, step := range e.Steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+
+	fmt.Printf("\nOutcome:\n")
+	for _, result := range e.Results {
+		switch {
+		case result.Error != "":
+			fmt.Printf("%s %s: %s (%s)\n", errorIcon, result.Name, result.Status, result.Error)
+		case result.Reason != "":
+			fmt.Printf("%s %s: %s (%s)\n", skipIcon, result.Name, result.Status, result.Reason)
+		case result.Status == "up_to_date":
+			fmt.Printf("%s %s: %s\n", successIcon, result.Name, result.Status)
+		default:
+			fmt.Printf("%s %s: %s\n", syncIcon, result.Name, result.Status)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Printf("   %s %s\n", warnIcon, warning)
+		}
+	}
+	writePlan(os.Stdout, e.Plan)
+
+	if !e.Selected {
+		fmt.Printf("\n%s A sync run would not process %s, because a filter above excludes it\n", skipIcon, e.Fork)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	addSyncFlags(explainCmd)
+	explainCmd.Flags().Lookup("fields").Usage = "Only output these comma-separated JSON fields, e.g. selected,filters (implies --json)"
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// exportOptions holds the flags of a single export invocation.
+type exportOptions struct {
+	format    string
+	out       string
+	sort      string
+	times     timestamps
+	discovery discoveryOptions
+}
+
+// newExportOptions reads the options of a export invocation from its flags.
+func newExportOptions(flags *pflag.FlagSet) (*exportOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &exportOptions{
+		format:    r.string("format"),
+		out:       r.string("out"),
+		sort:      r.string("sort"),
+		times:     r.timestamps(),
+		discovery: r.discovery(),
+	}
+	return o, r.err
+}
+
+// InventoryEntry describes one fork in an inventory export.
+type InventoryEntry struct {
+	Fork          string    `json:"fork"`   // owner/name
+	Parent        string    `json:"parent"` // owner/name of the upstream
+	Visibility    string    `json:"visibility"`
+	DefaultBranch string    `json:"default_branch"`
+	Detached      bool      `json:"detached"`     // Upstream is mapped in config
+	BehindBy      int       `json:"behind_by"`    // Last known drift, -1 if never checked
+	LastChecked   time.Time `json:"last_checked"` // Zero if never checked
+	LastSynced    time.Time `json:"last_synced"`  // Zero if never synced by Furca
+	Topics        []string  `json:"topics"`
+	Language      string    `json:"language"`
+
+	Stars          int       `json:"stars"`
+	Archived       bool      `json:"archived"`
+	PushedAt       time.Time `json:"pushed_at"` // Zero if unknown
+	ParentLanguage string    `json:"parent_language"`
+	ParentStars    int       `json:"parent_stars"`
+	ParentArchived bool      `json:"parent_archived"`
+	ParentPushedAt time.Time `json:"parent_pushed_at"` // Zero if unknown
+}
+
+// inventoryColumns are the CSV columns of an inventory, in order.
+var inventoryColumns = []string{"fork", "parent", "visibility", "default_branch", "detached", "behind_by", "last_checked", "last_synced", "topics", "language",
+	"stars", "archived", "pushed_at", "parent_language", "parent_stars", "parent_archived", "parent_pushed_at"}
+
+// inventoryOrders compares inventory entries by each field --sort accepts.
+// Counts and times put the largest and latest first, so that the most
+// important and most active projects lead, and archived repositories come first.
+var inventoryOrders = map[string]func(a, b InventoryEntry) int{
+	"fork":             func(a, b InventoryEntry) int { return cmp.Compare(a.Fork, b.Fork) },
+	"parent":           func(a, b InventoryEntry) int { return cmp.Compare(a.Parent, b.Parent) },
+	"language":         func(a, b InventoryEntry) int { return cmp.Compare(a.Language, b.Language) },
+	"parent_language":  func(a, b InventoryEntry) int { return cmp.Compare(a.ParentLanguage, b.ParentLanguage) },
+	"stars":            func(a, b InventoryEntry) int { return cmp.Compare(b.Stars, a.Stars) },
+	"parent_stars":     func(a, b InventoryEntry) int { return cmp.Compare(b.ParentStars, a.ParentStars) },
+	"pushed_at":        func(a, b InventoryEntry) int { return b.PushedAt.Compare(a.PushedAt) },
+	"parent_pushed_at": func(a, b InventoryEntry) int { return b.ParentPushedAt.Compare(a.ParentPushedAt) },
+	"behind_by":        func(a, b InventoryEntry) int { return cmp.Compare(b.BehindBy, a.BehindBy) },
+	"archived":         func(a, b InventoryEntry) int { return compareBool(b.Archived, a.Archived) },
+	"parent_archived":  func(a, b InventoryEntry) int { return compareBool(b.ParentArchived, a.ParentArchived) },
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an inventory of all your forks",
+	Long: `The export command writes a complete inventory of your forks, suitable for
+asset-management tools: each fork's parent, visibility, default branch,
+language, and topics, the star count, archived flag, and last push of both
+the fork and its parent, together with the drift and last sync recorded by
+earlier sync and ci-check runs.
+
+Forks are listed by name unless --sort names other fields, such as
+parent_stars to put the most important projects first. Counts and times sort
+largest and latest first.
+
+The inventory can be written as JSON or CSV, and read back on another machine
+with furca import.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newExportOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+
+		if o.format != "json" && o.format != "csv" {
+			return fmt.Errorf("invalid --format %q: must be json or csv", o.format)
+		}
+		var sortFields []string
+		if o.sort != "" {
+			for _, field := range strings.Split(o.sort, ",") {
+				field = strings.TrimSpace(field)
+				if inventoryOrders[field] == nil {
+					return fmt.Errorf("invalid --sort field %q: must be one of %s", field, strings.Join(slices.Sorted(maps.Keys(inventoryOrders)), ", "))
+				}
+				sortFields = append(sortFields, field)
+			}
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+
+		log.Info("Fetching forked repositories...")
+		forks, complete, err := discoverForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+		if !complete {
+			log.Warn("Fork discovery was incomplete; the inventory only covers the forks found so far")
+		}
+
+		snap, err := loadSnapshot()
+		if err != nil {
+			log.Warnf("Failed to load fork snapshot; drift will be missing: %v", err)
+			snap = &snapshot{}
+		}
+
+		inventory := make([]InventoryEntry, 0, len(forks))
+		for _, fork := range forks {
+			entry := InventoryEntry{
+				Fork:          fork.FullName,
+				Parent:        fork.ParentOwner + "/" + fork.ParentName,
+				Visibility:    fork.Visibility,
+				DefaultBranch: fork.DefaultBranch,
+				Detached:      fork.Detached,
+				BehindBy:      -1,
+				Topics:        fork.Topics,
+				Language:      fork.Language,
+
+				Stars:          fork.Stars,
+				Archived:       fork.Archived,
+				PushedAt:       fork.PushedAt,
+				ParentLanguage: fork.ParentLanguage,
+				ParentStars:    fork.ParentStars,
+				ParentArchived: fork.ParentArchived,
+				ParentPushedAt: fork.ParentPushedAt,
+			}
+			if known, ok := snap.Forks[fork.Name]; ok {
+				entry.BehindBy = known.BehindBy
+				entry.LastChecked = known.CheckedAt
+				entry.LastSynced = known.SyncedAt
+			}
+			inventory = append(inventory, entry)
+		}
+		sortInventory(inventory, sortFields)
+
+		out := io.Writer(os.Stdout)
+		if o.out != "" && o.out != "-" {
+			file, err := os.Create(o.out)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", o.out, err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err := writeInventory(out, o.format, inventory, o.times); err != nil {
+			return fmt.Errorf("failed to write inventory: %w", err)
+		}
+		if o.out != "" && o.out != "-" {
+			fmt.Fprintf(os.Stderr, "%s Exported %d forks to %s\n", successIcon, len(inventory), o.out)
+		}
+		return nil
+	},
+}
+
+// sortInventory orders the inventory by the given fields in turn, then by
+// fork name.
+func sortInventory(inventory []InventoryEntry, fields []string) {
+	slices.SortStableFunc(inventory, func(a, b InventoryEntry) int {
+		for _, field := range fields {
+			if c := inventoryOrders[field](a, b); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.Fork, b.Fork)
+	})
+}
+
+// writeInventory writes the inventory in the format, json or csv, with CSV
+// times formatted by times.
+func writeInventory(out io.Writer, format string, inventory []InventoryEntry, times timestamps) error {
+	if format == "csv" {
+		return writeInventoryCSV(out, inventory, times)
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inventory)
+}
+
+// writeInventoryCSV writes the inventory as CSV with a header row. Topics are
+// joined with semicolons and unknown times are left empty.
+func writeInventoryCSV(out io.Writer, inventory []InventoryEntry, times timestamps) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(inventoryColumns); err != nil {
+		return err
+	}
+	for _, e := range inventory {
+		if err := w.Write([]string{
+			e.Fork,
+			e.Parent,
+			e.Visibility,
+			e.DefaultBranch,
+			strconv.FormatBool(e.Detached),
+			strconv.Itoa(e.BehindBy),
+			formatTime(e.LastChecked, times),
+			formatTime(e.LastSynced, times),
+			strings.Join(e.Topics, ";"),
+			e.Language,
+			strconv.Itoa(e.Stars),
+			strconv.FormatBool(e.Archived),
+			formatTime(e.PushedAt, times),
+			e.ParentLanguage,
+			strconv.Itoa(e.ParentStars),
+			strconv.FormatBool(e.ParentArchived),
+			formatTime(e.ParentPushedAt, times),
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// readInventoryCSV parses an inventory written by writeInventoryCSV, reading
+// times with times.
+func readInventoryCSV(in io.Reader, times timestamps) ([]InventoryEntry, error) {
+	rows, err := csv.NewReader(in).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[name] = i
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	var inventory []InventoryEntry
+	for n, row := range rows[1:] {
+		entry := InventoryEntry{
+			Fork:          field(row, "fork"),
+			Parent:        field(row, "parent"),
+			Visibility:    field(row, "visibility"),
+			DefaultBranch: field(row, "default_branch"),
+			Language:      field(row, "language"),
+			BehindBy:      -1,
+
+			ParentLanguage: field(row, "parent_language"),
+		}
+		if entry.Fork == "" {
+			return nil, fmt.Errorf("row %d: missing fork", n+2)
+		}
+		entry.Detached, _ = strconv.ParseBool(field(row, "detached"))
+		if behind, err := strconv.Atoi(field(row, "behind_by")); err == nil {
+			entry.BehindBy = behind
+		}
+		entry.LastChecked, _ = times.parse(field(row, "last_checked"))
+		entry.LastSynced, _ = times.parse(field(row, "last_synced"))
+		entry.Stars, _ = strconv.Atoi(field(row, "stars"))
+		entry.Archived, _ = strconv.ParseBool(field(row, "archived"))
+		entry.PushedAt, _ = times.parse(field(row, "pushed_at"))
+		entry.ParentStars, _ = strconv.Atoi(field(row, "parent_stars"))
+		entry.ParentArchived, _ = strconv.ParseBool(field(row, "parent_archived"))
+		entry.ParentPushedAt, _ = times.parse(field(row, "parent_pushed_at"))
+		if topics := field(row, "topics"); topics != "" {
+			entry.Topics = strings.Split(topics, ";")
+		}
+		inventory = append(inventory, entry)
+	}
+	return inventory, nil
+}
+
+// formatTime formats t with times, or returns an empty string for the zero
+// time.
+func formatTime(t time.Time, times timestamps) string {
+	if t.IsZero() {
+		return ""
+	}
+	return times.format(t)
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().String("format", "json", "Inventory format (json or csv)")
+	exportCmd.Flags().String("out", "", "Write the inventory to this file instead of standard output")
+	exportCmd.Flags().String("sort", "", "Order forks by these comma-separated fields instead of by name: fork, parent, language, stars, pushed_at, archived, behind_by, or parent_ followed by language, stars, pushed_at, or archived")
+
+	addDiscoveryFlags(exportCmd)
+}
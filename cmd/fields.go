@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fieldSet is the projection of JSON output asked for with --fields: the keys
+// to keep, each with the fields to keep below it, or nil to keep all of it.
+// Fields of lists and maps apply to each of their elements, so that
+// "fork,status" keeps the fork and status of every per-repository result.
+type fieldSet map[string]fieldSet
+
+// parseFields parses a comma-separated list of JSON field names, with dots
+// for fields nested in others (for example plan.fork). It returns nil if the
+// list is empty, for output that is not projected.
+func parseFields(value string) (fieldSet, error) {
+	var fields fieldSet
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if fields == nil {
+			fields = fieldSet{}
+		}
+		set := fields
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("invalid field %q", path)
+			}
+			sub, seen := set[name]
+			if i == len(names)-1 {
+				// Naming a field keeps all of it, even if nested fields were named too
+				set[name] = nil
+				break
+			}
+			if seen && sub == nil {
+				break
+			}
+			if sub == nil {
+				sub = fieldSet{}
+				set[name] = sub
+			}
+			set = sub
+		}
+	}
+	return fields, nil
+}
+
+// check reports fields that the JSON output of v does not have, so that a
+// mistyped field fails the command before it does any work instead of being
+// silently left out.
+func (fs fieldSet) check(v any) error {
+	return fs.checkType(reflect.TypeOf(v), "")
+}
+
+func (fs fieldSet) checkType(t reflect.Type, parent string) error {
+	if fs == nil {
+		return nil
+	}
+	t = recordType(t)
+	if t.Kind() != reflect.Struct || isJSONValue(t) {
+		return fmt.Errorf("unknown fields below %q, which has none", parent)
+	}
+	known := jsonFields(t)
+	for _, name := range fs.names() {
+		path := name
+		if parent != "" {
+			path = parent + "." + name
+		}
+		field, ok := known[name]
+		if !ok {
+			valid := make([]string, 0, len(known))
+			for name := range known {
+				valid = append(valid, name)
+			}
+			sort.Strings(valid)
+			return fmt.Errorf("unknown field %q (valid fields are %s)", path, strings.Join(valid, ", "))
+		}
+		if err := fs[name].checkType(field, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// names returns the field names in fs in order, for deterministic errors.
+func (fs fieldSet) names() []string {
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// project returns v encoded as JSON with only the fields in fs, in the order
+// v has them, or all of v if fs is nil.
+func (fs fieldSet) project(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return fs.projectJSON(data, reflect.TypeOf(v))
+}
+
+// projectJSON projects data, the JSON encoding of a value of type t.
+func (fs fieldSet) projectJSON(data json.RawMessage, t reflect.Type) (json.RawMessage, error) {
+	if fs == nil || bytes.Equal(data, []byte("null")) {
+		return data, nil
+	}
+	t = elemType(t)
+	if isJSONValue(t) {
+		return data, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return nil, err
+		}
+		for i := range elems {
+			projected, err := fs.projectJSON(elems[i], t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = projected
+		}
+		return json.Marshal(elems)
+
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			projected, err := fs.projectJSON(value, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			values[key] = projected
+		}
+		return json.Marshal(values)
+
+	case reflect.Struct:
+		// Walk the object key by key to keep the order of the struct's fields
+		known := jsonFields(t)
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		out.WriteByte('{')
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := token.(string)
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			sub, ok := fs[key]
+			if !ok {
+				continue
+			}
+			if value, err = sub.projectJSON(value, known[key]); err != nil {
+				return nil, err
+			}
+			if out.Len() > 1 {
+				out.WriteByte(',')
+			}
+			name, _ := json.Marshal(key)
+			out.Write(name)
+			out.WriteByte(':')
+			out.Write(value)
+		}
+		out.WriteByte('}')
+		return out.Bytes(), nil
+	}
+	return data, nil
+}
+
+// jsonFields returns the type of each field of a struct by the name it is
+// encoded as, following encoding/json: fields tagged "-" and unexported
+// fields are left out, and the fields of embedded structs are promoted.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && elemType(field.Type).Kind() == reflect.Struct {
+			for name, promoted := range jsonFields(elemType(field.Type)) {
+				if _, ok := fields[name]; !ok {
+					fields[name] = promoted
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// elemType returns the type pointers of t point to.
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// recordType returns the type of the records a value of type t holds: t
+// itself, or the elements of lists and maps of them.
+func recordType(t reflect.Type) reflect.Type {
+	for {
+		t = elemType(t)
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if isJSONValue(t) {
+				return t
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// isJSONValue reports whether values of type t encode themselves, like
+// time.Time, and so have no fields to project.
+func isJSONValue(t reflect.Type) bool {
+	for _, m := range []reflect.Type{jsonMarshaler, textMarshaler} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return true
+		}
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestFields checks that --fields keeps only the fields asked for, of each
+// per-repository result and of summaries, and rejects fields the output lacks.
+func TestFields(t *testing.T) {
+	entries := []HotEntry{
+		{Fork: "octocat/widgets", Upstream: "acme/widgets", Commits: 12, PerWeek: 3, BehindBy: 4},
+		{Fork: "octocat/tools", Upstream: "acme/tools", BehindBy: -1, Error: "not found"},
+	}
+	report := ConsistencyReport{Group: "platform", Members: []ConsistencyResult{{Name: "widgets", Status: "synced", BehindBy: 2}}}
+
+	tests := []struct {
+		fields string
+		v      any
+		want   string // Compact JSON output, or the start of the error
+	}{
+		{"", entries, `[{"fork":"octocat/widgets","upstream":"acme/widgets","commits":12,"commits_per_week":3,"behind_by":4},{"fork":"octocat/tools","upstream":"acme/tools","commits":0,"commits_per_week":0,"behind_by":-1,"error":"not found"}]`},
+		{"behind_by, fork", entries, `[{"fork":"octocat/widgets","behind_by":4},{"fork":"octocat/tools","behind_by":-1}]`},
+		{"error", entries, `[{},{"error":"not found"}]`},
+		{"members.name,members.status", report, `{"members":[{"name":"widgets","status":"synced"}]}`},
+		{"members.name,members", report, `{"members":[{"name":"widgets","status":"synced","behind_by":2}]}`},
+		{"name", entries, `unknown field "name" (valid fields are behind_by, commits, commits_per_week, error, fork, upstream)`},
+		{"members.nme", report, `unknown field "members.nme"`},
+		{"fork.name", entries, `unknown fields below "fork"`},
+		{"plan..fork", entries, `invalid field "plan..fork"`},
+	}
+	for _, tt := range tests {
+		fields, err := parseFields(tt.fields)
+		if err == nil {
+			err = fields.check(tt.v)
+		}
+		var got string
+		if err != nil {
+			got = err.Error()
+		} else {
+			data, err := fields.project(tt.v)
+			if err != nil {
+				t.Fatalf("--fields %q: %v", tt.fields, err)
+			}
+			got = string(data)
+		}
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("--fields %q:\ngot  %s\nwant %s", tt.fields, got, tt.want)
+		}
+	}
+}
+
+// TestFieldsExamples checks that the example in the help of every --fields
+// flag only names fields the command's JSON output has.
+func TestFieldsExamples(t *testing.T) {
+	outputs := map[*cobra.Command]any{
+		applyCmd:       []ApplyResult(nil),
+		ciCheckCmd:     CICheckResult{},
+		consistencyCmd: ConsistencyReport{},
+		diffFilesCmd:   DiffFilesReport{},
+		explainCmd:     Explanation{},
+		hotCmd:         []HotEntry(nil),
+		planCmd:        SyncSummary{},
+		retargetCmd:    []RetargetResult(nil),
+		syncCmd:        SyncSummary{},
+	}
+	example := regexp.MustCompile(`e\.g\. (\S+)`)
+	for cmd, output := range outputs {
+		flag := cmd.Flags().Lookup("fields")
+		if flag == nil {
+			t.Errorf("%s has no --fields flag", cmd.Name())
+			continue
+		}
+		match := example.FindStringSubmatch(flag.Usage)
+		if match == nil {
+			t.Errorf("%s --fields has no example: %q", cmd.Name(), flag.Usage)
+			continue
+		}
+		fields, err := parseFields(match[1])
+		if err == nil {
+			err = fields.check(output)
+		}
+		if err != nil {
+			t.Errorf("%s --fields example %q: %v", cmd.Name(), match[1], err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package cmd
+
+import "os"
+
+// exposedToOthers reports whether users other than the owner can read or
+// write a file.
+func exposedToOthers(info os.FileInfo) bool {
+	return info.Mode().Perm()&0o077 != 0
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package cmd
+
+import "os"
+
+// exposedToOthers always reports false on Windows, where access is governed
+// by ACLs rather than the permission bits Go reports.
+func exposedToOthers(info os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/fatih/color"
+)
+
+// update rewrites the golden files with the current output instead of
+// comparing against them:
+//
+//	go test ./cmd -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenCase renders canned results through one renderer.
+type goldenCase struct {
+	name   string // Golden file under testdata/golden
+	render func(w *bytes.Buffer) error
+}
+
+// TestGolden renders canned result sets through every output format and
+// compares the output with the golden files checked in under testdata/golden,
+// so that changes to output that scripts and dashboards consume are deliberate.
+func TestGolden(t *testing.T) {
+	plainOutput(t)
+
+	for _, c := range goldenCases() {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.render(&buf); err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			checkGolden(t, c.name, buf.Bytes())
+		})
+	}
+}
+
+// plainOutput renders status icons as uncolored ASCII in English for the
+// duration of the test, so that the golden files do not depend on the
+// terminal or the environment.
+func plainOutput(t testing.TB) {
+	t.Helper()
+	savedNoColor, savedNoEmoji, savedLanguage := color.NoColor, noEmoji, language
+	t.Cleanup(func() {
+		color.NoColor, noEmoji, language = savedNoColor, savedNoEmoji, savedLanguage
+		configureOutput()
+	})
+	color.NoColor, noEmoji, language = true, true, ""
+	configureOutput()
+}
+
+// checkGolden compares got with the golden file, or rewrites the file with
+// -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./cmd -run TestGolden -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; if the change is intended, run go test ./cmd -run TestGolden -update\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+func goldenCases() []goldenCase {
+	syncSummary := func() *SyncSummary {
+		return &SyncSummary{
+			Synced:        []string{"widgets", "gadgets"},
+			UpToDate:      []string{"tools"},
+			Skipped:       map[string]string{"legacy": "sync disabled in config"},
+			NoWriteAccess: map[string]string{},
+			Quarantined:   map[string]string{"flaky": "failed 5 runs in a row"},
+			TimedOut:      []string{},
+			Verified:      []string{"gadgets", "widgets"},
+			VerifyFailed:  map[string]string{},
+			Errors:        map[string]string{"broken": "merge conflict"},
+			Warnings:      map[string][]string{"widgets": {"LICENSE"}},
+			Workflows:     map[string][]string{"widgets": {".github/workflows/ci.yml"}},
+			WorkflowRisks: map[string][]string{},
+			PendingReview: map[string]string{},
+			SSORequired:   map[string]string{},
+			Plan: []PlannedAction{
+				{Fork: "widgets", Action: "merge_upstream", Detail: "merge 3 upstream commits into main"},
+				{Fork: "gadgets", Action: "fast_forward", Detail: "fast-forward main by 12 commits"},
+			},
+			DiscoveryIncomplete: true,
+		}
+	}
+	deferredSummary := func() *SyncSummary {
+		o := &syncOptions{group: "platform", times: timestamps{zone: time.UTC}}
+		summary := o.newSummary("")
+		summary.Status, summary.Blackout = "deferred_blackout", "release-freeze"
+		summary.NextEligible = o.times.format(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC))
+		summary.Timestamp = o.times.format(time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC))
+		return &summary
+	}
+	ciResult := func() *CICheckResult {
+		return &CICheckResult{
+			BehindRepos:    []string{"gadgets", "widgets"},
+			UpToDateRepos:  []string{"tools"},
+			SLABreaches:    []string{"gadgets"},
+			Errors:         map[string]string{"broken": "not found"},
+			TimedOut:       []string{"slow"},
+			RequestIDs:     map[string]string{"broken": "ABCD:1234"},
+			TotalBehind:    2,
+			TotalUpToDate:  1,
+			TotalErrors:    1,
+			TotalTimedOut:  1,
+			TotalRepos:     5,
+			OutdatedStatus: true,
+			NewlyBehind:    []string{"widgets"},
+			Recovered:      []string{"tools"},
+			Group:          "platform",
+		}
+	}
+
+	checked := time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)
+	inventory := []InventoryEntry{
+		{
+			Fork: "octocat/widgets", Parent: "acme/widgets", Visibility: "public", DefaultBranch: "main",
+			BehindBy: 3, LastChecked: checked, LastSynced: checked.Add(-24 * time.Hour),
+			Topics: []string{"furca-managed", "go"}, Language: "Go",
+			Stars: 2, PushedAt: checked.Add(-time.Hour), ParentLanguage: "Go", ParentStars: 1500, ParentPushedAt: checked,
+		},
+		{
+			Fork: "octocat/old-tool", Parent: "other/tool", Visibility: "private", DefaultBranch: "master",
+			Detached: true, BehindBy: -1, Archived: true, ParentArchived: true,
+		},
+	}
+
+	forks := []github.Repository{
+		{Owner: "octocat", Name: "widgets", FullName: "octocat/widgets", ParentOwner: "acme", ParentName: "widgets"},
+		{Owner: "octocat", Name: "it's", FullName: "octocat/it's", ParentOwner: "other", ParentName: "quoted"},
+	}
+	remotes := []RemoteEntry{
+		{Fork: "octocat/widgets", Origin: cloneURL("octocat", "widgets", "https"), Upstream: cloneURL("acme", "widgets", "https")},
+		{Fork: "octocat/it's", Origin: cloneURL("octocat", "it's", "ssh"), Upstream: cloneURL("other", "quoted", "ssh")},
+	}
+
+	d := &digest{
+		From: "2026-03-07T09:26:53Z", To: "2026-03-14T09:26:53Z",
+		AuditLog: true, Syncs: 5, SyncedForks: 2, Rollbacks: 1,
+		MostSynced: []digestCount{{Fork: "octocat/widgets", Count: 4}, {Fork: "octocat/gadgets", Count: 1}},
+		Forks:      4, Checked: 3, Behind: 1, AverageBehind: 4.0 / 3,
+		Stalest: []forkSnapshot{{Name: "gadgets", BehindBy: 4}},
+		Problems: []digestProblem{
+			{Fork: "broken", Failures: 6, LastError: "merge conflict <main>", Since: "2026-03-10T00:00:00Z", Quarantined: true},
+		},
+		More: 2,
+	}
+
+	return []goldenCase{
+		{"sync.txt", func(w *bytes.Buffer) error {
+			o := &syncOptions{planOut: "plan.json"}
+			return o.writeSummary(w, syncSummary(), 2)
+		}},
+		{"sync_dry_run.txt", func(w *bytes.Buffer) error {
+			o := &syncOptions{dryRun: true, group: "platform"}
+			return o.writeSummary(w, syncSummary(), 0)
+		}},
+		{"sync.json", func(w *bytes.Buffer) error {
+			o := &syncOptions{jsonOutput: true}
+			return o.writeSummary(w, syncSummary(), 0)
+		}},
+		{"sync_fields.json", func(w *bytes.Buffer) error {
+			fields, err := parseFields("errors, plan.fork, plan.action, synced")
+			if err != nil {
+				return err
+			}
+			o := &syncOptions{jsonOutput: true, fields: fields}
+			return o.writeSummary(w, syncSummary(), 0)
+		}},
+		{"sync_deferred.txt", func(w *bytes.Buffer) error {
+			return (&syncOptions{}).writeDeferred(w, deferredSummary())
+		}},
+		{"sync_deferred.json", func(w *bytes.Buffer) error {
+			return (&syncOptions{jsonOutput: true}).writeDeferred(w, deferredSummary())
+		}},
+		{"sync_deferred_fields.json", func(w *bytes.Buffer) error {
+			fields, err := parseFields("status,next_eligible")
+			if err != nil {
+				return err
+			}
+			o := &syncOptions{jsonOutput: true, fields: fields}
+			return o.writeDeferred(w, deferredSummary())
+		}},
+		{"ci_check.txt", func(w *bytes.Buffer) error {
+			o := &ciCheckOptions{failOnOutdated: true}
+			return o.writeSummary(w, ciResult(), true, false)
+		}},
+		{"ci_check_baseline.txt", func(w *bytes.Buffer) error {
+			o := &ciCheckOptions{group: "platform"}
+			return o.writeSummary(w, ciResult(), false, true)
+		}},
+		{"ci_check.json", func(w *bytes.Buffer) error {
+			o := &ciCheckOptions{jsonOutput: true}
+			return o.writeSummary(w, ciResult(), false, false)
+		}},
+		{"ci_check_fields.json", func(w *bytes.Buffer) error {
+			fields, err := parseFields("total_behind,behind_repos")
+			if err != nil {
+				return err
+			}
+			o := &ciCheckOptions{jsonOutput: true, fields: fields}
+			return o.writeSummary(w, ciResult(), false, false)
+		}},
+		{"inventory.json", func(w *bytes.Buffer) error { return writeInventory(w, "json", inventory, timestamps{zone: time.UTC}) }},
+		{"inventory.csv", func(w *bytes.Buffer) error { return writeInventory(w, "csv", inventory, timestamps{zone: time.UTC}) }},
+		{"remotes.sh", func(w *bytes.Buffer) error { return writeRemotes(w, "shell", forks, remotes) }},
+		{"remotes.json", func(w *bytes.Buffer) error { return writeRemotes(w, "json", forks, remotes) }},
+		{"digest.md", func(w *bytes.Buffer) error { return d.write(w, "markdown") }},
+		{"digest.html", func(w *bytes.Buffer) error { return d.write(w, "html") }},
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/viper"
+)
+
+// runHealth collects the outcome of a scheduled run for the healthcheck ping.
+type runHealth struct {
+	command string
+	dryRun  bool // Log the ping instead of sending it
+	failed  bool
+	summary string
+}
+
+// report records the outcome of a run that completed.
+func (h *runHealth) report(failed bool, summary string) {
+	h.failed = failed
+	h.summary = summary
+}
+
+// ping reports the outcome of the run to HEALTHCHECK_URL, if set, so that cron
+// monitors notice runs that fail or stop happening. Following the convention
+// of healthchecks.io, successful runs ping the URL itself and failed runs the
+// URL with "/fail" appended, with the summary as the request body. err is the
+// error the command returned, if any.
+func (h *runHealth) ping(err error) {
+	endpoint := viper.GetString("HEALTHCHECK_URL")
+	if endpoint == "" || offline {
+		return
+	}
+
+	// A completed check that only sets the exit code has reported its result
+	var exitErr *ExitError
+	completed := h.summary != "" && errors.As(err, &exitErr) && exitErr.Err == nil
+	if err != nil && !completed {
+		h.failed = true
+		h.summary = "furca " + h.command + " failed: " + err.Error()
+	}
+	if h.summary == "" {
+		h.summary = "furca " + h.command + " completed"
+	}
+	if h.failed {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/fail"
+	}
+
+	log := logger.GetLogger()
+	if h.dryRun {
+		outcome := "success"
+		if h.failed {
+			outcome = "failure"
+		}
+		log.Infof("Dry run: would ping the healthcheck with %s: %s", outcome, h.summary)
+		return
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, strings.NewReader(h.summary))
+	if err != nil {
+		log.Warnf("Failed to ping healthcheck: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := alertClient.Do(req)
+	if err != nil {
+		log.Warnf("Failed to ping healthcheck: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warnf("Failed to ping healthcheck: unexpected response: %s", resp.Status)
+	}
+}
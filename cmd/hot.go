@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// HotEntry describes the upstream activity of one fork.
+type HotEntry struct {
+	Fork     string  `json:"fork"`
+	Upstream string  `json:"upstream"`
+	Commits  int     `json:"commits"`          // Upstream commits in the window
+	PerWeek  float64 `json:"commits_per_week"` // Commits per week over the window
+	BehindBy int     `json:"behind_by"`        // Last known drift, -1 if never checked
+	Error    string  `json:"error,omitempty"`
+}
+
+// hotOptions holds the flags of a single hot invocation.
+type hotOptions struct {
+	days       int
+	limit      int
+	jsonOutput bool
+	fields     fieldSet // Fields of the JSON output to keep, all if nil
+	discovery  discoveryOptions
+}
+
+// newHotOptions reads the options of a hot invocation from its flags.
+func newHotOptions(flags *pflag.FlagSet) (*hotOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &hotOptions{
+		days:       r.int("days"),
+		limit:      r.int("limit"),
+		jsonOutput: r.jsonOutput(),
+		fields:     r.fields(),
+		discovery:  r.discovery(),
+	}
+	return o, r.err
+}
+
+// hotCmd represents the hot command
+var hotCmd = &cobra.Command{
+	Use:   "hot",
+	Short: "Rank forks by how fast their upstream is moving",
+	Long: `The hot command ranks your forks by the number of commits made to their
+upstream's default branch over the last --days days (28 by default), using
+GitHub's commit activity statistics, so you can see which forks need frequent
+syncing and which can be synced rarely.
+
+Each fork's last known drift, from the last sync or ci-check run, is shown
+next to its upstream's activity. Forks sharing an upstream cost one request.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newHotOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if err := o.fields.check([]HotEntry(nil)); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+		if o.days < 1 || o.days > github.MaxActivityDays {
+			return fmt.Errorf("invalid --days %d: must be between 1 and %d", o.days, github.MaxActivityDays)
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+
+		log.Info("Fetching forked repositories...")
+		forks, complete, err := discoverForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+		if !complete {
+			log.Warn("Fork discovery was incomplete; only the forks found so far are ranked")
+		}
+
+		snap, err := loadSnapshot()
+		if err != nil {
+			log.Warnf("Failed to load fork snapshot; drift will be missing: %v", err)
+			snap = &snapshot{}
+		}
+
+		// Fetch the activity of each upstream once
+		type activity struct {
+			commits int
+			err     error
+		}
+		upstreams := make(map[string]github.Repository)
+		for _, fork := range forks {
+			upstreams[strings.ToLower(fork.ParentOwner+"/"+fork.ParentName)] = fork
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		activities := make(map[string]activity, len(upstreams))
+		for key, fork := range upstreams {
+			wg.Add(1)
+			go func(key string, fork github.Repository) {
+				defer wg.Done()
+				commits, err := client.UpstreamCommitActivity(ctx, fork, o.days)
+				mu.Lock()
+				activities[key] = activity{commits: commits, err: err}
+				mu.Unlock()
+			}(key, fork)
+		}
+		wg.Wait()
+
+		entries := make([]HotEntry, 0, len(forks))
+		for _, fork := range forks {
+			upstream := fork.ParentOwner + "/" + fork.ParentName
+			act := activities[strings.ToLower(upstream)]
+			entry := HotEntry{
+				Fork:     fork.FullName,
+				Upstream: upstream,
+				Commits:  act.commits,
+				PerWeek:  float64(act.commits) * 7 / float64(o.days),
+				BehindBy: -1,
+			}
+			if act.err != nil {
+				entry.Error = act.err.Error()
+				log.Warnf("Failed to get upstream activity of %s: %v", fork.FullName, act.err)
+			}
+			if known, ok := snap.Forks[fork.Name]; ok {
+				entry.BehindBy = known.BehindBy
+			}
+			entries = append(entries, entry)
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Commits != entries[j].Commits {
+				return entries[i].Commits > entries[j].Commits
+			}
+			return entries[i].Fork < entries[j].Fork
+		})
+		if o.limit > 0 && len(entries) > o.limit {
+			entries = entries[:o.limit]
+		}
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, entries, o.fields); err != nil {
+				return fmt.Errorf("failed to generate JSON output: %w", err)
+			}
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "FORK\tUPSTREAM\tCOMMITS (%dd)\tPER WEEK\tBEHIND\n", o.days)
+		for _, entry := range entries {
+			commits, perWeek := strconv.Itoa(entry.Commits), fmt.Sprintf("%.1f", entry.PerWeek)
+			if entry.Error != "" {
+				commits, perWeek = "?", "?"
+			}
+			behind := "-"
+			if entry.BehindBy >= 0 {
+				behind = strconv.Itoa(entry.BehindBy)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Fork, entry.Upstream, commits, perWeek, behind)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hotCmd)
+
+	hotCmd.Flags().Int("days", 28, fmt.Sprintf("Number of days of upstream activity to count (at most %d)", github.MaxActivityDays))
+	hotCmd.Flags().Int("limit", 0, "Only list the most active N forks (0 for all)")
+
+	// JSON output flag with default from environment
+	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
+	hotCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	hotCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. fork,behind_by (implies --json)")
+
+	addDiscoveryFlags(hotCmd)
+}
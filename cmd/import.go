@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/TFMV/furca/state"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Seed state and config from an inventory export",
+	Long: `The import command reads an inventory written by furca export and seeds
+this machine with it: the recorded drift and last sync of each fork become the
+saved fork states used by --offline and badge, and forks whose upstream was
+mapped manually get the same mapping under "upstreams" in config.yaml.
+
+States already saved on this machine are only replaced by newer ones.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := args[0]
+
+		// Detect the format from the file extension unless given
+		r := &flagReader{flags: cmd.Flags()}
+		format, times := r.string("format"), r.timestamps()
+		if r.err != nil {
+			return fmt.Errorf("failed to read flags: %w", r.err)
+		}
+		if format == "" {
+			format = "json"
+			if strings.EqualFold(path.Ext(file), ".csv") {
+				format = "csv"
+			}
+		}
+
+		in, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer in.Close()
+
+		var inventory []InventoryEntry
+		switch format {
+		case "json":
+			err = json.NewDecoder(in).Decode(&inventory)
+		case "csv":
+			inventory, err = readInventoryCSV(in, times)
+		default:
+			return fmt.Errorf("invalid --format %q: must be json or csv", format)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read inventory: %w", err)
+		}
+
+		snap, err := loadSnapshot()
+		if err != nil {
+			return fmt.Errorf("failed to load fork snapshot: %w", err)
+		}
+
+		var seeded, mapped int
+		for _, entry := range inventory {
+			_, name, _ := strings.Cut(entry.Fork, "/")
+			if entry.BehindBy >= 0 && !entry.LastChecked.IsZero() && entry.LastChecked.After(snap.Forks[name].CheckedAt) {
+				snap.Forks[name] = forkSnapshot{
+					Name:      name,
+					Status:    "imported",
+					BehindBy:  entry.BehindBy,
+					CheckedAt: entry.LastChecked,
+					SyncedAt:  entry.LastSynced,
+				}
+				seeded++
+			}
+
+			if entry.Detached && entry.Parent != "" {
+				if _, err := setConfigValue("upstreams."+entry.Fork, entry.Parent); err != nil {
+					return fmt.Errorf("failed to map upstream of %s: %w", entry.Fork, err)
+				}
+				mapped++
+			}
+		}
+
+		if err := state.Save(snapshotFile, snap); err != nil {
+			return fmt.Errorf("failed to save fork snapshot: %w", err)
+		}
+		fmt.Printf("%s Imported %d forks: %d fork states seeded, %d upstream mappings added\n", successIcon, len(inventory), seeded, mapped)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("format", "", "Inventory format (json or csv; detected from the file extension by default)")
+}
@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// initOptions holds the flags of a single init invocation.
+type initOptions struct {
+	clientID string
+	force    bool
+	write    string
+
+	// Flags of non-interactive setup
+	tokenEnv       string
+	apiURL         string
+	since          string
+	healthcheckURL string
+	skipCheck      bool
+}
+
+// newInitOptions reads the options of an init invocation from its flags.
+func newInitOptions(flags *pflag.FlagSet) (*initOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &initOptions{
+		clientID: r.string("client-id"),
+		force:    r.bool("force"),
+		write:    r.string("write"),
+
+		tokenEnv:       r.string("token-env"),
+		apiURL:         r.string("api-url"),
+		since:          r.string("since"),
+		healthcheckURL: r.string("healthcheck-url"),
+		skipCheck:      r.bool("skip-check"),
+	}
+	return o, r.err
+}
+
+// wizard asks the questions of furca init on the console.
+type wizard struct {
+	in *bufio.Reader
+
+	// values holds the answers by setting, in the order they were given
+	keys   []string
+	values map[string]string
+}
+
+// errSetupAborted is returned when the console closes in the middle of setup.
+var errSetupAborted = errors.New("setup aborted; nothing was written")
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up Furca interactively",
+	Long: `The init command walks you through setting up Furca: the GitHub Enterprise
+Server to use, if any; a token, either pasted or obtained by signing in with
+your browser; which forks to manage; and where to report the outcome of runs.
+
+The token is checked with GitHub before anything is written. Secrets are
+stored in secrets.env in the platform config directory, which only you can
+read, and the other answers in config.yaml next to it, or in the file given
+with --config. With --write, everything goes in that file instead, which is
+then only readable by you.
+
+Signing in with the browser uses GitHub's device flow, which needs the client
+ID of an OAuth app with device flow enabled, given with --client-id.
+
+With --token-env, init asks nothing and takes the token from that environment
+variable (GITHUB_TOKEN if no name is given) and the other settings from flags,
+for provisioning machines with tools such as Ansible or cloud-init:
+
+  furca init --token-env --concurrency 8 --exclude 'archive-*' --write ~/.furca.yaml`,
+	Example: `  furca init
+  furca init --client-id Iv1.0123456789abcdef
+  FURCA_PROVISION_TOKEN=ghp_xxx furca init --token-env FURCA_PROVISION_TOKEN --topic furca-managed --write /etc/furca/config.yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newInitOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if offline {
+			return errors.New("setup checks the token with GitHub and cannot run offline")
+		}
+		w := &wizard{in: bufio.NewReader(os.Stdin), values: make(map[string]string)}
+		if o.tokenEnv != "" {
+			return o.provision(cmd.Flags(), w)
+		}
+
+		if viper.GetString("GITHUB_TOKEN") != "" && !o.force {
+			again, err := w.askYesNo("Furca already has a GitHub token. Set it up again?", false)
+			if err != nil {
+				return err
+			}
+			if !again {
+				fmt.Printf("%s Nothing changed; run 'furca config validate' to review your settings\n", infoIcon)
+				return nil
+			}
+		}
+
+		fmt.Printf("\n%s GitHub\n", infoIcon)
+		apiURL, err := w.askSetting("GITHUB_API_URL", "GitHub Enterprise Server API URL, such as https://ghe.example.com/api/v3/ (empty for github.com)", false)
+		if err != nil {
+			return err
+		}
+		if err := w.setupToken(o.clientID, apiURL); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%s Forks\n", infoIcon)
+		if _, err := w.askSetting("TOPIC", "Only manage forks with this topic (empty for all forks)", false); err != nil {
+			return err
+		}
+		if _, err := w.askSetting("SINCE", "Only check forks whose upstream was pushed to within this window, such as 30d (empty for all)", false); err != nil {
+			return err
+		}
+		search, err := w.askYesNo("Find forks with the search API? This is faster for accounts with thousands of repositories", false)
+		if err != nil {
+			return err
+		}
+		if search {
+			w.set("DISCOVERY", "search")
+		}
+
+		fmt.Printf("\n%s Notifications\n", infoIcon)
+		if _, err := w.askSetting("HEALTHCHECK_URL", "URL to ping with the outcome of each run, such as a healthchecks.io check (empty to skip)", false); err != nil {
+			return err
+		}
+		if _, err := w.askSetting("PAGERDUTY_ROUTING_KEY", "PagerDuty routing key for alerts about forks that keep failing (empty to skip)", true); err != nil {
+			return err
+		}
+		if _, err := w.askSetting("OPSGENIE_API_KEY", "Opsgenie API key for alerts about forks that keep failing (empty to skip)", true); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		return w.save(o.write)
+	},
+}
+
+// provisionFlags maps the flags non-interactive setup reads settings from, when
+// given, to their settings.
+var provisionFlags = []struct{ flag, key string }{
+	{"api-url", "GITHUB_API_URL"},
+	{"topic", "TOPIC"},
+	{"exclude", "EXCLUDE"},
+	{"discovery", "DISCOVERY"},
+	{"concurrency", "CONCURRENCY"},
+	{"since", "SINCE"},
+	{"healthcheck-url", "HEALTHCHECK_URL"},
+}
+
+// provision sets Furca up without asking anything: the token comes from the
+// --token-env environment variable and the other settings from flags. Every
+// value is validated, and the token checked with GitHub unless --skip-check is
+// given, before anything is written.
+func (o *initOptions) provision(flags *pflag.FlagSet, w *wizard) error {
+	token := strings.TrimSpace(os.Getenv(o.tokenEnv))
+	if token == "" {
+		return fmt.Errorf("environment variable %s holds no token", o.tokenEnv)
+	}
+	w.set("GITHUB_TOKEN", token)
+
+	for _, p := range provisionFlags {
+		if !flags.Changed(p.flag) {
+			continue
+		}
+		value := flags.Lookup(p.flag).Value.String()
+		s, _ := lookupSetting(p.key)
+		if err := s.check(value); err != nil {
+			return fmt.Errorf("invalid --%s: %w", p.flag, err)
+		}
+		w.set(p.key, value)
+	}
+
+	if !o.skipCheck {
+		login, err := checkToken(token, o.apiURL)
+		if err != nil {
+			return fmt.Errorf("the token in %s does not work: %w", o.tokenEnv, err)
+		}
+		fmt.Printf("%s Authenticated as %s\n", successIcon, login)
+	}
+	return w.save(o.write)
+}
+
+// setupToken asks for a token, by device flow if a client ID is given and the
+// user agrees, and checks it with GitHub, asking again if it does not work.
+func (w *wizard) setupToken(clientID, apiURL string) error {
+	for attempt := 1; ; attempt++ {
+		var token string
+		var err error
+		useDevice := false
+		if clientID != "" {
+			if useDevice, err = w.askYesNo("Sign in with your browser?", true); err != nil {
+				return err
+			}
+		}
+		if useDevice {
+			token, err = deviceFlowToken(context.Background(), clientID, apiURL)
+		} else {
+			token, err = w.askSecret(fmt.Sprintf("Personal access token with repo scope (create one at %s)", tokenURL(apiURL)))
+		}
+		if err != nil {
+			return err
+		}
+
+		login, err := checkToken(token, apiURL)
+		if err == nil {
+			fmt.Printf("%s Authenticated as %s\n", successIcon, login)
+			w.set("GITHUB_TOKEN", token)
+			return nil
+		}
+		fmt.Printf("%s The token does not work: %v\n", errorIcon, err)
+		if attempt == 3 {
+			return errors.New("no working token after 3 attempts; nothing was written")
+		}
+	}
+}
+
+// checkToken authenticates with the token and returns the user's login.
+func checkToken(token, apiURL string) (string, error) {
+	opts := []github.Option{github.WithUserAgent(userAgent())}
+	if apiURL != "" {
+		opts = append(opts, github.WithEnterpriseURL(apiURL))
+	}
+	client, err := github.NewClientWithOptions([]string{token}, opts...)
+	if err != nil {
+		return "", err
+	}
+	return client.User(), nil
+}
+
+// webURL returns the web address of the GitHub instance with the API URL.
+func webURL(apiURL string) string {
+	if apiURL == "" {
+		return "https://github.com"
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "https://github.com"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// tokenURL returns the page for creating a personal access token with repo scope.
+func tokenURL(apiURL string) string {
+	return webURL(apiURL) + "/settings/tokens/new?scopes=repo&description=furca"
+}
+
+// deviceFlowToken obtains a token with repo scope by GitHub's device flow: the
+// user enters a code shown here on GitHub, and the token is issued once they
+// approve it.
+func deviceFlowToken(ctx context.Context, clientID, apiURL string) (string, error) {
+	endpoint := endpoints.GitHub
+	if apiURL != "" {
+		base := webURL(apiURL)
+		endpoint = oauth2.Endpoint{
+			AuthURL:       base + "/login/oauth/authorize",
+			TokenURL:      base + "/login/oauth/access_token",
+			DeviceAuthURL: base + "/login/device/code",
+		}
+	}
+	config := &oauth2.Config{ClientID: clientID, Endpoint: endpoint, Scopes: []string{"repo"}}
+
+	auth, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start signing in: %w", err)
+	}
+	fmt.Printf("%s Open %s and enter the code %s\n", infoIcon, auth.VerificationURI, auth.UserCode)
+	token, err := config.DeviceAccessToken(ctx, auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign in: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// ask prints the question and returns the trimmed answer.
+func (w *wizard) ask(question string) (string, error) {
+	fmt.Printf("%s: ", question)
+	answer, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", errSetupAborted
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// askSecret asks for a secret without echoing the answer.
+func (w *wizard) askSecret(question string) (string, error) {
+	fmt.Printf("%s: ", question)
+	answer, err := readHiddenLine(w.in)
+	fmt.Println()
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", errSetupAborted
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// askYesNo asks a yes-or-no question, returning def for an empty answer.
+func (w *wizard) askYesNo(question string, def bool) (bool, error) {
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	for {
+		answer, err := w.ask(question + " " + choices)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// askSetting asks for the value of a setting until it is valid, and records
+// it unless the answer is empty.
+func (w *wizard) askSetting(key, question string, secret bool) (string, error) {
+	s, _ := lookupSetting(key)
+	for {
+		var value string
+		var err error
+		if secret {
+			value, err = w.askSecret(question)
+		} else {
+			value, err = w.ask(question)
+		}
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", nil
+		}
+		if err := s.check(value); err != nil {
+			fmt.Printf("%s %v\n", errorIcon, err)
+			continue
+		}
+		w.set(key, value)
+		return value, nil
+	}
+}
+
+// set records the value of a setting.
+func (w *wizard) set(key, value string) {
+	if _, ok := w.values[key]; !ok {
+		w.keys = append(w.keys, key)
+	}
+	w.values[key] = value
+}
+
+// save writes the answers, secrets to the secrets file and everything else to
+// the config file, or all of them to the YAML file at path if one is given.
+func (w *wizard) save(path string) error {
+	if path != "" {
+		if configFormat(path) != "yaml" {
+			return fmt.Errorf("--write can only write YAML files, not %s", path)
+		}
+		values := make(map[string]string, len(w.values))
+		for key, value := range w.values {
+			values[strings.ToLower(key)] = value
+		}
+		// The file holds the token, so only its owner may read it
+		if err := updateConfigFile(path, values, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("%s Wrote %d settings to %s\n", successIcon, len(values), path)
+		fmt.Printf("%s Furca is set up. Run 'furca --config %s ci-check' to see how far behind your forks are\n", successIcon, path)
+		return nil
+	}
+
+	for _, key := range w.keys {
+		s, _ := lookupSetting(key)
+		if s.Secret {
+			path, err := saveSecret(s.Key, w.values[key])
+			if err != nil {
+				return fmt.Errorf("failed to store %s: %w", s.Key, err)
+			}
+			fmt.Printf("%s Stored %s in %s\n", successIcon, s.Key, path)
+			continue
+		}
+		path, err := setConfigValue(strings.ToLower(s.Key), w.values[key])
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", s.Key, err)
+		}
+		fmt.Printf("%s Set %s in %s\n", successIcon, strings.ToLower(s.Key), path)
+	}
+	fmt.Printf("\n%s Furca is set up. Run 'furca ci-check' to see how far behind your forks are\n", successIcon)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("client-id", "", "Client ID of an OAuth app with device flow enabled, to sign in with the browser instead of pasting a token")
+	initCmd.Flags().Bool("force", false, "Set up again without asking when a token is already configured")
+	initCmd.Flags().String("write", "", "Write all settings, including secrets, to this YAML file")
+
+	// Non-interactive setup
+	initCmd.Flags().String("token-env", "", "Set up without asking, taking the token from this environment variable")
+	initCmd.Flags().Lookup("token-env").NoOptDefVal = "GITHUB_TOKEN"
+	initCmd.Flags().String("api-url", "", "GitHub Enterprise Server API URL, with --token-env")
+	initCmd.Flags().String("topic", "", "Only manage forks with this GitHub topic, with --token-env")
+	initCmd.Flags().String("exclude", "", "Leave alone forks matching these comma-separated globs, with --token-env")
+	initCmd.Flags().String("discovery", "", "How to find forks, list or search, with --token-env")
+	initCmd.Flags().Int("concurrency", 0, "Process at most this many forks at once, with --token-env")
+	initCmd.Flags().String("since", "", "Only check forks whose upstream was pushed to within this window, with --token-env")
+	initCmd.Flags().String("healthcheck-url", "", "URL to ping with the outcome of each run, with --token-env")
+	initCmd.Flags().Bool("skip-check", false, "Write the token without checking it with GitHub, with --token-env")
+}
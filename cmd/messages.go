@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// language is the language of console messages, chosen by configureLanguage.
+// Messages are written in English when it is empty.
+var language string
+
+// messages holds the translations of console messages by language, keyed by
+// the English format string. Translations may reorder their arguments with
+// explicit indexes such as %[2]s. Messages without a translation are written
+// in English.
+var messages = map[string]map[string]string{
+	"de": {
+		// sync
+		"%s %s %s is up to date with upstream":                               "%s %s %s ist auf dem Stand von Upstream",
+		"%s %s is up to date with upstream":                                  "%s %s ist auf dem Stand von Upstream",
+		"%s %s Would sync %s (behind by %s commits)":                         "%s %s Würde %s synchronisieren (%s Commits zurück)",
+		"%s Successfully synced %s with upstream (was behind by %s commits)": "%s %s erfolgreich mit Upstream synchronisiert (war %s Commits zurück)",
+		"%s Skipped %s: %s":                                                  "%s %s übersprungen: %s",
+		"%s Cannot sync %s: %s":                                              "%s %s kann nicht synchronisiert werden: %s",
+		"%s Synced %s but verification failed: %s":                           "%s %s synchronisiert, aber die Überprüfung ist fehlgeschlagen: %s",
+		"%s Held back %s (behind by %s commits): %s":                         "%s %s zurückgehalten (%s Commits zurück): %s",
+		"%s Quarantined %s: %s":                                              "%s %s in Quarantäne: %s",
+		"%s Cannot access %s: %s":                                            "%s Kein Zugriff auf %s: %s",
+		"%s Timed out processing %s: %s":                                     "%s Zeitüberschreitung bei %s: %s",
+		"%s Error checking %s: %s":                                           "%s Fehler beim Prüfen von %s: %s",
+		"   %s %s: upstream changes workflows %s":                            "   %s %s: Upstream ändert die Workflows %s",
+		"%s Summary:":                                                        "%s Zusammenfassung:",
+		"%s Summary for group %s:":                                           "%s Zusammenfassung für Gruppe %s:",
+		"%s Would sync repositories: %d":                                     "%s Zu synchronisierende Repositories: %d",
+		"%s Synced repositories: %d":                                         "%s Synchronisierte Repositories: %d",
+		"%s Up-to-date repositories: %d":                                     "%s Aktuelle Repositories: %d",
+		"%s Skipped repositories: %d":                                        "%s Übersprungene Repositories: %d",
+		"%s Repositories without write access: %d":                           "%s Repositories ohne Schreibzugriff: %d",
+		"%s Syncs held back for review: %d":                                  "%s Zur Prüfung zurückgehaltene Synchronisierungen: %d",
+		"%s Quarantined repositories: %d":                                    "%s Repositories in Quarantäne: %d",
+		"%s Repositories needing SSO authorization: %d":                      "%s Repositories, die eine SSO-Autorisierung benötigen: %d",
+		"%s Failed verifications: %d":                                        "%s Fehlgeschlagene Überprüfungen: %d",
+		"%s Timed out repositories: %d":                                      "%s Repositories mit Zeitüberschreitung: %d",
+		"%s Errors encountered: %d":                                          "%s Aufgetretene Fehler: %d",
+		"Repositories receiving license or CODEOWNERS changes: %d":           "Repositories mit Lizenz- oder CODEOWNERS-Änderungen: %d",
+		"See logs for details.":                                              "Details stehen in den Logs.",
+		"Fork discovery was incomplete; run again with --resume to cover the remaining forks": "Die Fork-Erkennung war unvollständig; mit --resume erneut ausführen, um die übrigen Forks zu erfassen",
+		"%s Wrote a plan of %d syncs to %s; run furca apply %s to make them":                  "%s Plan mit %d Synchronisierungen in %s geschrieben; mit furca apply %s ausführen",
+		"\n%s Stopped after the canary forks: %s\n":                                           "\n%s Nach den Canary-Forks angehalten: %s\n",
+		"%s Deferred: blackout window %s is in effect; the next eligible time is %s\n":        "%s Zurückgestellt: Sperrzeitraum %s ist aktiv; nächster möglicher Zeitpunkt ist %s\n",
+		"%s Blackout window %s is in effect until %s\n":                                       "%s Sperrzeitraum %s ist aktiv bis %s\n",
+		"Repositories receiving workflow permission or third-party action changes: %d":        "Repositories mit Änderungen an Workflow-Berechtigungen oder Actions von Drittanbietern: %d",
+
+		// ci-check
+		"%s %s is behind upstream by %s commits":                                         "%s %s liegt %s Commits hinter Upstream",
+		"%s %s exceeds the policy limit of %d commits behind":                            "%s %s überschreitet das Richtlinienlimit von %d Commits Rückstand",
+		"%s %s is behind upstream by %s commits, none touching %s":                       "%s %s liegt %s Commits hinter Upstream, keiner davon betrifft %s",
+		"%s Repositories behind upstream: %d":                                            "%s Repositories hinter Upstream: %d",
+		"%s Repositories up to date: %d":                                                 "%s Aktuelle Repositories: %d",
+		"%s Policy SLA breaches: %d":                                                     "%s Verstöße gegen das Richtlinien-SLA: %d",
+		"%s Total repositories checked: %d":                                              "%s Geprüfte Repositories insgesamt: %d",
+		"%s Newly behind since baseline: %d":                                             "%s Seit der Baseline neu zurückgefallen: %d",
+		"%s Caught up since baseline: %d":                                                "%s Seit der Baseline aufgeholt: %d",
+		"Some repositories are behind their upstream sources":                            "Einige Repositories liegen hinter ihren Upstream-Quellen",
+		"Exiting with non-zero status code due to --fail-on-outdated flag":               "Beende mit Fehlerstatus wegen --fail-on-outdated",
+		"Exiting with non-zero status code: repositories fell behind since the baseline": "Beende mit Fehlerstatus: Repositories sind seit der Baseline zurückgefallen",
+	},
+}
+
+// configureLanguage chooses the language of console messages from FURCA_LANG,
+// which takes a language code such as "de" or a locale such as "de_DE.UTF-8".
+func configureLanguage() {
+	value := viper.GetString("LANG")
+	code := languageCode(value)
+
+	language = ""
+	switch {
+	case code == "" || code == "en" || code == "c":
+	case messages[code] != nil:
+		language = code
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: no translations for FURCA_LANG=%s; using English\n", value)
+	}
+}
+
+// languageCode returns the lowercase language code of a language or locale
+// name, such as "de" for "de_DE.UTF-8".
+func languageCode(value string) string {
+	code := strings.ToLower(value)
+	if i := strings.IndexAny(code, "_-."); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+// tr returns the translation of a console message format string into the
+// configured language. Leading and trailing newlines are kept as they are.
+func tr(format string) string {
+	catalog := messages[language]
+	if catalog == nil {
+		return format
+	}
+	message := strings.Trim(format, "\n")
+	translated, ok := catalog[message]
+	if !ok {
+		return format
+	}
+	i := strings.Index(format, message)
+	return format[:i] + translated + format[i+len(message):]
+}
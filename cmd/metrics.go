@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/viper"
+)
+
+// writeMetrics writes the GitHub API quota of each token to METRICS_FILE, if
+// set, in the Prometheus text format read by node_exporter's textfile
+// collector, and logs it at debug level with reset times formatted by times.
+func writeMetrics(ctx context.Context, client *github.Client, times timestamps) {
+	log := logger.FromContext(ctx)
+	limits := client.RateLimits()
+	for _, limit := range limits {
+		log.Debugf("GitHub API quota of the %s token: %d of %d requests left, resetting at %s", limit.Token, limit.Remaining, limit.Limit, times.format(limit.Reset))
+	}
+
+	path := viper.GetString("METRICS_FILE")
+	if path == "" {
+		return
+	}
+	var b strings.Builder
+	gauge := func(name, help string, value func(github.RateLimit) int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, limit := range limits {
+			fmt.Fprintf(&b, "%s{token=%q} %d\n", name, limit.Token, value(limit))
+		}
+	}
+	gauge("furca_github_rate_limit", "Requests GitHub allows the token per rate limit window.",
+		func(l github.RateLimit) int64 { return l.Limit })
+	gauge("furca_github_rate_remaining", "Requests the token has left in the current rate limit window.",
+		func(l github.RateLimit) int64 { return l.Remaining })
+	gauge("furca_github_rate_reset_timestamp_seconds", "Unix time at which the token's rate limit window resets.",
+		func(l github.RateLimit) int64 { return l.Reset.Unix() })
+
+	if err := writeFileAtomic(path, []byte(b.String()), 0o644); err != nil {
+		log.Warnf("Failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/TFMV/furca/state"
+	"github.com/fatih/color"
+)
+
+// snapshotFile is the state file holding the last known state of every fork.
+const snapshotFile = "snapshot.json"
+
+// offline restricts Furca to reading its own state, without contacting GitHub.
+var offline bool
+
+// errOffline is returned by every HTTP request made in offline mode.
+var errOffline = errors.New("network access is disabled by --offline")
+
+// offlineTransport is installed as the default HTTP transport in offline
+// mode, so that no code path can reach the network.
+type offlineTransport struct{}
+
+// RoundTrip fails every request.
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errOffline
+}
+
+// enforceOffline disables all network access for the rest of the process when
+// offline mode is enabled.
+func enforceOffline() {
+	if !offline {
+		return
+	}
+	http.DefaultTransport = offlineTransport{}
+	http.DefaultClient = &http.Client{Transport: offlineTransport{}}
+}
+
+// forkSnapshot is the last known state of a fork.
+type forkSnapshot struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	BehindBy  int       `json:"behind_by"`
+	CheckedAt time.Time `json:"checked_at"`
+	SyncedAt  time.Time `json:"synced_at"` // Last successful sync, zero if unknown
+}
+
+// snapshot records the last known state of every fork, as observed by sync
+// and ci-check, for use in offline mode.
+type snapshot struct {
+	RunID string                  `json:"run_id"`
+	Forks map[string]forkSnapshot `json:"forks"`
+
+	// Upstreams maps each fork to the upstream (owner/name) it tracked when
+	// last seen, so that upstream renames and transfers can be noticed
+	Upstreams map[string]string `json:"upstreams,omitempty"`
+}
+
+// loadSnapshot returns the saved snapshot, or an empty one if there is none.
+func loadSnapshot() (*snapshot, error) {
+	snap := &snapshot{}
+	if _, err := state.Load(snapshotFile, snap); err != nil {
+		return nil, err
+	}
+	if snap.Forks == nil {
+		snap.Forks = make(map[string]forkSnapshot)
+	}
+	if snap.Upstreams == nil {
+		snap.Upstreams = make(map[string]string)
+	}
+	return snap, nil
+}
+
+// newSnapshot starts a snapshot update for the run, keeping what is known
+// about forks the run does not observe.
+func newSnapshot(ctx context.Context, runID string) *snapshot {
+	snap, err := loadSnapshot()
+	if err != nil {
+		logger.FromContext(ctx).Warnf("Failed to load fork snapshot: %v", err)
+		snap = &snapshot{Forks: make(map[string]forkSnapshot), Upstreams: make(map[string]string)}
+	}
+	snap.RunID = runID
+	return snap
+}
+
+// record stores the observed state of a fork. Failed checks are not recorded,
+// so the previous state is kept.
+func (s *snapshot) record(name, status string, behindBy int) {
+	switch status {
+	case "error", "timed_out", "quarantined", "sso_required":
+		return
+	}
+
+	now := time.Now()
+	syncedAt := s.Forks[name].SyncedAt
+	if status == "synced" {
+		behindBy = 0
+		syncedAt = now
+	}
+	s.Forks[name] = forkSnapshot{
+		Name:      name,
+		Status:    status,
+		BehindBy:  behindBy,
+		CheckedAt: now,
+		SyncedAt:  syncedAt,
+	}
+}
+
+// upstreamMove is a fork whose upstream was renamed or transferred since the
+// fork was last seen.
+type upstreamMove struct {
+	Fork     github.Repository
+	From, To string
+}
+
+// trackUpstreams records the upstream of each fork and returns the forks
+// whose upstream has moved since the snapshot was saved. GitHub reports the
+// upstream under its new name, so the forks keep syncing with it.
+func (s *snapshot) trackUpstreams(ctx context.Context, forks []github.Repository) []upstreamMove {
+	log := logger.FromContext(ctx)
+
+	var moves []upstreamMove
+	for _, fork := range forks {
+		upstream := fork.ParentOwner + "/" + fork.ParentName
+		if from, ok := s.Upstreams[fork.Name]; ok && !strings.EqualFold(from, upstream) {
+			log.Warnf("Upstream of %s moved from %s to %s; following it", fork.FullName, from, upstream)
+			moves = append(moves, upstreamMove{Fork: fork, From: from, To: upstream})
+		}
+		s.Upstreams[fork.Name] = upstream
+	}
+	return moves
+}
+
+// save writes the snapshot to the state directory.
+func (s *snapshot) save(ctx context.Context) {
+	if err := state.Save(snapshotFile, s); err != nil {
+		logger.FromContext(ctx).Warnf("Failed to save fork snapshot: %v", err)
+	}
+}
+
+// OfflineReport describes what a command would do, based on the last known
+// state of each fork rather than on current data from GitHub.
+type OfflineReport struct {
+	RunID     string         `json:"run_id"` // Run that last updated the data
+	WouldSync []string       `json:"would_sync"`
+	UpToDate  []string       `json:"up_to_date"`
+	Forks     []forkSnapshot `json:"forks"`
+}
+
+// printOfflineReport prints the offline report for sync or ci-check, keeping
+// only the given fields of the JSON output.
+func printOfflineReport(asJSON bool, fields fieldSet) error {
+	if err := fields.check(OfflineReport{}); err != nil {
+		return fmt.Errorf("invalid --fields: %w", err)
+	}
+	snap, err := loadSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to load fork snapshot: %w", err)
+	}
+	if len(snap.Forks) == 0 {
+		return errors.New("no saved fork data; run sync or ci-check online at least once before using --offline")
+	}
+
+	report := OfflineReport{RunID: snap.RunID, WouldSync: []string{}, UpToDate: []string{}}
+	for _, fork := range snap.Forks {
+		report.Forks = append(report.Forks, fork)
+	}
+	sort.Slice(report.Forks, func(i, j int) bool { return report.Forks[i].Name < report.Forks[j].Name })
+
+	for _, fork := range report.Forks {
+		age := time.Since(fork.CheckedAt).Round(time.Minute)
+		switch {
+		case fork.Status == "skipped" || fork.Status == "no_write_access":
+			if !asJSON {
+				fmt.Printf("%s %s was not synced (%s), behind by %d commits as of %s ago\n", skipIcon, fork.Name, fork.Status, fork.BehindBy, age)
+			}
+		case fork.BehindBy > 0:
+			report.WouldSync = append(report.WouldSync, fork.Name)
+			if !asJSON {
+				fmt.Printf("%s %s Would sync %s (behind by %d commits as of %s ago)\n", dryRunIcon, syncIcon, fork.Name, fork.BehindBy, age)
+			}
+		default:
+			report.UpToDate = append(report.UpToDate, fork.Name)
+			if !asJSON {
+				fmt.Printf("%s %s was up to date as of %s ago\n", successIcon, fork.Name, age)
+			}
+		}
+	}
+
+	if asJSON {
+		if err := writeJSON(os.Stdout, report, fields); err != nil {
+			return fmt.Errorf("failed to generate JSON output: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Printf("\n%s Summary (offline):\n", summaryIcon)
+	fmt.Printf("%s Would sync repositories: %d\n", syncIcon, len(report.WouldSync))
+	fmt.Printf("%s Up-to-date repositories: %d\n", successIcon, len(report.UpToDate))
+	fmt.Printf("\n%s %s\n", warnIcon, color.YellowString("Based on saved data; upstreams may have moved since"))
+	return nil
+}
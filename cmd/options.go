@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// flagReader reads typed flag values into a command's options, remembering
+// the first error so that the options can be built without checking each one.
+type flagReader struct {
+	flags *pflag.FlagSet
+	err   error
+}
+
+// keep records err if it is the first error.
+func (r *flagReader) keep(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *flagReader) bool(name string) bool {
+	v, err := r.flags.GetBool(name)
+	r.keep(err)
+	return v
+}
+
+func (r *flagReader) string(name string) string {
+	v, err := r.flags.GetString(name)
+	r.keep(err)
+	return v
+}
+
+func (r *flagReader) int(name string) int {
+	v, err := r.flags.GetInt(name)
+	r.keep(err)
+	return v
+}
+
+func (r *flagReader) duration(name string) time.Duration {
+	v, err := r.flags.GetDuration(name)
+	r.keep(err)
+	return v
+}
+
+// jsonOutput reads --json, which --fields implies.
+func (r *flagReader) jsonOutput() bool {
+	return r.bool("json") || r.string("fields") != ""
+}
+
+// fields reads the projection of JSON output asked for with --fields.
+func (r *flagReader) fields() fieldSet {
+	fields, err := parseFields(r.string("fields"))
+	if err != nil {
+		r.keep(fmt.Errorf("invalid --fields: %w", err))
+	}
+	return fields
+}
+
+// discovery reads the flags added by addDiscoveryFlags.
+func (r *flagReader) discovery() discoveryOptions {
+	return discoveryOptions{
+		method:  r.string("discovery"),
+		exclude: r.string("exclude"),
+		topic:   r.string("topic"),
+		resume:  r.bool("resume"),
+	}
+}
+
+// timestamps reads --time-format and --timezone.
+func (r *flagReader) timestamps() timestamps {
+	ts, err := newTimestamps(r.string("time-format"), r.string("timezone"))
+	r.keep(err)
+	return ts
+}
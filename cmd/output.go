@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// writeJSONFile writes v as JSON to path. By default the file is replaced
+// atomically (written to a temporary file and renamed), so readers never see a
+// partial result. With appendMode, v is appended as a single compact line,
+// producing a JSON Lines file with one result per run.
+func writeJSONFile(path string, v any, appendMode bool) error {
+	if appendMode {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		// A single write keeps concurrent appenders from interleaving lines
+		if _, err := file.Write(append(data, '\n')); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return file.Close()
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+
+	return writeFileAtomic(path, append(data, '\n'), 0o644)
+}
+
+// writeFileAtomic replaces the file at path with data by writing a temporary
+// file in the same directory and renaming it over the original.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// formatBehind formats a behind-by count, marking counts that GitHub capped
+// as lower bounds (for example "250+").
+func formatBehind(behindBy int, capped bool) string {
+	if capped {
+		return fmt.Sprintf("%d+", behindBy)
+	}
+	return strconv.Itoa(behindBy)
+}
+
+// writeSummaryHeader starts the console summary of a run, naming the group it
+// was limited to, if any.
+func writeSummaryHeader(w io.Writer, group string) {
+	if group == "" {
+		fmt.Fprintf(w, tr("\n%s Summary:\n"), summaryIcon)
+		return
+	}
+	fmt.Fprintf(w, tr("\n%s Summary for group %s:\n"), summaryIcon, group)
+}
+
+// writeJSON writes v to w as indented JSON, as printed with --json, keeping
+// only the fields asked for with --fields, if any.
+func writeJSON(w io.Writer, v any, fields fieldSet) error {
+	data, err := fields.project(v)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(w)
+	return err
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+)
+
+// PlanFile is a reviewed set of syncs, written by furca plan and made by furca apply.
+type PlanFile struct {
+	RunID     string        `json:"run_id"`
+	CreatedAt string        `json:"created_at"`
+	Syncs     []PlannedSync `json:"syncs"`
+
+	// Signature is the base64 Ed25519 signature of the plan without it,
+	// made with AUDIT_KEY
+	Signature string `json:"signature,omitempty"`
+}
+
+// PlannedSync is a single sync in a plan file, pinned to the commits it was planned against.
+type PlannedSync struct {
+	Fork        string `json:"fork"`         // Fork as owner/name
+	Branch      string `json:"branch"`       // Fork branch to update
+	Upstream    string `json:"upstream"`     // Upstream as owner/name
+	UpstreamRef string `json:"upstream_ref"` // Upstream branch, tag, or SHA the branch catches up with
+	Action      string `json:"action"`       // merge_upstream or fast_forward
+	BehindBy    int    `json:"behind_by"`
+	ForkSHA     string `json:"fork_sha"`     // Head of the fork branch when planned
+	UpstreamSHA string `json:"upstream_sha"` // Upstream commit the fork branch is brought to
+}
+
+// PlannedAction is a change to a fork that a dry run would have made.
+type PlannedAction struct {
+	Fork   string `json:"fork"`
+	Action string `json:"action"` // merge_upstream, fast_forward, rename_branch, open_pull_request, disable_actions, or set_status
+	Detail string `json:"detail"`
+}
+
+// actionPlan collects the changes a dry run would make to a single fork.
+type actionPlan struct {
+	actions []PlannedAction
+}
+
+// add records a planned action, described by a format string.
+func (p *actionPlan) add(action, format string, args ...any) {
+	p.actions = append(p.actions, PlannedAction{Action: action, Detail: fmt.Sprintf(format, args...)})
+}
+
+// addStatus records the commit status that would be set on a branch.
+func (p *actionPlan) addStatus(branch string, behindBy int) {
+	if behindBy > 0 {
+		p.add("set_status", "set %s on %s to failure (behind by %d)", github.StatusContext, branch, behindBy)
+		return
+	}
+	p.add("set_status", "set %s on %s to success (up-to-date)", github.StatusContext, branch)
+}
+
+// writePlan writes the actions a dry run would have taken to w, grouped by
+// fork.
+func writePlan(w io.Writer, plan []PlannedAction) {
+	if len(plan) == 0 {
+		return
+	}
+	sort.SliceStable(plan, func(i, j int) bool { return plan[i].Fork < plan[j].Fork })
+
+	fmt.Fprintf(w, "\n%s Plan:\n", dryRunIcon)
+	for _, action := range plan {
+		fmt.Fprintf(w, "   %s: %s\n", action.Fork, action.Detail)
+	}
+}
+
+// writePlanFile writes a plan file, listing its syncs by fork. The plan is
+// signed if AUDIT_KEY is set.
+func writePlanFile(path string, plan *PlanFile) error {
+	sort.SliceStable(plan.Syncs, func(i, j int) bool { return plan.Syncs[i].Fork < plan.Syncs[j].Fork })
+	key, err := loadAuditKey()
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		unsigned, err := json.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		plan.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, unsigned))
+	}
+	if err := writeJSONFile(path, plan, false); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// readPlanFile reads a plan file written by furca plan.
+func readPlanFile(path string) (*PlanFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	var plan PlanFile
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return &plan, nil
+}
+
+// verifyPlan checks the signature of a plan if AUDIT_KEY is set, so that only
+// plans made with the same key are applied.
+func verifyPlan(plan *PlanFile) error {
+	key, err := verifyKey("")
+	if err != nil || key == nil {
+		return err
+	}
+	if plan.Signature == "" {
+		return errors.New("plan is not signed; AUDIT_KEY is set, so only signed plans are applied")
+	}
+	signature, err := base64.StdEncoding.DecodeString(plan.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode plan signature: %w", err)
+	}
+	unsigned := *plan
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if !ed25519.Verify(key, data, signature) {
+		return fmt.Errorf("plan signature is not valid for key %s; the plan was changed or signed with another key", keyID(key))
+	}
+	return nil
+}
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan --out FILE",
+	Short: "Record the syncs a sync would make for review",
+	Long: `The plan command checks your forks exactly like a dry-run sync and writes
+the syncs it would make to the --out file, together with the commit each fork
+branch is at and the upstream commit it would be brought to.
+
+Review the plan, then make exactly those syncs with furca apply. Forks whose
+sync needs a branch rename or a pull request for workflow changes are left
+out of the plan; use furca sync for them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newPlanOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		return o.run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	addSyncFlags(planCmd)
+	planCmd.Flags().String("out", "", "Write the plan to this file")
+	planCmd.MarkFlagRequired("out")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+)
+
+// policyRepo is the owner/name of the repository holding the organization policy.
+var policyRepo string
+
+// applyPolicy fetches the organization policy when --policy-repo is set and
+// returns it together with the forks the policy allows Furca to manage.
+// Without a policy repository, the forks are returned unchanged and the policy is nil.
+func applyPolicy(ctx context.Context, client *github.Client, forks []github.Repository) (*github.Policy, []github.Repository, error) {
+	if policyRepo == "" {
+		return nil, forks, nil
+	}
+
+	log := logger.GetLogger()
+	policy, err := client.GetPolicy(ctx, policyRepo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load policy from %s: %w", policyRepo, err)
+	}
+	log.Infof("Applying policy from %s to forks owned by %s", policyRepo, policy.Org)
+
+	var allowed []github.Repository
+	for _, fork := range forks {
+		if policy.Allows(fork) {
+			allowed = append(allowed, fork)
+		} else {
+			log.Debugf("Excluded by policy: %s", fork.FullName)
+		}
+	}
+
+	return policy, allowed, nil
+}
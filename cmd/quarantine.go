@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/TFMV/furca/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// failuresFile is the state file counting consecutive failed runs per fork.
+const failuresFile = "failures.json"
+
+// failureRecord tracks the consecutive failed runs of a fork.
+type failureRecord struct {
+	Count        int       `json:"count"`
+	LastError    string    `json:"last_error"`
+	FirstFailure time.Time `json:"first_failure"`
+	LastFailure  time.Time `json:"last_failure"`
+
+	// Alerted is set once on-call has been alerted about the failures
+	Alerted bool `json:"alerted,omitempty"`
+}
+
+// loadFailures returns the failure records keyed by fork name.
+func loadFailures() (map[string]failureRecord, error) {
+	failures := make(map[string]failureRecord)
+	if _, err := state.Load(failuresFile, &failures); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// quarantined reports whether a fork with the given record is quarantined
+// after the given number of consecutive failed runs, 0 to never quarantine.
+func (r failureRecord) quarantined(after int) bool {
+	return after > 0 && r.Count >= after
+}
+
+// partitionQuarantined splits off the forks quarantined after the given number of
+// failed runs and orders the rest so that forks which failed recently are
+// processed last.
+func partitionQuarantined(forks []github.Repository, failures map[string]failureRecord, after int) (active []github.Repository, held []SyncResult) {
+	for _, fork := range forks {
+		record := failures[fork.Name]
+		if record.quarantined(after) {
+			held = append(held, SyncResult{
+				Name:   fork.Name,
+				Status: "quarantined",
+				Reason: fmt.Sprintf("failed %d runs in a row (last: %s); run 'furca quarantine clear %s' to retry", record.Count, record.LastError, fork.Name),
+			})
+			continue
+		}
+		active = append(active, fork)
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return failures[active[i].Name].Count < failures[active[j].Name].Count
+	})
+	return active, held
+}
+
+// recordOutcome updates a fork's failure record with the result of this run.
+func recordOutcome(failures map[string]failureRecord, result SyncResult) {
+	switch result.Status {
+	case "error", "timed_out", "verify_failed":
+		record := failures[result.Name]
+		if record.Count == 0 {
+			record.FirstFailure = time.Now()
+		}
+		record.Count++
+		record.LastError = result.Error
+		record.LastFailure = time.Now()
+		failures[result.Name] = record
+	case "quarantined":
+	default:
+		delete(failures, result.Name)
+	}
+}
+
+// saveFailures writes the failure records, logging any error.
+func saveFailures(ctx context.Context, failures map[string]failureRecord) {
+	if err := state.Save(failuresFile, failures); err != nil {
+		logger.FromContext(ctx).Warnf("Failed to save failure counts: %v", err)
+	}
+}
+
+// quarantineCmd represents the quarantine command
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Manage forks that are skipped after failing repeatedly",
+	Long: `Forks that fail to sync in several consecutive runs (see --quarantine-after)
+are quarantined: sync skips them and reports them as quarantined, so known-broken
+forks stop consuming retries and producing noise. Once the cause is fixed,
+clear the quarantine to include them again.`,
+}
+
+// quarantineListCmd represents the quarantine list command
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List forks with consecutive failures",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failures, err := loadFailures()
+		if err != nil {
+			return fmt.Errorf("failed to load failure counts: %w", err)
+		}
+		if len(failures) == 0 {
+			fmt.Printf("%s No forks have failed recently\n", successIcon)
+			return nil
+		}
+
+		// Use the same threshold as sync
+		after := viper.GetInt("QUARANTINE_AFTER")
+
+		names := make([]string, 0, len(failures))
+		for name := range failures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FORK\tFAILED RUNS\tSTATUS\tLAST ERROR")
+		for _, name := range names {
+			record := failures[name]
+			status := "retrying"
+			if record.quarantined(after) {
+				status = "quarantined"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", name, record.Count, status, record.LastError)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// quarantineClearCmd represents the quarantine clear command
+var quarantineClearCmd = &cobra.Command{
+	Use:   "clear [FORK...]",
+	Short: "Reset the failure count of the given forks, or of all forks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		failures, err := loadFailures()
+		if err != nil {
+			return fmt.Errorf("failed to load failure counts: %w", err)
+		}
+
+		if len(args) == 0 {
+			if err := state.Remove(failuresFile); err != nil {
+				return fmt.Errorf("failed to clear failure counts: %w", err)
+			}
+			fmt.Printf("%s Cleared %d forks\n", successIcon, len(failures))
+			return nil
+		}
+
+		for _, name := range args {
+			if _, ok := failures[name]; !ok {
+				fmt.Printf("%s %s has no recorded failures\n", infoIcon, name)
+				continue
+			}
+			delete(failures, name)
+			fmt.Printf("%s Cleared %s\n", successIcon, name)
+		}
+		if err := state.Save(failuresFile, failures); err != nil {
+			return fmt.Errorf("failed to save failure counts: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantineClearCmd)
+}
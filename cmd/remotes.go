@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// RemoteEntry holds the clone URLs of a fork and its upstream.
+type RemoteEntry struct {
+	Fork     string `json:"fork"`
+	Origin   string `json:"origin"`
+	Upstream string `json:"upstream"`
+}
+
+// remotesOptions holds the flags of a single remotes invocation.
+type remotesOptions struct {
+	format    string
+	protocol  string
+	discovery discoveryOptions
+}
+
+// newRemotesOptions reads the options of a remotes invocation from its flags.
+func newRemotesOptions(flags *pflag.FlagSet) (*remotesOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &remotesOptions{
+		format:    r.string("format"),
+		protocol:  r.string("protocol"),
+		discovery: r.discovery(),
+	}
+	return o, r.err
+}
+
+// remotesCmd represents the remotes command
+var remotesCmd = &cobra.Command{
+	Use:   "remotes",
+	Short: "Print git remote commands for the upstream of each fork",
+	Long: `The remotes command prints the clone URLs of every fork and its upstream,
+as known to GitHub (or mapped in config), for scripting local setups.
+
+With --format shell (the default), it prints one git command per fork that
+adds the upstream as a remote named "upstream" to a clone in a directory named
+after the fork, ready to be evaluated from the directory holding the clones:
+
+  cd ~/src && eval "$(furca remotes --protocol ssh)"
+
+With --format json, it prints the fork, origin, and upstream URLs of each fork.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newRemotesOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+
+		if o.format != "shell" && o.format != "json" {
+			return fmt.Errorf("invalid --format %q: must be shell or json", o.format)
+		}
+		if o.protocol != "https" && o.protocol != "ssh" {
+			return fmt.Errorf("invalid --protocol %q: must be https or ssh", o.protocol)
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+
+		forks, complete, err := discoverForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+		if !complete {
+			log.Warn("Fork discovery was incomplete; only the forks found so far are listed")
+		}
+		sort.Slice(forks, func(i, j int) bool { return forks[i].FullName < forks[j].FullName })
+
+		entries := make([]RemoteEntry, 0, len(forks))
+		for _, fork := range forks {
+			entries = append(entries, RemoteEntry{
+				Fork:     fork.FullName,
+				Origin:   cloneURL(fork.Owner, fork.Name, o.protocol),
+				Upstream: cloneURL(fork.ParentOwner, fork.ParentName, o.protocol),
+			})
+		}
+
+		if err := writeRemotes(os.Stdout, o.format, forks, entries); err != nil {
+			return fmt.Errorf("failed to generate %s output: %w", o.format, err)
+		}
+		return nil
+	},
+}
+
+// writeRemotes writes the remotes of the forks, given in the same order as
+// their entries, as JSON or as git commands for the shell.
+func writeRemotes(w io.Writer, format string, forks []github.Repository, entries []RemoteEntry) error {
+	if format == "json" {
+		return writeJSON(w, entries, nil)
+	}
+	for i, entry := range entries {
+		if _, err := fmt.Fprintf(w, "git -C %s remote add upstream %s  # %s\n", shellQuote(forks[i].Name), shellQuote(entry.Upstream), entry.Fork); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneURL returns the clone URL of a repository on GitHub.
+func cloneURL(owner, name, protocol string) string {
+	if protocol == "ssh" {
+		return fmt.Sprintf("git@github.com:%s/%s.git", owner, name)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s.git", owner, name)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(remotesCmd)
+
+	remotesCmd.Flags().String("format", "shell", "Output format (shell or json)")
+	remotesCmd.Flags().String("protocol", "https", "URL protocol (https or ssh)")
+
+	addDiscoveryFlags(remotesCmd)
+}
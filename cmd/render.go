@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// Status icons shared by all commands. They are chosen by configureOutput once
+// flags are parsed, so that --no-color and --no-emoji apply everywhere.
+var (
+	successIcon string
+	syncIcon    string
+	errorIcon   string
+	skipIcon    string
+	dryRunIcon  string
+	warnIcon    string
+	infoIcon    string
+	summaryIcon string
+)
+
+var (
+	noColor bool
+	noEmoji bool
+)
+
+// glyph is a status icon with an ASCII fallback for terminals and CI logs
+// without good Unicode support.
+type glyph struct {
+	emoji string
+	ascii string
+	paint func(format string, a ...interface{}) string
+}
+
+// render returns the glyph as configured: emoji or ASCII, colored or not.
+func (g glyph) render() string {
+	text := g.emoji
+	if noEmoji {
+		text = g.ascii
+	}
+	return g.paint(text)
+}
+
+func init() {
+	configureOutput()
+}
+
+// configureOutput applies the color and emoji settings. Color is disabled by
+// --no-color or the NO_COLOR environment variable (https://no-color.org), and
+// emoji are replaced with ASCII by --no-emoji or NO_EMOJI, or when the console
+// cannot display them.
+func configureOutput() {
+	prepareConsole()
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		color.NoColor = true
+	}
+	if os.Getenv("NO_EMOJI") != "" {
+		noEmoji = true
+	}
+
+	successIcon = glyph{"✅", "[OK]", color.GreenString}.render()
+	syncIcon = glyph{"🔄", "[SYNC]", color.BlueString}.render()
+	errorIcon = glyph{"❌", "[ERROR]", color.RedString}.render()
+	skipIcon = glyph{"⏭️", "[SKIP]", color.YellowString}.render()
+	dryRunIcon = glyph{"[DRY-RUN]", "[DRY-RUN]", color.YellowString}.render()
+	warnIcon = glyph{"⚠️ ", "[WARN]", color.YellowString}.render()
+	infoIcon = glyph{"ℹ️", "[INFO]", color.CyanString}.render()
+	summaryIcon = glyph{"📊", "==", fmt.Sprintf}.render()
+}
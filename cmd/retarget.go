@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// RetargetResult represents the outcome of retargeting one fork's default branch.
+type RetargetResult struct {
+	Name    string `json:"name"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Status  string `json:"status"`
+	Created bool   `json:"created_branch,omitempty"`
+	Deleted bool   `json:"deleted_old_branch,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// retargetOptions holds the flags of a single retarget invocation.
+type retargetOptions struct {
+	dryRun     bool
+	jsonOutput bool
+	fields     fieldSet // Fields of the JSON output to keep, all if nil
+	deleteOld  bool
+	discovery  discoveryOptions
+}
+
+// newRetargetOptions reads the options of a retarget invocation from its flags.
+func newRetargetOptions(flags *pflag.FlagSet) (*retargetOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &retargetOptions{
+		dryRun:     r.bool("dry-run"),
+		jsonOutput: r.jsonOutput(),
+		fields:     r.fields(),
+		deleteOld:  r.bool("delete-old"),
+		discovery:  r.discovery(),
+	}
+	return o, r.err
+}
+
+// retargetCmd represents the retarget command which aligns fork default branch
+// names with their upstreams.
+var retargetCmd = &cobra.Command{
+	Use:   "retarget",
+	Short: "Rename fork default branches to match their upstreams",
+	Long: `The retarget command finds forks whose default branch name differs from
+their upstream's current default branch (for example after upstream moved
+from master to main) and switches each fork to the upstream's name.
+
+For every such fork, the new branch is created from the head of the old
+default branch (unless it already exists), the fork's default branch is
+switched to it, and, with --delete-old, the old branch is deleted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newRetargetOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if err := o.fields.check([]RetargetResult(nil)); err != nil {
+			return fmt.Errorf("invalid --fields: %w", err)
+		}
+
+		// Create GitHub client
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+
+		// Create a context for all operations, tagged with this run's ID
+		ctx, _ := startRun(context.Background())
+		log := logger.FromContext(ctx)
+		if !o.dryRun {
+			release, err := acquireRunLock(ctx, "retarget")
+			if err != nil {
+				return err
+			}
+			defer release()
+		}
+
+		// Get forked repositories
+		log.Info("Fetching forked repositories...")
+		forks, _, err := discoverForks(ctx, client, o.discovery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch forked repositories: %w", err)
+		}
+
+		// Apply the organization policy, if any
+		_, forks, err = applyPolicy(ctx, client, forks)
+		if err != nil {
+			return fmt.Errorf("failed to apply policy: %w", err)
+		}
+
+		results := []RetargetResult{}
+		for _, fork := range forks {
+			if fork.DefaultBranch == "" || fork.ParentDefaultBranch == "" || fork.DefaultBranch == fork.ParentDefaultBranch {
+				continue
+			}
+
+			result := RetargetResult{
+				Name: fork.Name,
+				From: fork.DefaultBranch,
+				To:   fork.ParentDefaultBranch,
+			}
+			if o.dryRun {
+				result.Status = "would_retarget"
+				results = append(results, result)
+				continue
+			}
+
+			ctx := logger.WithFields(ctx, "repo", fork.Name, "owner", fork.Owner)
+			created, err := client.RetargetDefaultBranch(ctx, fork, fork.ParentDefaultBranch, o.deleteOld)
+			result.Created = created
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			} else {
+				result.Status = "retargeted"
+				result.Deleted = o.deleteOld
+			}
+			results = append(results, result)
+		}
+
+		if o.jsonOutput {
+			if err := writeJSON(os.Stdout, results, o.fields); err != nil {
+				log.Errorf("Failed to generate JSON output: %v", err)
+			}
+			return nil
+		}
+
+		for _, result := range results {
+			switch result.Status {
+			case "would_retarget":
+				fmt.Printf("%s %s Would retarget %s: %s → %s\n", dryRunIcon, syncIcon, result.Name, result.From, result.To)
+			case "retargeted":
+				fmt.Printf("%s Retargeted %s: %s → %s\n", successIcon, result.Name, result.From, result.To)
+			case "error":
+				fmt.Printf("%s Error retargeting %s: %s\n", errorIcon, result.Name, result.Error)
+			}
+		}
+		if len(results) == 0 {
+			fmt.Println(color.GreenString("All fork default branches already match their upstreams"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(retargetCmd)
+
+	// Add flags with default values from environment variables
+	defaultDryRun := viper.GetBool("DRY_RUN")
+	retargetCmd.Flags().Bool("dry-run", defaultDryRun, "Preview which forks would be retargeted without making changes")
+
+	// JSON output flag with default from environment
+	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
+	retargetCmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	retargetCmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. name,status (implies --json)")
+
+	retargetCmd.Flags().Bool("delete-old", false, "Delete the old default branch after switching")
+
+	addDiscoveryFlags(retargetCmd)
+}
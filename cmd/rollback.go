@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// rollbackOptions holds the flags of a single rollback invocation.
+type rollbackOptions struct {
+	branch string
+	seq    int
+	yes    bool
+	force  bool
+	dryRun bool
+	times  timestamps
+}
+
+// newRollbackOptions reads the options of a rollback invocation from its flags.
+func newRollbackOptions(flags *pflag.FlagSet) (*rollbackOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &rollbackOptions{
+		branch: r.string("branch"),
+		seq:    r.int("seq"),
+		yes:    r.bool("yes"),
+		force:  r.bool("force"),
+		dryRun: r.bool("dry-run"),
+		times:  r.timestamps(),
+	}
+	return o, r.err
+}
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback OWNER/REPO",
+	Short: "Reset a fork branch to where it was before its last sync",
+	Long: `The rollback command undoes a sync that pulled in something breaking. It
+finds the fork's most recent merge or fast-forward in the audit log (AUDIT_LOG)
+and force-resets the synced branch to the commit it was at before. The owner
+defaults to the authenticated user.
+
+The branch is only reset if it is still at the commit the sync left it at,
+so that commits pushed since are not discarded by accident; pass --force to
+reset it anyway. Use --branch or --seq to roll back an earlier sync than the
+most recent one. The rollback is recorded in the audit log itself.
+
+It asks for confirmation first unless --yes is given.`,
+	Example: `  furca rollback myorg/duckdb
+  furca rollback duckdb --branch release/1.2 --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newRollbackOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		if offline {
+			return errors.New("a fork cannot be rolled back offline")
+		}
+		path := viper.GetString("AUDIT_LOG")
+		if path == "" {
+			return errors.New("rollback needs the commits recorded in the audit log, but AUDIT_LOG is not set")
+		}
+
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		ctx, runID := startRun(context.Background())
+
+		owner, name, ok := strings.Cut(args[0], "/")
+		if !ok {
+			owner, name = client.User(), args[0]
+		}
+		fork, err := client.GetFork(ctx, owner, name)
+		if err != nil {
+			return err
+		}
+
+		record, err := o.findSync(path, fork)
+		if err != nil {
+			return err
+		}
+		head, err := client.HeadSHA(ctx, fork.Owner, fork.Name, record.Branch)
+		if err != nil {
+			return err
+		}
+		if head == record.Before {
+			fmt.Printf("%s %s %s is already at %s, where it was before the sync\n", successIcon, fork.FullName, record.Branch, shortSHA(record.Before))
+			return nil
+		}
+		if record.After != "" && head != record.After {
+			if !o.force {
+				return fmt.Errorf("%s of %s moved to %s after the sync left it at %s; pass --force to discard those commits too", record.Branch, fork.FullName, shortSHA(head), shortSHA(record.After))
+			}
+			fmt.Printf("%s %s moved to %s after the sync left it at %s; those commits will be discarded too\n", warnIcon, record.Branch, shortSHA(head), shortSHA(record.After))
+		}
+
+		fmt.Printf("%s Rolling back %s %s from %s to %s, undoing the %s of run %s at %s\n",
+			infoIcon, fork.FullName, record.Branch, shortSHA(head), shortSHA(record.Before), record.Action, record.RunID, record.Time)
+		if o.dryRun {
+			fmt.Printf("%s %s Would reset %s of %s to %s\n", dryRunIcon, syncIcon, record.Branch, fork.FullName, shortSHA(record.Before))
+			return nil
+		}
+		if !o.yes && !confirm(fmt.Sprintf("Force-reset %s of %s to %s?", record.Branch, fork.FullName, shortSHA(record.Before))) {
+			return errors.New("rollback not confirmed; pass --yes to roll back without asking")
+		}
+
+		release, err := acquireRunLock(ctx, "rollback")
+		if err != nil {
+			return err
+		}
+		defer release()
+		audit, err := openAuditLog(runID, "rollback", client.User(), o.times)
+		if err != nil {
+			return err
+		}
+
+		if err := client.ResetBranch(ctx, fork, record.Branch, record.Before); err != nil {
+			return err
+		}
+		audit.record(ctx, client, fork, record.Branch, "rollback", head)
+		fmt.Printf("%s Reset %s of %s to %s\n", syncIcon, record.Branch, fork.FullName, shortSHA(record.Before))
+		return nil
+	},
+}
+
+// findSync returns the audit record of the sync to roll back: the fork's most
+// recent merge or fast-forward, narrowed down by --branch and --seq.
+func (o *rollbackOptions) findSync(path string, fork github.Repository) (AuditRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var found *AuditRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return AuditRecord{}, fmt.Errorf("failed to parse the audit log: %w", err)
+		}
+		switch {
+		case !strings.EqualFold(record.Fork, fork.FullName):
+		case record.Action != "merge_upstream" && record.Action != "fast_forward":
+		case o.branch != "" && record.Branch != o.branch:
+		case o.seq != 0 && record.Seq != o.seq:
+		default:
+			found = &record
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	switch {
+	case found == nil && o.seq != 0:
+		return AuditRecord{}, fmt.Errorf("record %d of %s is not a sync of %s", o.seq, path, fork.FullName)
+	case found == nil:
+		return AuditRecord{}, fmt.Errorf("%s records no sync of %s to roll back", path, fork.FullName)
+	case found.Before == "":
+		return AuditRecord{}, fmt.Errorf("record %d does not hold the commit %s was at before the sync", found.Seq, fork.FullName)
+	}
+	return *found, nil
+}
+
+// confirm asks a yes-or-no question on the console, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().String("branch", "", "Roll back the most recent sync of this branch instead of any branch")
+	rollbackCmd.Flags().Int("seq", 0, "Roll back the sync recorded under this audit record number")
+	rollbackCmd.Flags().Bool("yes", false, "Reset the branch without asking for confirmation")
+	rollbackCmd.Flags().Bool("force", false, "Reset the branch even if commits were pushed to it after the sync")
+	rollbackCmd.Flags().Bool("dry-run", false, "Show what would be reset without changing the fork")
+}
@@ -5,13 +5,24 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/TFMV/furca/github"
+	"github.com/TFMV/furca/logger"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// cfgFile is the config file given with --config, if any.
+var cfgFile string
+
+// exactCounts counts behind-by exactly for forks too stale for GitHub's compare API.
+var exactCounts bool
+
 var rootCmd = &cobra.Command{
 	Use:   "furca",
 	Short: "Furca - Keep your GitHub forks effortlessly fresh",
@@ -21,40 +32,257 @@ forked GitHub repositories with their upstream sources.
 It simplifies the developer experience by automatically fetching repository 
 information, determining if forks are behind their upstream repositories, 
 and synchronizing them accordingly when executed.`,
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() error {
-	return rootCmd.Execute()
+// Commands return their errors instead of exiting, so that deferred cleanup
+// runs; Execute reports the error and returns the process exit code.
+func Execute() int {
+	err := rootCmd.Execute()
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.Err == nil {
+		// The command has already reported the failure
+		return exitErr.Code
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	return exitCode(err)
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentPreRunE = setup
+
+	// Explicit config file, bypassing discovery
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file to use instead of the discovered ones (YAML, TOML, or env format)")
+
+	// Offline mode, reading only saved state
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Never contact the network; report from the last saved data instead")
+
+	// Output styling
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Use ASCII status markers instead of emoji (also honors NO_EMOJI)")
+
+	// Organization policy repository with default from environment
+	defaultPolicyRepo := viper.GetString("POLICY_REPO")
+	rootCmd.PersistentFlags().StringVar(&policyRepo, "policy-repo", defaultPolicyRepo, "Repository (owner/name) holding an organization-wide policy.yaml")
+
+	// Exact behind-by counts for very stale forks with default from environment
+	defaultExactCounts := viper.GetBool("EXACT_COUNTS")
+	rootCmd.PersistentFlags().BoolVar(&exactCounts, "exact-counts", defaultExactCounts, fmt.Sprintf("Count commits exactly for forks behind by %d or more instead of reporting %d+", github.CompareCommitLimit, github.CompareCommitLimit))
+
+	// Skip the lock that prevents overlapping runs
+	rootCmd.PersistentFlags().BoolVar(&noLock, "no-lock", false, "Run even if another furca process appears to be using the same state directory")
+
+	// Timestamp format and time zone with defaults from environment
+	defaultTimeFormat := viper.GetString("TIME_FORMAT")
+	if defaultTimeFormat == "" {
+		defaultTimeFormat = "rfc3339"
+	}
+	rootCmd.PersistentFlags().String("time-format", defaultTimeFormat, "Format of timestamps in summaries, reports, and the audit log: rfc3339, rfc3339nano, rfc1123, datetime, unix, or a Go layout")
+	defaultTimeZone := viper.GetString("TIMEZONE")
+	if defaultTimeZone == "" {
+		defaultTimeZone = "Local"
+	}
+	rootCmd.PersistentFlags().String("timezone", defaultTimeZone, "Time zone of timestamps, such as UTC or Europe/Berlin (Local for the system time zone)")
 }
 
-func initConfig() {
-	// Find home directory
-	home, err := os.UserHomeDir()
+// setup runs before every command, loading the configuration and setting up
+// the output.
+func setup(cmd *cobra.Command, args []string) error {
+	if err := initConfig(); err != nil {
+		return err
+	}
+	configureOutput()
+	configureLanguage()
+	// Check --time-format and --timezone before any command uses them
+	r := &flagReader{flags: cmd.Flags()}
+	if r.timestamps(); r.err != nil {
+		return r.err
+	}
+
+	// Errors from here on are not usage errors
+	rootCmd.SilenceUsage = true
+	return nil
+}
+
+// initConfig loads the configuration from the environment, config files, and
+// stored secrets.
+func initConfig() error {
+	// Bind every setting to its FURCA_ environment variable (or legacy name)
+	bindSettings()
+
+	// An explicit config file replaces the discovered ones
+	if cfgFile == "" {
+		cfgFile = os.Getenv(envPrefix + "CONFIG")
+	}
+	if cfgFile != "" {
+		format := configFormat(cfgFile)
+		if err := mergeConfigFile(cfgFile, format); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Using config file:", cfgFile)
+		loadedConfigFiles = append(loadedConfigFiles, configFile{Path: cfgFile, Format: format})
+	} else {
+		loadDiscoveredConfig()
+	}
+
+	// Fall back to secrets stored with "furca config set"
+	loadSecrets()
+
+	// Use configured settings for flags not given on the command line
+	applyFlagDefaults(rootCmd)
+
+	// Cut off the network entirely in offline mode
+	enforceOffline()
+	return nil
+}
+
+// loadDiscoveredConfig reads the first env-style config file found and merges
+// the YAML config files, if any.
+func loadDiscoveredConfig() {
+	// Read the first env-style config file found
+	for _, path := range envConfigFiles() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := mergeConfigFile(path, "env"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		fmt.Fprintln(os.Stderr, "Using config file:", path)
+		loadedConfigFiles = append(loadedConfigFiles, configFile{Path: path, Format: "env"})
+		break
+	}
+
+	// Merge structured settings from YAML config files, if any
+	loadStructuredConfig()
+}
+
+// configFormat returns the format of a config file from its extension. Files
+// without a YAML or TOML extension, such as .env, are read as env files.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "env"
+	}
+}
+
+// envConfigFiles returns the env-style config files to look for, in order of
+// preference: .env in the current directory, config.env in the platform config
+// directory, and the legacy ~/.furca file.
+func envConfigFiles() []string {
+	paths := []string{".env"}
+	if dir, err := configDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "config.env"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".furca"))
+	}
+	return paths
+}
+
+// configDir returns the platform-specific directory for Furca's config files, as
+// defined by os.UserConfigDir (for example ~/.config/furca on Linux,
+// ~/Library/Application Support/furca on macOS, or %AppData%\furca on Windows).
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		fmt.Println("Error finding home directory:", err)
-		os.Exit(1)
+		return "", err
+	}
+	return filepath.Join(dir, "furca"), nil
+}
+
+// githubTokens returns the primary token followed by any additional tokens
+// listed (comma-separated) in GITHUB_TOKENS, skipping blanks and duplicates.
+func githubTokens(primary string) []string {
+	tokens := []string{primary}
+	seen := map[string]bool{primary: true}
+	for _, token := range strings.Split(viper.GetString("GITHUB_TOKENS"), ",") {
+		token = strings.TrimSpace(token)
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
 	}
+	return tokens
+}
 
-	// Search for config in home directory with name ".furca" (without extension)
-	viper.AddConfigPath(home)
-	viper.SetConfigName(".furca")
-	viper.SetConfigType("env")
+// userAgent returns the User-Agent to send to GitHub: USER_AGENT if set,
+// otherwise one identifying this version of Furca.
+func userAgent() string {
+	if ua := viper.GetString("USER_AGENT"); ua != "" {
+		return ua
+	}
+	return fmt.Sprintf("furca/%s (+https://github.com/TFMV/furca)", version)
+}
 
-	// Also look for .env file in current directory
-	viper.AddConfigPath(".")
-	viper.SetConfigName(".env")
+// newGitHubClient creates a GitHub client from the configured tokens. If no token
+// is configured, it prints setup instructions and returns an error.
+func newGitHubClient() (*github.Client, error) {
+	log := logger.GetLogger()
 
-	// Read in environment variables that match
-	viper.AutomaticEnv()
+	if offline {
+		return nil, errors.New("this command needs the GitHub API and cannot run with --offline")
+	}
 
-	// If a config file is found, read it in
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	// Get GitHub token from environment
+	token := viper.GetString("GITHUB_TOKEN")
+	if token == "" {
+		fmt.Printf("\n%s ERROR: GitHub token not found\n", errorIcon)
+		fmt.Println("\nTo use Furca, you need to provide a GitHub personal access token with 'repo' scope.")
+		fmt.Println("\nYou can set it in one of these ways:")
+		fmt.Println("  1. Run the setup wizard:")
+		fmt.Println("     furca init")
+		fmt.Println("  2. Create a .env file in the current directory with:")
+		fmt.Println("     GITHUB_TOKEN=your_github_token_here")
+		fmt.Println("  3. Set an environment variable:")
+		fmt.Println("     export GITHUB_TOKEN=your_github_token_here")
+		fmt.Println("\nTo create a token, visit: https://github.com/settings/tokens")
+		return nil, &ExitError{Code: ExitFailure}
+	}
+
+	// Create GitHub client
+	opts := []github.Option{
+		github.WithUserAgent(userAgent()),
+		github.WithUpstreams(loadUpstreams()),
+		github.WithCompareWait(viper.GetDuration("COMPARE_WAIT")),
+		github.WithExactCounts(exactCounts),
+	}
+	writeToken := viper.GetString("GITHUB_WRITE_TOKEN")
+	if writeToken != "" {
+		opts = append(opts, github.WithWriteToken(writeToken))
+	}
+	if apiURL := viper.GetString("GITHUB_API_URL"); apiURL != "" {
+		opts = append(opts, github.WithEnterpriseURL(apiURL))
+	}
+	client, err := github.NewClientWithOptions(githubTokens(token), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	if client.TokenCount() > 1 {
+		log.Infof("Distributing API calls across %d tokens", client.TokenCount())
+	}
+	if writeToken != "" {
+		log.Debug("Using GITHUB_WRITE_TOKEN for changes to forks and GITHUB_TOKEN only for reading")
+	}
+	if caps := client.Capabilities(); caps.Enterprise {
+		switch {
+		case caps.Version == "":
+			log.Debug("Connected to GitHub Enterprise Server of unknown version; syncing forks with merge-upstream")
+		case caps.MergeUpstream:
+			log.Debugf("Connected to GitHub Enterprise Server %s; syncing forks with merge-upstream", caps.Version)
+		default:
+			log.Infof("Connected to GitHub Enterprise Server %s, which lacks merge-upstream (added in %s); syncing forks by fast-forwarding their branches instead", caps.Version, github.MergeUpstreamVersion)
+		}
 	}
+	return client, nil
 }
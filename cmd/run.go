@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/TFMV/furca/state"
+)
+
+// newRunID returns a random (version 4) UUID identifying a single invocation.
+func newRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate run ID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// startRun assigns a new run ID and returns it along with a context whose
+// logger includes it in every entry.
+func startRun(ctx context.Context) (context.Context, string) {
+	runID := newRunID()
+	ctx = logger.WithFields(ctx, "run_id", runID)
+	logger.FromContext(ctx).Debugf("Starting run %s", runID)
+	return ctx, runID
+}
+
+// runLockFile is the state file that keeps two runs from changing the same
+// forks at the same time.
+const runLockFile = "furca.lock"
+
+// noLock skips the run lock, for runs the user knows cannot overlap.
+var noLock bool
+
+// acquireRunLock takes the state directory's run lock for the named command,
+// failing if another run holds it. The returned function releases the lock.
+func acquireRunLock(ctx context.Context, command string) (func(), error) {
+	if noLock {
+		return func() {}, nil
+	}
+
+	log := logger.FromContext(ctx)
+	lock, err := state.AcquireLock(runLockFile, command)
+	if errors.Is(err, state.ErrLocked) {
+		return nil, fmt.Errorf("%w; wait for it to finish, or use --no-lock if it is not actually running", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the state directory: %w", err)
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			log.Warnf("Failed to release lock: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// secretsFileName is the env-style file in the config directory that holds
+// secret settings written by "furca config set". It is only readable by its owner.
+const secretsFileName = "secrets.env"
+
+// loadedSecrets holds the settings read from the secrets file at startup.
+var loadedSecrets map[string]string
+
+// secretsFile returns the path of the secrets file.
+func secretsFile() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, secretsFileName), nil
+}
+
+// loadSecrets reads the secrets file, if any, and makes its values available
+// as defaults, so that the environment and config files still take precedence.
+func loadSecrets() {
+	path, err := secretsFile()
+	if err != nil {
+		return
+	}
+	secrets, err := readSecrets(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", path, err)
+		return
+	}
+
+	loadedSecrets = secrets
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	warnIfExposed(path, keys)
+	for key, value := range secrets {
+		viper.SetDefault(key, value)
+	}
+}
+
+// readSecrets parses the KEY=VALUE lines of the secrets file at path. A
+// missing file yields no secrets.
+func readSecrets(path string) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			secrets[strings.ToUpper(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	return secrets, scanner.Err()
+}
+
+// saveSecret stores a secret setting in the secrets file, replacing any
+// previous value, and restricts the file to its owner.
+func saveSecret(key, value string) (string, error) {
+	path, err := secretsFile()
+	if err != nil {
+		return "", err
+	}
+	secrets, err := readSecrets(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	secrets[key] = value
+
+	var b strings.Builder
+	b.WriteString("# Written by furca config set; do not commit this file\n")
+	for _, s := range settings {
+		if value, ok := secrets[s.Key]; ok {
+			fmt.Fprintf(&b, "%s=%s\n", s.Key, value)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return path, writeFileAtomic(path, []byte(b.String()), 0o600)
+}
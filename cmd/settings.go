@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// envPrefix is prepended to every setting's name to form its environment
+// variable. The unprefixed names are still accepted for compatibility.
+const envPrefix = "FURCA_"
+
+// Kinds of values a setting can hold.
+const (
+	kindString   = "string"
+	kindBool     = "bool"
+	kindInt      = "int"
+	kindDuration = "duration"
+)
+
+// setting describes a value that can be configured through the environment or
+// an env-style config file.
+type setting struct {
+	Key         string // Environment variable name
+	Kind        string // Type of the value (string, bool, int, or duration)
+	Flag        string // Corresponding command-line flag, if any
+	Default     string // Value used when the setting is not configured
+	Description string
+	Secret      bool // Never printed in full
+
+	// PrefixOnly settings are only read from their FURCA_ variable, because
+	// other programs use the unprefixed name
+	PrefixOnly bool
+}
+
+// settings lists every flat setting Furca reads. Structured settings such as
+// repository groups live under structuredKeys in YAML config files instead.
+var settings = []setting{
+	{Key: "GITHUB_TOKEN", Kind: kindString, Description: "GitHub token with repo scope", Secret: true},
+	{Key: "GITHUB_API_URL", Kind: kindString, Description: "API URL of a GitHub Enterprise Server, such as https://ghe.example.com/api/v3/"},
+	{Key: "GITHUB_WRITE_TOKEN", Kind: kindString, Description: "Separate token used only for changes to forks; GITHUB_TOKEN is then only used to read", Secret: true},
+	{Key: "GITHUB_TOKENS", Kind: kindString, Description: "Additional comma-separated tokens for read-only calls", Secret: true},
+	{Key: "PAGERDUTY_ROUTING_KEY", Kind: kindString, Description: "PagerDuty Events API v2 routing key for alerts", Secret: true},
+	{Key: "OPSGENIE_API_KEY", Kind: kindString, Description: "Opsgenie API key for alerts", Secret: true},
+	{Key: "TELEMETRY", Kind: kindBool, Default: "false", Description: "Gather anonymous usage statistics locally, for furca telemetry show and send"},
+	{Key: "TELEMETRY_URL", Kind: kindString, Description: "Endpoint furca telemetry send posts the usage statistics to"},
+	{Key: "METRICS_FILE", Kind: kindString, Description: "File to write GitHub API quota gauges to in Prometheus text format after each sync and ci-check run"},
+	{Key: "HEALTHCHECK_URL", Kind: kindString, Description: "URL to ping with the outcome of each sync and ci-check run", Secret: true},
+	{Key: "AUDIT_LOG", Kind: kindString, Description: "Append a hash-chained record of every change made to forks to this file"},
+	{Key: "AUDIT_KEY", Kind: kindString, Description: "Ed25519 private key (PEM) to sign audit records and plan files with"},
+	{Key: "USER_AGENT", Kind: kindString, Description: "User-Agent sent with API requests"},
+	{Key: "POLICY_REPO", Kind: kindString, Flag: "policy-repo", Description: "Repository (owner/name) holding policy.yaml"},
+	{Key: "DISCOVERY", Kind: kindString, Flag: "discovery", Default: "list", Description: "How to find forks: list every repository, or search for forks (faster for large accounts)"},
+	{Key: "EXCLUDE", Kind: kindString, Flag: "exclude", Description: "Leave alone forks matching these comma-separated globs"},
+	{Key: "CONCURRENCY", Kind: kindInt, Flag: "concurrency", Default: "0", Description: "Process at most this many forks at once (0 for all at once)"},
+	{Key: "TOPIC", Kind: kindString, Flag: "topic", Description: "Only manage forks with this GitHub topic"},
+	{Key: "STATE_DIR", Kind: kindString, Description: "Directory for state such as interrupted discoveries"},
+	{Key: "LOG_LEVEL", Kind: kindString, Default: "info", Description: "Log level"},
+	{Key: "LOG_FORMAT", Kind: kindString, Default: "console", Description: "Log format (console or json)"},
+	{Key: "LOG_FILE", Kind: kindString, Description: "Also write logs to this file"},
+	{Key: "LOG_MAX_SIZE", Kind: kindInt, Default: "100", Description: "Rotate the log file at this size in MB"},
+	{Key: "LOG_MAX_AGE", Kind: kindInt, Default: "0", Description: "Rotate the log file after this many days"},
+	{Key: "LOG_MAX_BACKUPS", Kind: kindInt, Default: "5", Description: "Number of rotated log files to keep"},
+	{Key: "TIME_FORMAT", Kind: kindString, Flag: "time-format", Default: "rfc3339", Description: "Format of timestamps in summaries, reports, and the audit log"},
+	{Key: "TIMEZONE", Kind: kindString, Flag: "timezone", Default: "Local", Description: "Time zone of timestamps (an IANA name, UTC, or Local)"},
+	{Key: "LANG", Kind: kindString, Default: "en", Description: "Language of sync and ci-check console messages (en or de)", PrefixOnly: true},
+	{Key: "OFFLINE", Kind: kindBool, Flag: "offline", Default: "false", Description: "Never contact the network; report from saved data"},
+	{Key: "DRY_RUN", Kind: kindBool, Flag: "dry-run", Default: "false", Description: "Preview syncs without making changes"},
+	{Key: "JSON_OUTPUT", Kind: kindBool, Flag: "json", Default: "false", Description: "Output results in JSON format"},
+	{Key: "OUT_FILE", Kind: kindString, Flag: "out", Description: "Also write JSON results to this file"},
+	{Key: "MAX_RETRIES", Kind: kindInt, Flag: "max-retries", Default: "2", Description: "Retry attempts for API operations"},
+	{Key: "RETRY_MERGES", Kind: kindBool, Flag: "retry-merges", Default: "true", Description: "Retry failed merges that comparing with upstream shows did not go through"},
+	{Key: "RETRY_DELAY", Kind: kindInt, Flag: "retry-delay", Default: "3", Description: "Seconds between retry attempts"},
+	{Key: "SINCE", Kind: kindString, Flag: "since", Description: "Only check forks whose upstream was pushed to within this window"},
+	{Key: "COMPARE_WAIT", Kind: kindDuration, Default: "30s", Description: "How long to wait for comparisons and statistics GitHub is still computing"},
+	{Key: "STABLE_OUTPUT", Kind: kindBool, Flag: "stable-output", Default: "false", Description: "List forks in name order and leave run IDs and timestamps out of JSON results"},
+	{Key: "EXACT_COUNTS", Kind: kindBool, Flag: "exact-counts", Default: "false", Description: "Count behind-by exactly for forks behind by 250 or more"},
+	{Key: "BRANCH_PATTERN", Kind: kindString, Flag: "branch-pattern", Description: "Sync every fork branch matching this glob with the same-named upstream branch"},
+	{Key: "ONLY_IF_PATHS", Kind: kindString, Flag: "only-if-paths", Description: "Only sync forks whose incoming changes touch these comma-separated globs"},
+	{Key: "SHARD", Kind: kindString, Flag: "shard", Description: "Only process shard i of n (for example 2/4)"},
+	{Key: "ALERT_AFTER", Kind: kindDuration, Flag: "alert-after", Default: "0s", Description: "Alert on-call when a fork has been failing this long (0 disables)"},
+	{Key: "REPO_TIMEOUT", Kind: kindDuration, Flag: "repo-timeout", Default: "0s", Description: "Time limit per repository"},
+	{Key: "QUARANTINE_AFTER", Kind: kindInt, Flag: "quarantine-after", Default: "3", Description: "Skip forks that failed this many runs in a row (0 never skips)"},
+	{Key: "REQUIRE_UPSTREAM_GREEN", Kind: kindBool, Flag: "require-upstream-green", Default: "false", Description: "Skip forks whose upstream head commit has failing or unfinished CI"},
+	{Key: "CANARY", Kind: kindString, Flag: "canary", Description: "Share or number of forks to sync first, such as 10%"},
+	{Key: "CANARY_SOAK", Kind: kindDuration, Flag: "canary-soak", Default: "0s", Description: "How long synced canary forks soak before the rest are synced"},
+	{Key: "CANARY_CHECK", Kind: kindString, Flag: "canary-check", Description: "Command or URL that must report the canary forks healthy"},
+	{Key: "MAX_FILES", Kind: kindInt, Flag: "max-files", Default: "0", Description: "Open a pull request instead of syncing when upstream changes more files than this (0 for no limit)"},
+	{Key: "MAX_LINES", Kind: kindInt, Flag: "max-lines", Default: "0", Description: "Open a pull request instead of syncing when upstream changes more lines than this (0 for no limit)"},
+	{Key: "BLOCK_WORKFLOW_CHANGES", Kind: kindBool, Flag: "block-workflow-changes", Default: "false", Description: "Open a pull request instead of syncing when upstream changes workflows"},
+	{Key: "DISABLE_ACTIONS", Kind: kindBool, Flag: "disable-actions", Default: "false", Description: "Turn off GitHub Actions on each fork after syncing it"},
+	{Key: "UPSTREAM_REF", Kind: kindString, Flag: "upstream-ref", Description: "Upstream tag, branch, or SHA to compare and sync with"},
+	{Key: "SET_STATUS", Kind: kindBool, Flag: "set-status", Default: "false", Description: "Set a furca/sync commit status on each fork"},
+	{Key: "FOLLOW_RENAMES", Kind: kindBool, Flag: "follow-renames", Default: "false", Description: "Rename fork branches to follow upstream renames"},
+	{Key: "INCLUDE_READ_ONLY", Kind: kindBool, Flag: "include-read-only", Default: "false", Description: "Check drift of forks the token cannot push to"},
+	{Key: "VERIFY_SYNC", Kind: kindBool, Flag: "verify", Default: "true", Description: "Compare with upstream again after each sync"},
+	{Key: "VERIFY_RETRY", Kind: kindBool, Flag: "verify-retry", Default: "false", Description: "Sync once more if verification fails"},
+	{Key: "CI_PATHS", Kind: kindString, Flag: "paths", Description: "Only count ci-check drift that touches these comma-separated globs"},
+	{Key: "CI_FAIL_ON_OUTDATED", Kind: kindBool, Flag: "fail-on-outdated", Default: "false", Description: "Make ci-check exit non-zero when forks are behind"},
+}
+
+// structuredKeys are the top-level keys of settings that only YAML config files
+// can hold.
+var structuredKeys = []string{"blackouts", "groups", "upstreams"}
+
+// lookupSetting returns the setting with the given key, ignoring case and an
+// optional FURCA_ prefix.
+func lookupSetting(key string) (setting, bool) {
+	if len(key) > len(envPrefix) && strings.EqualFold(key[:len(envPrefix)], envPrefix) {
+		key = key[len(envPrefix):]
+	}
+	for _, s := range settings {
+		if strings.EqualFold(s.Key, key) {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// EnvName returns the preferred environment variable for the setting.
+func (s setting) EnvName() string {
+	return envPrefix + s.Key
+}
+
+// envNames returns the environment variables the setting is read from, in
+// order of precedence.
+func (s setting) envNames() []string {
+	if s.PrefixOnly {
+		return []string{s.EnvName()}
+	}
+	return []string{s.EnvName(), s.Key}
+}
+
+// envValue returns the value of the setting from the environment and the name
+// of the variable it came from. The prefixed name wins over the legacy one.
+func (s setting) envValue() (name, value string, ok bool) {
+	for _, name := range s.envNames() {
+		if value, ok := os.LookupEnv(name); ok {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
+
+// bindSettings binds every setting to its FURCA_ environment variable and its
+// legacy unprefixed name, and registers its default value.
+func bindSettings() {
+	for _, s := range settings {
+		viper.BindEnv(append([]string{s.Key}, s.envNames()...)...)
+		if s.Default != "" {
+			viper.SetDefault(s.Key, s.Default)
+		}
+	}
+}
+
+// configured reports whether the setting was given a value in the
+// environment, a config file, or the secrets file.
+func (s setting) configured() bool {
+	if _, _, ok := s.envValue(); ok {
+		return true
+	}
+	_, secret := loadedSecrets[s.Key]
+	return secret || viper.InConfig(s.Key)
+}
+
+// applyFlagDefaults makes configured settings the defaults of their flags on
+// every command, leaving flags given on the command line untouched. Flag
+// defaults cannot be read from the configuration when the flags are declared,
+// because the environment and config files are only loaded once a command runs.
+func applyFlagDefaults(cmd *cobra.Command) {
+	for _, s := range settings {
+		if s.Flag == "" || !s.configured() {
+			continue
+		}
+		for _, flags := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+			flag := flags.Lookup(s.Flag)
+			if flag == nil || flag.Changed {
+				continue
+			}
+			value := viper.GetString(s.Key)
+			if value == "" {
+				continue
+			}
+			if err := flag.Value.Set(value); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring invalid %s: %v\n", s.Key, err)
+			}
+		}
+	}
+	for _, child := range cmd.Commands() {
+		applyFlagDefaults(child)
+	}
+}
+
+// check reports whether value is valid for the setting.
+func (s setting) check(value string) error {
+	var err error
+	switch s.Kind {
+	case kindBool:
+		_, err = strconv.ParseBool(value)
+	case kindInt:
+		_, err = strconv.Atoi(value)
+	case kindDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s value %q", s.Key, s.Kind, value)
+	}
+
+	switch s.Key {
+	case "LOG_LEVEL":
+		switch strings.ToLower(value) {
+		case "debug", "info", "warn", "warning", "error", "dpanic", "panic", "fatal":
+		default:
+			return fmt.Errorf("LOG_LEVEL: unknown level %q", value)
+		}
+	case "LOG_FORMAT":
+		if value != "console" && value != "json" {
+			return fmt.Errorf("LOG_FORMAT must be console or json, got %q", value)
+		}
+	case "SINCE":
+		if _, err := parseWindow(value); err != nil {
+			return fmt.Errorf("SINCE: %v", err)
+		}
+	case "MAX_FILES", "MAX_LINES", "CONCURRENCY":
+		if n, _ := strconv.Atoi(value); n < 0 {
+			return fmt.Errorf("%s cannot be negative, got %d", s.Key, n)
+		}
+	case "TIME_FORMAT":
+		if _, err := parseTimeFormat(value); err != nil {
+			return fmt.Errorf("TIME_FORMAT: %v", err)
+		}
+	case "TIMEZONE":
+		if _, err := parseTimeZone(value); err != nil {
+			return fmt.Errorf("TIMEZONE: %v", err)
+		}
+	case "LANG":
+		if code := languageCode(value); code != "en" && code != "c" && messages[code] == nil {
+			return fmt.Errorf("LANG: no translations for %q", value)
+		}
+	case "DISCOVERY":
+		if value != "list" && value != "search" {
+			return fmt.Errorf("DISCOVERY must be list or search, got %q", value)
+		}
+	case "GITHUB_API_URL":
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("GITHUB_API_URL must be an http(s) URL, got %q", value)
+		}
+	case "EXCLUDE":
+		if err := checkPatterns(value, github.ValidatePattern); err != nil {
+			return fmt.Errorf("EXCLUDE: %v", err)
+		}
+	case "ONLY_IF_PATHS", "CI_PATHS":
+		if err := checkPatterns(value, github.ValidatePathPattern); err != nil {
+			return fmt.Errorf("%s: %v", s.Key, err)
+		}
+	case "TOPIC":
+		if !topicPattern.MatchString(value) {
+			return fmt.Errorf("TOPIC must be a GitHub topic: lowercase letters, digits, and hyphens, got %q", value)
+		}
+	case "POLICY_REPO":
+		if owner, name, ok := strings.Cut(value, "/"); !ok || owner == "" || name == "" {
+			return fmt.Errorf("POLICY_REPO must be in owner/name form, got %q", value)
+		}
+	}
+	return nil
+}
+
+// mask hides all but the last four characters of a secret value.
+func mask(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", 8) + value[len(value)-4:]
+}
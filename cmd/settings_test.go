@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+
+	"github.com/TFMV/furca/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// sources says where a setting is given in one configuration, and with which
+// value, numbered so that every source gives a different value.
+type sources struct {
+	Flag, Prefixed, Legacy, File, FilePrefixed bool
+	N                                          uint16
+}
+
+// value is the value the source with the given number gives the setting.
+func (src sources) value(s setting, source int) string {
+	n := int(src.N)*10 + source
+	switch s.Kind {
+	case kindInt:
+		return fmt.Sprint(n)
+	case kindDuration:
+		return fmt.Sprintf("%ds", n)
+	default:
+		return fmt.Sprintf("v%d", n)
+	}
+}
+
+// want returns the value a setting resolves to, by the documented order of
+// precedence: the command line, FURCA_ variables, legacy variables, config
+// files (where FURCA_ keys win too), then the default.
+func (src sources) want(s setting, withFlag bool) string {
+	switch {
+	case withFlag && src.Flag:
+		return src.value(s, 1)
+	case src.Prefixed:
+		return src.value(s, 2)
+	case src.Legacy && !s.PrefixOnly:
+		return src.value(s, 3)
+	case src.FilePrefixed:
+		return src.value(s, 5)
+	case src.File:
+		return src.value(s, 4)
+	}
+	return s.Default
+}
+
+// resolve loads a configuration with the setting given in the sources, the
+// way initConfig does, and returns its value in viper and on its flag.
+func (src sources) resolve(t *testing.T, s setting) (configured, flagged string) {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	setEnv := func(name string, set bool, value string) {
+		if set {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+	setEnv(s.EnvName(), src.Prefixed, src.value(s, 2))
+	setEnv(s.Key, src.Legacy, src.value(s, 3))
+	bindSettings()
+
+	if src.File || src.FilePrefixed {
+		var content string
+		if src.File {
+			content += fmt.Sprintf("%s: %q\n", s.Key, src.value(s, 4))
+		}
+		if src.FilePrefixed {
+			content += fmt.Sprintf("%s: %q\n", s.EnvName(), src.value(s, 5))
+		}
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := mergeConfigFile(path, "yaml"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String(s.Flag, s.Default, "")
+	if src.Flag {
+		if err := cmd.Flags().Set(s.Flag, src.value(s, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	applyFlagDefaults(cmd)
+	return viper.GetString(s.Key), cmd.Flags().Lookup(s.Flag).Value.String()
+}
+
+// TestSettingPrecedence checks that every combination of sources resolves a
+// setting to the value of the source with the highest precedence, the same
+// way every time.
+func TestSettingPrecedence(t *testing.T) {
+	var tested []setting
+	for _, key := range []string{"TOPIC", "MAX_RETRIES", "CONCURRENCY", "REPO_TIMEOUT"} {
+		s, ok := lookupSetting(key)
+		if !ok || s.Flag == "" {
+			t.Fatalf("%s is not a setting with a flag", key)
+		}
+		tested = append(tested, s)
+		// Restore the environment when the test ends
+		t.Setenv(s.EnvName(), "")
+		t.Setenv(s.Key, "")
+	}
+
+	for _, s := range tested {
+		t.Run(s.Key, func(t *testing.T) {
+			property := func(src sources) bool {
+				configured, flagged := src.resolve(t, s)
+				again, flaggedAgain := src.resolve(t, s)
+				if configured != again || flagged != flaggedAgain {
+					t.Logf("%+v resolved differently: %q/%q, then %q/%q", src, configured, flagged, again, flaggedAgain)
+					return false
+				}
+				if configured != src.want(s, false) || flagged != src.want(s, true) {
+					t.Logf("%+v resolved to %q (flag %q), want %q (flag %q)", src, configured, flagged, src.want(s, false), src.want(s, true))
+					return false
+				}
+				return true
+			}
+			if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestCheckPatterns checks that malformed globs in pattern settings are
+// reported instead of silently matching nothing.
+func TestCheckPatterns(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"EXCLUDE", "archive-*", true},
+		{"EXCLUDE", "archive-*, octocat/[a-z]*", true},
+		{"EXCLUDE", "", true},
+		{"EXCLUDE", ",,", true},
+		{"EXCLUDE", "archive-*,[a-", false},
+		{"EXCLUDE", `tools\`, false},
+		{"ONLY_IF_PATHS", "src/**,go.mod", true},
+		{"ONLY_IF_PATHS", "src/[/**", false},
+		{"CI_PATHS", "**/*.md", true},
+		{"CI_PATHS", "docs/[]", false},
+	}
+	for _, tt := range tests {
+		s, ok := lookupSetting(tt.key)
+		if !ok {
+			t.Fatalf("%s is not a setting", tt.key)
+		}
+		if err := s.check(tt.value); (err == nil) != tt.valid {
+			t.Errorf("%s=%q: got error %v, want valid %v", tt.key, tt.value, err, tt.valid)
+		}
+	}
+
+	if err := checkPatterns("ok-*,[", github.ValidatePattern); err == nil {
+		t.Error("checkPatterns accepted an unclosed class")
+	}
+}
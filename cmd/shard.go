@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/TFMV/furca/github"
+)
+
+// shardSpec selects one of several disjoint shards of the fork list.
+type shardSpec struct {
+	Index int // 1-based index of the shard to process
+	Count int // Total number of shards
+}
+
+// parseShard parses a shard in "i/n" form, such as 2/4. An empty value selects
+// all forks.
+func parseShard(value string) (*shardSpec, error) {
+	if value == "" {
+		return nil, nil
+	}
+	index, count, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, fmt.Errorf("%q is not in i/n form", value)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(index))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not in i/n form", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not in i/n form", value)
+	}
+	if n < 1 || i < 1 || i > n {
+		return nil, fmt.Errorf("%q: shard index must be between 1 and the shard count", value)
+	}
+	return &shardSpec{Index: i, Count: n}, nil
+}
+
+// contains reports whether the fork belongs to this shard. Forks are assigned
+// by a hash of their full name, so every runner using the same shard count
+// agrees on the split without coordinating.
+func (s *shardSpec) contains(fork github.Repository) bool {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(fork.FullName)))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
+
+// selectShard returns the forks in the given shard, or all forks if shard is nil.
+func selectShard(forks []github.Repository, shard *shardSpec) []github.Repository {
+	if shard == nil {
+		return forks
+	}
+	var selected []github.Repository
+	for _, fork := range forks {
+		if shard.contains(fork) {
+			selected = append(selected, fork)
+		}
+	}
+	return selected
+}
+
+// shuffleForks randomizes the processing order, so that runs do not hit the
+// same upstreams at the same point every time.
+func shuffleForks(forks []github.Repository) {
+	rand.Shuffle(len(forks), func(i, j int) {
+		forks[i], forks[j] = forks[j], forks[i]
+	})
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand returns a command that runs script with sh.
+func shellCommand(ctx context.Context, script string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", script)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addStableOutputFlag adds --stable-output to a command, which makes the
+// output of sync, plan, and ci-check depend only on the state of the forks:
+// per-fork lines come in name order instead of as forks finish, and JSON
+// results leave out the run ID and timestamp.
+func addStableOutputFlag(cmd *cobra.Command) {
+	// Output that only changes when the forks do, with default from environment
+	defaultStableOutput := viper.GetBool("STABLE_OUTPUT")
+	cmd.Flags().Bool("stable-output", defaultStableOutput, "Print forks in name order and leave run IDs and timestamps out of JSON results, so that runs can be diffed")
+}
+
+// inOrder returns the results as they arrive, or if stable, once all have
+// arrived, ordered by name.
+func inOrder[T any](results <-chan T, name func(T) string, stable bool) <-chan T {
+	if !stable {
+		return results
+	}
+	var collected []T
+	for result := range results {
+		collected = append(collected, result)
+	}
+	slices.SortStableFunc(collected, func(a, b T) int { return cmp.Compare(name(a), name(b)) })
+
+	ordered := make(chan T, len(collected))
+	for _, result := range collected {
+		ordered <- result
+	}
+	close(ordered)
+	return ordered
+}
+
+// sort orders the lists of a sync summary by fork name. If stable, it also
+// clears the fields that differ between otherwise identical runs.
+func (s *SyncSummary) sort(stable bool) {
+	for _, names := range [][]string{s.Synced, s.UpToDate, s.TimedOut, s.Verified, s.Canary} {
+		slices.Sort(names)
+	}
+	slices.SortStableFunc(s.Plan, func(a, b PlannedAction) int { return cmp.Compare(a.Fork, b.Fork) })
+	if stable {
+		s.RunID, s.Timestamp = "", ""
+	}
+}
+
+// sort orders the lists of a ci-check result by repository name. If stable,
+// it also clears the fields that differ between otherwise identical runs.
+func (r *CICheckResult) sort(stable bool) {
+	for _, names := range [][]string{r.BehindRepos, r.UpToDateRepos, r.SLABreaches, r.TimedOut, r.NewlyBehind, r.Recovered, r.OutsidePaths} {
+		slices.Sort(names)
+	}
+	if stable {
+		r.RunID, r.Timestamp = "", ""
+	}
+}
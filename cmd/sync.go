@@ -2,16 +2,18 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/TFMV/furca/github"
 	"github.com/TFMV/furca/logger"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -22,29 +24,187 @@ type SyncResult struct {
 	Name   string `json:"name"`
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+	Reason string `json:"reason,omitempty"`
 	Behind int    `json:"behind_by,omitempty"`
+
+	// UpstreamBranch is the upstream branch the fork's branch was compared with,
+	// set when their names differ
+	UpstreamBranch string `json:"upstream_branch,omitempty"`
+
+	// RequestID is GitHub's ID for the failed API request, for support escalations
+	RequestID string `json:"request_id,omitempty"`
+
+	// BehindCapped is set when Behind is a lower bound because GitHub
+	// stopped counting (see --exact-counts)
+	BehindCapped bool `json:"behind_capped,omitempty"`
+
+	// Warnings flags upstream changes to licensing or code ownership files
+	Warnings []string `json:"warnings,omitempty"`
+
+	// WorkflowChanges lists the GitHub Actions workflow files changed upstream
+	WorkflowChanges []string `json:"workflow_changes,omitempty"`
+
+	// WorkflowRisks flags workflow changes that grant permissions or bring in
+	// third-party actions
+	WorkflowRisks []string `json:"workflow_risks,omitempty"`
+
+	// Verification is "verified" or "failed" once a sync has been checked
+	// by comparing with upstream again
+	Verification string `json:"verification,omitempty"`
+
+	// Plan lists the changes a dry run would have made
+	Plan []PlannedAction `json:"plan,omitempty"`
+
+	// Sync is the sync recorded for furca apply when making a plan
+	Sync *PlannedSync `json:"-"`
 }
 
 // SyncSummary represents the summary of all sync operations performed.
 // It contains lists of repositories that were synced, up-to-date, and encountered errors,
 // as well as a timestamp of when the sync operation was performed.
 type SyncSummary struct {
-	Synced    []string          `json:"synced"`
-	UpToDate  []string          `json:"up_to_date"`
-	Errors    map[string]string `json:"errors"`
-	Timestamp string            `json:"timestamp"`
+	RunID         string              `json:"run_id,omitempty"`
+	Synced        []string            `json:"synced"`
+	UpToDate      []string            `json:"up_to_date"`
+	Skipped       map[string]string   `json:"skipped"`
+	NoWriteAccess map[string]string   `json:"no_write_access"` // Forks the token cannot push to
+	Quarantined   map[string]string   `json:"quarantined"`     // Forks skipped after failing repeatedly
+	TimedOut      []string            `json:"timed_out"`
+	Verified      []string            `json:"verified"`      // Synced forks confirmed to have caught up
+	VerifyFailed  map[string]string   `json:"verify_failed"` // Synced forks still behind upstream afterwards
+	Errors        map[string]string   `json:"errors"`
+	Warnings      map[string][]string `json:"warnings"`         // License and CODEOWNERS changes entering forks
+	Workflows     map[string][]string `json:"workflow_changes"` // Workflow files changed by upstream
+	WorkflowRisks map[string][]string `json:"workflow_risks"`   // Permission and third-party action changes in workflows
+	PendingReview map[string]string   `json:"pending_review"`   // Syncs held back in a pull request
+	Group         string              `json:"group,omitempty"`  // Group the run was limited to with --group
+	Canary        []string            `json:"canary,omitempty"` // Forks synced first, before the rest
+	SSORequired   map[string]string   `json:"sso_required"`     // Forks the token is not authorized for by SAML SSO
+	Plan          []PlannedAction     `json:"plan,omitempty"`   // Changes a dry run would have made
+	Timestamp     string              `json:"timestamp,omitempty"`
+
+	// CanaryHalted is why the forks after the canaries were not synced
+	CanaryHalted string `json:"canary_halted,omitempty"`
+
+	// Status is deferred_blackout when a blackout window kept the run from
+	// syncing anything, and NextEligible is when syncs may resume
+	Status       string `json:"status,omitempty"`
+	Blackout     string `json:"blackout,omitempty"`
+	NextEligible string `json:"next_eligible,omitempty"`
+
+	// DiscoveryIncomplete is set when fork discovery stopped early and only
+	// the forks found so far were processed
+	DiscoveryIncomplete bool `json:"discovery_incomplete,omitempty"`
 }
 
-var (
-	dryRun      bool
-	jsonOutput  bool
-	maxRetries  int
-	retryDelay  int
-	successIcon = color.GreenString("✅")
-	syncIcon    = color.BlueString("🔄")
-	errorIcon   = color.RedString("❌")
-	dryRunIcon  = color.YellowString("[DRY-RUN]")
-)
+// syncOptions holds the flags of a single sync invocation.
+type syncOptions struct {
+	dryRun          bool
+	jsonOutput      bool
+	fields          fieldSet // Fields of the JSON output to keep, all if nil
+	maxRetries      int
+	retryDelay      int
+	retryMerges     bool
+	since           string
+	repoTimeout     time.Duration
+	setStatus       bool
+	followRenames   bool
+	outFile         string
+	includeReadOnly bool
+	appendOut       bool
+	verifySync      bool
+	verifyRetry     bool
+	upstreamRef     string
+	shard           string
+	group           string
+	branchPattern   string
+	onlyIfPaths     string
+	quarantineAfter int
+	alertAfter      time.Duration
+	times           timestamps
+	discovery       discoveryOptions
+	stableOutput    bool
+	concurrency     int
+
+	blockWorkflowChanges bool
+	disableActions       bool
+	requireUpstreamGreen bool
+	ignoreBlackout       bool
+	maxFiles             int // Route syncs changing more files through a pull request, 0 for no limit
+	maxLines             int // Route syncs changing more lines through a pull request, 0 for no limit
+
+	canary      string        // Share or number of forks to sync first, such as 10%
+	canaryGroup string        // Group of forks to sync first
+	canarySoak  time.Duration // How long synced canaries must soak before the rest
+	canaryCheck string        // Command or URL that must report the canaries healthy
+
+	// planOut is where furca plan writes the syncs a dry run would make
+	planOut string
+
+	audit *auditLog // Where changes made by this run are recorded, if anywhere
+
+	trace *decisionTrace // Steps of each decision, recorded for furca explain
+}
+
+// newSyncOptions reads the options of a sync invocation from its flags.
+func newSyncOptions(flags *pflag.FlagSet) (*syncOptions, error) {
+	o, r := readSyncOptions(flags)
+	o.dryRun = r.bool("dry-run")
+	o.outFile = r.string("out")
+	o.appendOut = r.bool("append")
+	return o, r.err
+}
+
+// newPlanOptions reads the options of a plan invocation from its flags. A plan
+// is made by a dry-run sync that also records its syncs in the --out file.
+func newPlanOptions(flags *pflag.FlagSet) (*syncOptions, error) {
+	o, r := readSyncOptions(flags)
+	o.dryRun = true
+	o.planOut = r.string("out")
+	return o, r.err
+}
+
+// readSyncOptions reads the flags shared by sync and plan.
+func readSyncOptions(flags *pflag.FlagSet) (*syncOptions, *flagReader) {
+	r := &flagReader{flags: flags}
+	o := &syncOptions{
+		jsonOutput:      r.jsonOutput(),
+		fields:          r.fields(),
+		maxRetries:      r.int("max-retries"),
+		retryDelay:      r.int("retry-delay"),
+		retryMerges:     r.bool("retry-merges"),
+		since:           r.string("since"),
+		repoTimeout:     r.duration("repo-timeout"),
+		setStatus:       r.bool("set-status"),
+		followRenames:   r.bool("follow-renames"),
+		includeReadOnly: r.bool("include-read-only"),
+		verifySync:      r.bool("verify"),
+		verifyRetry:     r.bool("verify-retry"),
+		upstreamRef:     r.string("upstream-ref"),
+		shard:           r.string("shard"),
+		group:           r.string("group"),
+		branchPattern:   r.string("branch-pattern"),
+		onlyIfPaths:     r.string("only-if-paths"),
+		quarantineAfter: r.int("quarantine-after"),
+		alertAfter:      r.duration("alert-after"),
+		times:           r.timestamps(),
+		discovery:       r.discovery(),
+		stableOutput:    r.bool("stable-output"),
+		concurrency:     r.int("concurrency"),
+
+		blockWorkflowChanges: r.bool("block-workflow-changes"),
+		disableActions:       r.bool("disable-actions"),
+		requireUpstreamGreen: r.bool("require-upstream-green"),
+		ignoreBlackout:       r.bool("ignore-blackout"),
+		canary:               r.string("canary"),
+		canaryGroup:          r.string("canary-group"),
+		canarySoak:           r.duration("canary-soak"),
+		canaryCheck:          r.string("canary-check"),
+		maxFiles:             r.int("max-files"),
+		maxLines:             r.int("max-lines"),
+	}
+	return o, r
+}
 
 // syncCmd represents the sync command which synchronizes forked repositories
 // with their upstream sources.
@@ -56,237 +216,1102 @@ with their upstream sources if they are behind.
 
 It requires a GitHub token with appropriate permissions, which can be provided
 via the GITHUB_TOKEN environment variable or in a .env file.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		log := logger.GetLogger()
-
-		// Get GitHub token from environment
-		token := viper.GetString("GITHUB_TOKEN")
-		if token == "" {
-			fmt.Println("\n❌ ERROR: GitHub token not found")
-			fmt.Println("\nTo use Furca, you need to provide a GitHub personal access token with 'repo' scope.")
-			fmt.Println("\nYou can set it in one of these ways:")
-			fmt.Println("  1. Create a .env file in the current directory with:")
-			fmt.Println("     GITHUB_TOKEN=your_github_token_here")
-			fmt.Println("  2. Set an environment variable:")
-			fmt.Println("     export GITHUB_TOKEN=your_github_token_here")
-			fmt.Println("\nTo create a token, visit: https://github.com/settings/tokens")
-			os.Exit(1)
-		}
-
-		// Create GitHub client
-		client, err := github.NewClient(token)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newSyncOptions(cmd.Flags())
 		if err != nil {
-			log.Fatalf("Failed to create GitHub client: %v", err)
+			return fmt.Errorf("failed to read flags: %w", err)
 		}
+		return o.run()
+	},
+}
 
-		// Create a context for all operations
-		ctx := context.Background()
+// run checks every fork and syncs those that are behind, or only reports what
+// it would do in a dry run. With planOut set, it also records the syncs it
+// would make in a plan file for furca apply.
+func (o *syncOptions) run() (err error) {
+	command := "sync"
+	if o.planOut != "" {
+		command = "plan"
+	}
 
-		// Get forked repositories
-		log.Info("Fetching forked repositories...")
-		forks, err := client.GetForkedRepositories(ctx)
-		if err != nil {
-			log.Fatalf("Failed to fetch forked repositories: %v", err)
+	// Tell the cron monitor how the run went, even if it ended early
+	health := &runHealth{command: command, dryRun: o.dryRun}
+	if o.group != "" {
+		health.command += " --group " + o.group
+	}
+	defer func() { health.ping(err) }()
+
+	// Report from saved data without contacting GitHub
+	if offline {
+		if o.planOut != "" {
+			return errors.New("a plan needs the current upstream commits and cannot be made offline")
 		}
+		return printOfflineReport(o.jsonOutput, o.fields)
+	}
+	if err := o.fields.check(SyncSummary{}); err != nil {
+		return fmt.Errorf("invalid --fields: %w", err)
+	}
+
+	if o.branchPattern != "" && o.upstreamRef != "" {
+		return errors.New("--branch-pattern and --upstream-ref cannot be combined")
+	}
+	if o.maxFiles < 0 || o.maxLines < 0 {
+		return errors.New("--max-files and --max-lines cannot be negative")
+	}
+	if err := checkPatterns(o.onlyIfPaths, github.ValidatePathPattern); err != nil {
+		return fmt.Errorf("invalid --only-if-paths: %w", err)
+	}
+
+	// Leave forks alone during a blackout window
+	if deferred, err := o.deferForBlackout(health); deferred || err != nil {
+		return err
+	}
 
-		if len(forks) == 0 {
-			log.Info("No forked repositories found with parent information.")
-			return
+	// Create GitHub client
+	client, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	// Create a context for all operations, tagged with this run's ID
+	ctx, runID := startRun(context.Background())
+	log := logger.FromContext(ctx)
+
+	// Make sure no other run is syncing the same forks
+	release, err := acquireRunLock(ctx, command)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !o.dryRun {
+		if o.audit, err = openAuditLog(runID, command, client.User(), o.times); err != nil {
+			return err
 		}
+	}
+
+	// Get forked repositories
+	log.Info("Fetching forked repositories...")
+	forks, discoveryComplete, err := discoverForks(ctx, client, o.discovery)
+	if err != nil {
+		return fmt.Errorf("failed to fetch forked repositories: %w", err)
+	}
+
+	if len(forks) == 0 {
+		log.Info("No forked repositories found with parent information.")
+		return nil
+	}
 
-		log.Infof("Found %d forked repositories with parent information", len(forks))
+	log.Infof("Found %d forked repositories with parent information", len(forks))
 
-		// Process repositories concurrently
-		var wg sync.WaitGroup
-		results := make(chan SyncResult, len(forks))
+	// Apply the organization policy, if any
+	policy, forks, err := applyPolicy(ctx, client, forks)
+	if err != nil {
+		return fmt.Errorf("failed to apply policy: %w", err)
+	}
+
+	// Keep only the members of the --group group
+	if forks, err = selectGroup(ctx, forks, o.group); err != nil {
+		return err
+	}
 
-		// Initialize summary
-		summary := SyncSummary{
-			Synced:    []string{},
-			UpToDate:  []string{},
-			Errors:    make(map[string]string),
-			Timestamp: time.Now().Format(time.RFC3339),
+	// Skip forks whose upstream has been dormant for the whole window
+	if o.since != "" {
+		window, err := parseWindow(o.since)
+		if err != nil {
+			return fmt.Errorf("invalid --since value: %w", err)
 		}
+		var dormant int
+		forks, dormant = filterActiveSince(forks, time.Now().Add(-window))
+		log.Infof("Skipping %d forks whose upstream has not been pushed to in the last %s", dormant, o.since)
+	}
 
-		for _, fork := range forks {
-			wg.Add(1)
-			go func(fork github.Repository) {
-				defer wg.Done()
+	// Keep only this runner's shard, in random order
+	shard, err := parseShard(o.shard)
+	if err != nil {
+		return fmt.Errorf("invalid --shard value: %w", err)
+	}
+	if shard != nil {
+		forks = selectShard(forks, shard)
+		log.Infof("Processing shard %d/%d: %d forks", shard.Index, shard.Count, len(forks))
+	}
+	shuffleForks(forks)
 
-				log.Debugf("Checking repository: %s", fork.Name)
+	// Hold back forks that keep failing, and try recently failed ones last
+	failures, err := loadFailures()
+	if err != nil {
+		log.Warnf("Failed to load failure counts: %v", err)
+		failures = make(map[string]failureRecord)
+	}
+	forks, held := partitionQuarantined(forks, failures, o.quarantineAfter)
+	alerted := alertedForks(failures)
 
-				// Check if fork is behind upstream with retries
-				behind, behindBy, err := checkRepositoryWithRetries(ctx, client, fork, maxRetries, retryDelay)
-				if err != nil {
-					errMsg := fmt.Sprintf("failed to compare commits: %v", err)
-					results <- SyncResult{
-						Name:   fork.Name,
-						Status: "error",
-						Error:  errMsg,
-					}
-					return
-				}
+	// Sync the canaries first, if any
+	canaries, rest, err := o.selectCanaries(ctx, forks)
+	if err != nil {
+		return err
+	}
 
-				if !behind {
-					if dryRun {
-						results <- SyncResult{
-							Name:   fork.Name,
-							Status: "up_to_date",
-							Behind: 0,
-						}
-					} else {
-						results <- SyncResult{
-							Name:   fork.Name,
-							Status: "up_to_date",
-							Behind: 0,
-						}
-					}
-					return
-				}
+	// Process repositories concurrently
+	results := make(chan SyncResult, len(forks)+len(held))
+	for _, result := range held {
+		results <- result
+	}
 
-				// If dry run, just report what would happen
-				if dryRun {
-					results <- SyncResult{
-						Name:   fork.Name,
-						Status: "would_sync",
-						Behind: behindBy,
-					}
-					return
-				}
+	// Initialize summary
+	summary := o.newSummary(runID)
+	summary.DiscoveryIncomplete = !discoveryComplete
 
-				// Sync fork with upstream with retries
-				log.Debugf("Syncing %s with upstream...", fork.Name)
-				err = syncRepositoryWithRetries(ctx, client, fork, maxRetries, retryDelay)
-				if err != nil {
-					errMsg := fmt.Sprintf("failed to sync repository: %v", err)
-					results <- SyncResult{
-						Name:   fork.Name,
-						Status: "error",
-						Error:  errMsg,
-					}
-					return
-				}
+	for _, fork := range canaries {
+		summary.Canary = append(summary.Canary, fork.Name)
+	}
 
-				results <- SyncResult{
-					Name:   fork.Name,
-					Status: "synced",
-					Behind: behindBy,
+	// Sync the rest once the canaries prove healthy
+	go func() {
+		defer close(results)
+		if len(canaries) > 0 {
+			log.Infof("Syncing %d canary forks before the other %d", len(canaries), len(rest))
+			if reason := o.checkCanaries(ctx, runID, o.syncAll(ctx, client, policy, canaries, results)); reason != "" {
+				summary.CanaryHalted = reason
+				for _, fork := range rest {
+					results <- SyncResult{Name: fork.Name, Status: "skipped", Reason: reason}
 				}
-			}(fork)
-		}
-
-		// Wait for all goroutines to finish
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
-
-		// Process results
-		for result := range results {
-			switch result.Status {
-			case "up_to_date":
-				summary.UpToDate = append(summary.UpToDate, result.Name)
-				if !jsonOutput {
-					if dryRun {
-						fmt.Printf("%s %s %s is up to date with upstream\n", dryRunIcon, successIcon, result.Name)
-					} else {
-						fmt.Printf("%s %s is up to date with upstream\n", successIcon, result.Name)
-					}
-				}
-			case "would_sync":
-				summary.Synced = append(summary.Synced, result.Name)
-				if !jsonOutput {
-					fmt.Printf("%s %s Would sync %s (behind by %d commits)\n", dryRunIcon, syncIcon, result.Name, result.Behind)
-				}
-			case "synced":
-				summary.Synced = append(summary.Synced, result.Name)
-				if !jsonOutput {
-					fmt.Printf("%s Successfully synced %s with upstream (was behind by %d commits)\n", syncIcon, result.Name, result.Behind)
-				}
-			case "error":
-				summary.Errors[result.Name] = result.Error
-				if !jsonOutput {
-					fmt.Printf("%s Error checking %s: %s\n", errorIcon, result.Name, result.Error)
+				return
+			}
+		}
+		o.syncAll(ctx, client, policy, rest, results)
+	}()
+
+	// Process results, remembering each fork's state for offline mode
+	snap := newSnapshot(ctx, runID)
+	usage := newRunUsage(health.command)
+	for _, move := range snap.trackUpstreams(ctx, forks) {
+		o.audit.upstreamMoved(ctx, move.Fork, move.From, move.To)
+	}
+	planFile := &PlanFile{RunID: runID, CreatedAt: summary.Timestamp, Syncs: []PlannedSync{}}
+	for result := range inOrder(results, func(r SyncResult) string { return r.Name }, o.stableOutput) {
+		if result.Sync != nil {
+			planFile.Syncs = append(planFile.Syncs, *result.Sync)
+		}
+		snap.record(result.Name, result.Status, result.Behind)
+		usage.count(result.Status)
+		if !o.dryRun {
+			recordOutcome(failures, result)
+		}
+		if len(result.Warnings) > 0 {
+			summary.Warnings[result.Name] = result.Warnings
+		}
+		if len(result.WorkflowChanges) > 0 {
+			summary.Workflows[result.Name] = result.WorkflowChanges
+		}
+		if len(result.WorkflowRisks) > 0 {
+			summary.WorkflowRisks[result.Name] = result.WorkflowRisks
+		}
+		for _, action := range result.Plan {
+			action.Fork = result.Name
+			summary.Plan = append(summary.Plan, action)
+		}
+		switch result.Status {
+		case "up_to_date":
+			summary.UpToDate = append(summary.UpToDate, result.Name)
+			if !o.jsonOutput {
+				if o.dryRun {
+					fmt.Printf(tr("%s %s %s is up to date with upstream\n"), dryRunIcon, successIcon, result.Name)
+				} else {
+					fmt.Printf(tr("%s %s is up to date with upstream\n"), successIcon, result.Name)
 				}
 			}
+		case "would_sync":
+			summary.Synced = append(summary.Synced, result.Name)
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s %s Would sync %s (behind by %s commits)\n"), dryRunIcon, syncIcon, result.Name, formatBehind(result.Behind, result.BehindCapped))
+			}
+		case "synced":
+			summary.Synced = append(summary.Synced, result.Name)
+			if result.Verification == "verified" {
+				summary.Verified = append(summary.Verified, result.Name)
+			}
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Successfully synced %s with upstream (was behind by %s commits)\n"), syncIcon, result.Name, formatBehind(result.Behind, result.BehindCapped))
+			}
+		case "skipped":
+			summary.Skipped[result.Name] = result.Reason
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Skipped %s: %s\n"), skipIcon, result.Name, result.Reason)
+			}
+		case "no_write_access":
+			summary.NoWriteAccess[result.Name] = result.Reason
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Cannot sync %s: %s\n"), skipIcon, result.Name, result.Reason)
+			}
+		case "verify_failed":
+			summary.VerifyFailed[result.Name] = result.Error
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Synced %s but verification failed: %s\n"), warnIcon, result.Name, result.Error)
+			}
+		case "pending_review":
+			summary.PendingReview[result.Name] = result.Reason
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Held back %s (behind by %s commits): %s\n"), warnIcon, result.Name, formatBehind(result.Behind, result.BehindCapped), result.Reason)
+			}
+		case "quarantined":
+			summary.Quarantined[result.Name] = result.Reason
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Quarantined %s: %s\n"), skipIcon, result.Name, result.Reason)
+			}
+		case "sso_required":
+			summary.SSORequired[result.Name] = result.Reason
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Cannot access %s: %s\n"), skipIcon, result.Name, result.Reason)
+			}
+		case "timed_out":
+			summary.TimedOut = append(summary.TimedOut, result.Name)
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Timed out processing %s: %s\n"), errorIcon, result.Name, result.Error)
+			}
+		case "error":
+			summary.Errors[result.Name] = result.Error
+			if !o.jsonOutput {
+				fmt.Printf(tr("%s Error checking %s: %s\n"), errorIcon, result.Name, result.Error)
+			}
+		}
+		if !o.jsonOutput {
+			for _, warning := range result.Warnings {
+				fmt.Printf("   %s %s\n", warnIcon, color.YellowString("%s: %s", result.Name, warning))
+			}
+			if len(result.WorkflowChanges) > 0 {
+				fmt.Printf(tr("   %s %s: upstream changes workflows %s\n"), infoIcon, result.Name, strings.Join(result.WorkflowChanges, ", "))
+			}
+			for _, risk := range result.WorkflowRisks {
+				fmt.Printf("   %s %s\n", warnIcon, color.YellowString("%s: %s", result.Name, risk))
+			}
+		}
+	}
+
+	snap.save(ctx)
+	usage.save(ctx)
+	writeMetrics(ctx, client, o.times)
+	health.report(len(summary.Errors)+len(summary.TimedOut)+len(summary.VerifyFailed) > 0 || summary.CanaryHalted != "",
+		fmt.Sprintf("furca %s: %d synced, %d up to date, %d errors, %d timed out, %d failed verification",
+			health.command, len(summary.Synced), len(summary.UpToDate), len(summary.Errors), len(summary.TimedOut), len(summary.VerifyFailed)))
+	if !o.dryRun {
+		updateAlerts(ctx, failures, alerted, o.alertAfter)
+		saveFailures(ctx, failures)
+	}
+
+	// Write the plan for furca apply
+	if o.planOut != "" {
+		if err := writePlanFile(o.planOut, planFile); err != nil {
+			return err
+		}
+	}
+
+	// Write results to a file if requested
+	summary.sort(o.stableOutput)
+	if o.outFile != "" {
+		if err := writeJSONFile(o.outFile, summary, o.appendOut); err != nil {
+			log.Errorf("Failed to write results: %v", err)
+		}
+	}
+
+	// Print summary or JSON output
+	if err := o.writeSummary(os.Stdout, &summary, len(planFile.Syncs)); err != nil {
+		log.Errorf("Failed to generate JSON output: %v", err)
+	}
+	return nil
+}
+
+// newSummary returns the empty summary of the run with the given ID. Its lists
+// and maps are empty rather than nil, so that JSON output always has them.
+func (o *syncOptions) newSummary(runID string) SyncSummary {
+	return SyncSummary{
+		RunID:    runID,
+		Synced:   []string{},
+		UpToDate: []string{},
+		Skipped:  make(map[string]string),
+		TimedOut: []string{},
+		Verified: []string{},
+
+		NoWriteAccess: make(map[string]string),
+		Quarantined:   make(map[string]string),
+		VerifyFailed:  make(map[string]string),
+		Errors:        make(map[string]string),
+		Warnings:      make(map[string][]string),
+		Workflows:     make(map[string][]string),
+		WorkflowRisks: make(map[string][]string),
+		PendingReview: make(map[string]string),
+		SSORequired:   make(map[string]string),
+		Group:         o.group,
+		Timestamp:     o.times.format(time.Now()),
+	}
+}
+
+// writeSummary writes the summary of a sync run to w, as JSON with --json or
+// as the console summary otherwise, which mentions the plannedSyncs written to
+// --plan-out.
+func (o *syncOptions) writeSummary(w io.Writer, summary *SyncSummary, plannedSyncs int) error {
+	if o.jsonOutput {
+		return writeJSON(w, summary, o.fields)
+	}
+
+	writePlan(w, summary.Plan)
+
+	// Print summary
+	writeSummaryHeader(w, o.group)
+	if o.dryRun {
+		fmt.Fprintf(w, tr("%s Would sync repositories: %d\n"), syncIcon, len(summary.Synced))
+	} else {
+		fmt.Fprintf(w, tr("%s Synced repositories: %d\n"), syncIcon, len(summary.Synced))
+	}
+	fmt.Fprintf(w, tr("%s Up-to-date repositories: %d\n"), successIcon, len(summary.UpToDate))
+	if len(summary.Skipped) > 0 {
+		fmt.Fprintf(w, tr("%s Skipped repositories: %d\n"), skipIcon, len(summary.Skipped))
+	}
+	if len(summary.NoWriteAccess) > 0 {
+		fmt.Fprintf(w, tr("%s Repositories without write access: %d\n"), skipIcon, len(summary.NoWriteAccess))
+	}
+	if len(summary.PendingReview) > 0 {
+		fmt.Fprintf(w, tr("%s Syncs held back for review: %d\n"), warnIcon, len(summary.PendingReview))
+	}
+	if len(summary.Quarantined) > 0 {
+		fmt.Fprintf(w, tr("%s Quarantined repositories: %d\n"), skipIcon, len(summary.Quarantined))
+	}
+	if len(summary.SSORequired) > 0 {
+		fmt.Fprintf(w, tr("%s Repositories needing SSO authorization: %d\n"), skipIcon, len(summary.SSORequired))
+	}
+	if len(summary.VerifyFailed) > 0 {
+		fmt.Fprintf(w, tr("%s Failed verifications: %d\n"), warnIcon, len(summary.VerifyFailed))
+	}
+	if len(summary.TimedOut) > 0 {
+		fmt.Fprintf(w, tr("%s Timed out repositories: %d\n"), errorIcon, len(summary.TimedOut))
+	}
+	fmt.Fprintf(w, tr("%s Errors encountered: %d\n"), errorIcon, len(summary.Errors))
+	if len(summary.Warnings) > 0 {
+		fmt.Fprintf(w, "%s %s\n", warnIcon, color.YellowString(tr("Repositories receiving license or CODEOWNERS changes: %d"), len(summary.Warnings)))
+	}
+	if len(summary.WorkflowRisks) > 0 {
+		fmt.Fprintf(w, "%s %s\n", warnIcon, color.YellowString(tr("Repositories receiving workflow permission or third-party action changes: %d"), len(summary.WorkflowRisks)))
+	}
+
+	if len(summary.Errors) > 0 {
+		fmt.Fprintln(w, tr("\nSee logs for details."))
+	}
+	if summary.CanaryHalted != "" {
+		fmt.Fprintf(w, tr("\n%s Stopped after the canary forks: %s\n"), warnIcon, summary.CanaryHalted)
+	}
+	if summary.DiscoveryIncomplete {
+		fmt.Fprintf(w, "\n%s %s\n", warnIcon, color.YellowString(tr("Fork discovery was incomplete; run again with --resume to cover the remaining forks")))
+	}
+	if o.planOut != "" {
+		fmt.Fprintf(w, tr("\n%s Wrote a plan of %d syncs to %s; run furca apply %s to make them\n"), infoIcon, plannedSyncs, o.planOut, o.planOut)
+	}
+	return nil
+}
+
+// syncFork checks a single fork against its upstream and syncs it if it is behind,
+// honoring the fork's repository config, the organization policy, and dry-run mode.
+// In a dry run, the changes that would have been made are listed in the result's plan.
+func (o *syncOptions) syncFork(ctx context.Context, client *github.Client, policy *github.Policy, fork github.Repository) (result SyncResult) {
+	plan := &actionPlan{}
+	defer func() { result.Plan = plan.actions }()
+
+	// Attach per-repository fields to every log entry from this worker
+	ctx = logger.WithFields(ctx, "repo", fork.Name, "owner", fork.Owner)
+	log := logger.FromContext(ctx)
+	log.Debugf("Checking repository: %s", fork.Name)
+
+	// Forks the token cannot push to can never be synced; only report their drift if asked
+	canPush, err := client.WriteAccess(ctx, fork)
+	if err != nil {
+		return errorResult(ctx, fork.Name, err.Error(), err)
+	}
+	switch {
+	case canPush:
+		o.trace.step("The token can push to %s", fork.FullName)
+	case o.includeReadOnly:
+		o.trace.step("The token cannot push to %s; its drift is still checked because of --include-read-only", fork.FullName)
+	default:
+		o.trace.step("The token cannot push to %s, and --include-read-only is not set", fork.FullName)
+	}
+	if !canPush && !o.includeReadOnly {
+		return SyncResult{
+			Name:   fork.Name,
+			Status: "no_write_access",
+			Reason: "token lacks push access",
+		}
+	}
+
+	// Honor the fork's own .github/furca.yml and .furcaignore
+	repoConfig, err := client.GetRepoConfig(ctx, fork)
+	if err != nil {
+		return errorResult(ctx, fork.Name, fmt.Sprintf("failed to read repository config: %v", err), err)
+	}
+	if !repoConfig.SyncEnabled() {
+		o.trace.step("Automatic sync is turned off by %s or .furcaignore", github.RepoConfigPath)
+		return SyncResult{
+			Name:   fork.Name,
+			Status: "skipped",
+			Reason: "automatic sync disabled by repository config",
+		}
+	}
+	// Branches selected by --branch-pattern are synced with the same-named upstream branch
+	if fork.Branch == "" {
+		fork.Branch = repoConfig.Branch
+		fork.UpstreamRef = repoConfig.UpstreamRef
+		if fork.UpstreamRef == "" {
+			fork.UpstreamRef = o.upstreamRef
+		}
+		o.trace.step("%s", explainBranch(fork, repoConfig))
+	} else {
+		o.trace.step("Branch %s was selected by --branch-pattern %s", fork.Branch, o.branchPattern)
+	}
+	if fork.Branch != "" {
+		ctx = logger.WithFields(ctx, "branch", fork.Branch)
+		log = logger.FromContext(ctx)
+	}
+	strategy := repoConfig.Strategy
+	if strategy == "" {
+		strategy = policy.StrategyFor(fork)
+	}
+	o.trace.step("%s", explainStrategy(repoConfig.Strategy, strategy))
+
+	// Check if fork is behind upstream with retries
+	comparison, err := checkRepositoryWithRetries(ctx, client, fork, o.maxRetries, o.retryDelay)
+	if err != nil {
+		return errorResult(ctx, fork.Name, fmt.Sprintf("failed to compare commits: %v", err), err)
+	}
+
+	behindBy := comparison.BehindBy
+	o.trace.step("%s", explainComparison(fork, comparison))
+
+	// Report which upstream branch the fork's branch was compared with if the names differ
+	var upstreamBranch string
+	if comparison.Branch != comparison.UpstreamBranch && fork.UpstreamRef == "" {
+		upstreamBranch = comparison.UpstreamBranch
+	}
+	if behindBy == 0 {
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, 0)
+		return SyncResult{
+			Name:   fork.Name,
+			Status: "up_to_date",
+			Behind: 0,
 		}
+	}
+
+	// GitHub only merges upstream branches of the same name, so a fork whose default
+	// branch differs from upstream's is fast-forwarded to the upstream branch instead
+	if comparison.Mapped {
+		if comparison.AheadBy > 0 {
+			o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+			return SyncResult{
+				Name:           fork.Name,
+				Status:         "skipped",
+				Reason:         fmt.Sprintf("fork's %s is %d commits ahead of upstream %s; branches with different names can only be fast-forwarded", comparison.Branch, comparison.AheadBy, comparison.UpstreamBranch),
+				Behind:         behindBy,
+				BehindCapped:   comparison.BehindCapped,
+				UpstreamBranch: upstreamBranch,
+			}
+		}
+		fork.UpstreamRef = comparison.UpstreamBranch
+	}
+
+	// A fast-forward-only fork must not have diverged from upstream
+	if fork.UpstreamRef != "" && comparison.AheadBy > 0 {
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+		return SyncResult{
+			Name:           fork.Name,
+			Status:         "skipped",
+			Reason:         fmt.Sprintf("fork is %d commits ahead of upstream %s; pinned forks can only be fast-forwarded", comparison.AheadBy, fork.UpstreamRef),
+			Behind:         behindBy,
+			BehindCapped:   comparison.BehindCapped,
+			UpstreamBranch: upstreamBranch,
+		}
+	}
+	if strategy == github.StrategyFastForward && comparison.AheadBy > 0 {
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+		return SyncResult{
+			Name:           fork.Name,
+			Status:         "skipped",
+			Reason:         fmt.Sprintf("fork is %d commits ahead of upstream (ff-only strategy)", comparison.AheadBy),
+			Behind:         behindBy,
+			BehindCapped:   comparison.BehindCapped,
+			UpstreamBranch: upstreamBranch,
+		}
+	}
 
-		// Print summary or JSON output
-		if jsonOutput {
-			jsonData, err := json.MarshalIndent(summary, "", "  ")
-			if err != nil {
-				log.Errorf("Failed to generate JSON output: %v", err)
+	if !canPush {
+		return SyncResult{
+			Name:           fork.Name,
+			Status:         "no_write_access",
+			Reason:         fmt.Sprintf("token lacks push access; behind upstream by %d commits", behindBy),
+			Behind:         behindBy,
+			BehindCapped:   comparison.BehindCapped,
+			UpstreamBranch: upstreamBranch,
+		}
+	}
+
+	// GitHub can only merge upstream changes into repositories it knows are forks
+	if fork.Detached {
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+		return SyncResult{
+			Name:           fork.Name,
+			Status:         "skipped",
+			Reason:         fmt.Sprintf("upstream is mapped in config and GitHub cannot merge into a detached fork; behind upstream by %d commits", behindBy),
+			Behind:         behindBy,
+			BehindCapped:   comparison.BehindCapped,
+			UpstreamBranch: upstreamBranch,
+		}
+	}
+
+	// Only bring in upstream commits whose CI passed
+	if o.requireUpstreamGreen {
+		ref := comparison.UpstreamBranch
+		if fork.UpstreamRef != "" {
+			ref = fork.UpstreamRef
+		}
+		state, names, err := client.UpstreamCIState(ctx, fork, ref)
+		if err != nil {
+			return errorResult(ctx, fork.Name, fmt.Sprintf("cannot check upstream CI for --require-upstream-green: %v", err), err)
+		}
+		o.trace.step("Upstream CI for %s is %s", ref, describeCIState(state, names))
+		if state == github.CIFailing || state == github.CIPending {
+			o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+			return SyncResult{
+				Name:           fork.Name,
+				Status:         "skipped",
+				Reason:         fmt.Sprintf("upstream CI for %s is %s (--require-upstream-green)", ref, describeCIState(state, names)),
+				Behind:         behindBy,
+				BehindCapped:   comparison.BehindCapped,
+				UpstreamBranch: upstreamBranch,
+			}
+		}
+	}
+
+	// Inspect the incoming changes for license, ownership, and workflow files
+	var warnings, workflows, risks []string
+	var review *syncReview
+	changes, err := client.IncomingChanges(ctx, fork, comparison)
+	files := github.ChangedPaths(changes)
+	if err != nil {
+		if o.blockWorkflowChanges {
+			return errorResult(ctx, fork.Name, fmt.Sprintf("cannot check upstream changes for workflow files: %v", err), err)
+		}
+		if o.maxFiles > 0 || o.maxLines > 0 {
+			return errorResult(ctx, fork.Name, fmt.Sprintf("cannot check upstream changes against --max-files and --max-lines: %v", err), err)
+		}
+		if o.onlyIfPaths != "" {
+			return errorResult(ctx, fork.Name, fmt.Sprintf("cannot check upstream changes against --only-if-paths: %v", err), err)
+		}
+		log.Warnf("Failed to list upstream changes to %s: %v", fork.FullName, err)
+		o.trace.step("The incoming changes could not be listed: %v", err)
+	} else {
+		o.trace.step("%s", explainFiles(files, o.onlyIfPaths))
+		// Leave forks alone unless upstream touches the paths that matter; a
+		// truncated file list may hide matching files, so it always counts
+		if o.onlyIfPaths != "" && len(files) < github.IncomingFileLimit && len(github.MatchingFiles(files, parsePathPatterns(o.onlyIfPaths))) == 0 {
+			o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+			return SyncResult{
+				Name:           fork.Name,
+				Status:         "skipped",
+				Reason:         fmt.Sprintf("incoming changes do not touch %s", o.onlyIfPaths),
+				Behind:         behindBy,
+				BehindCapped:   comparison.BehindCapped,
+				UpstreamBranch: upstreamBranch,
+			}
+		}
+
+		if compliance := github.ComplianceFiles(files); len(compliance) > 0 {
+			warnings = append(warnings, fmt.Sprintf("upstream changes %s; review before relying on the synced code", strings.Join(compliance, ", ")))
+		}
+		workflows = github.WorkflowFiles(files)
+		risks = github.WorkflowRisks(changes)
+		for _, risk := range risks {
+			o.trace.step("Workflow change: %s", risk)
+		}
+		if len(workflows) > 0 {
+			if o.blockWorkflowChanges {
+				o.trace.step("Upstream changes workflows %s, so --block-workflow-changes routes the sync through a pull request", strings.Join(workflows, ", "))
 			} else {
-				fmt.Println(string(jsonData))
+				o.trace.step("Upstream changes workflows %s; they are merged because --block-workflow-changes is not set", strings.Join(workflows, ", "))
 			}
+		}
+		review = o.needsReview(fork, workflows, changes, behindBy)
+		if review != nil && review.oversized {
+			o.trace.step("The sync is routed through a pull request because %s", review.reason)
+		}
+	}
+
+	// If dry run, just report what would happen
+	if o.dryRun {
+		if review != nil {
+			plan.add("open_pull_request", "open a pull request from upstream %s into %s because %s", comparison.UpstreamBranch, comparison.Branch, review.reason)
+			o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
 		} else {
-			// Print summary
-			fmt.Println("\n📊 Summary:")
-			if dryRun {
-				fmt.Printf("%s Would sync repositories: %d\n", syncIcon, len(summary.Synced))
+			branch := comparison.Branch
+			if comparison.Renamed && o.followRenames {
+				plan.add("rename_branch", "rename branch %s to %s to follow upstream", branch, comparison.UpstreamBranch)
+				branch = comparison.UpstreamBranch
+			}
+			if fork.UpstreamRef != "" {
+				plan.add("fast_forward", "fast-forward %s to upstream %s", branch, fork.UpstreamRef)
 			} else {
-				fmt.Printf("%s Synced repositories: %d\n", syncIcon, len(summary.Synced))
+				plan.add("merge_upstream", "merge %s upstream commits into %s", formatBehind(behindBy, comparison.BehindCapped), branch)
 			}
-			fmt.Printf("%s Up-to-date repositories: %d\n", successIcon, len(summary.UpToDate))
-			fmt.Printf("%s Errors encountered: %d\n", errorIcon, len(summary.Errors))
-
-			if len(summary.Errors) > 0 {
-				fmt.Println("\nSee logs for details.")
+			if o.actionsDisabled(repoConfig) {
+				plan.add("disable_actions", "turn off GitHub Actions")
 			}
+			o.reportFreshness(ctx, client, plan, fork, branch, 0)
 		}
-	},
+		result = SyncResult{
+			Name:            fork.Name,
+			Status:          "would_sync",
+			Behind:          behindBy,
+			BehindCapped:    comparison.BehindCapped,
+			UpstreamBranch:  upstreamBranch,
+			Warnings:        warnings,
+			WorkflowChanges: workflows,
+			WorkflowRisks:   risks,
+		}
+		if o.planOut != "" {
+			o.recordSync(ctx, client, fork, comparison, review, &result)
+		}
+		return result
+	}
+
+	// Route workflow changes and oversized syncs through a pull request instead of merging them
+	if review != nil {
+		url, err := client.OpenSyncPullRequest(ctx, fork, comparison, review.title, review.body)
+		if err != nil {
+			return errorResult(ctx, fork.Name, fmt.Sprintf("%s and the pull request could not be opened: %v", review.reason, err), err)
+		}
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+		return SyncResult{
+			Name:            fork.Name,
+			Status:          "pending_review",
+			Reason:          fmt.Sprintf("%s; review and merge %s", review.reason, url),
+			Behind:          behindBy,
+			BehindCapped:    comparison.BehindCapped,
+			UpstreamBranch:  upstreamBranch,
+			Warnings:        warnings,
+			WorkflowChanges: workflows,
+			WorkflowRisks:   risks,
+		}
+	}
+
+	// Follow an upstream branch rename by renaming the fork's branch to match
+	fork.Branch = comparison.Branch
+	if comparison.Renamed && o.followRenames {
+		log.Infof("Renaming branch %s of %s to %s to follow upstream", comparison.Branch, fork.FullName, comparison.UpstreamBranch)
+		if err := client.RenameBranch(ctx, fork, comparison.Branch, comparison.UpstreamBranch); err != nil {
+			return errorResult(ctx, fork.Name, err.Error(), err)
+		}
+		fork.Branch = comparison.UpstreamBranch
+		comparison.Branch = comparison.UpstreamBranch
+	}
+
+	// Sync fork with upstream with retries
+	log.Debugf("Syncing %s with upstream...", fork.Name)
+	err = o.syncRepository(ctx, client, fork)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to sync repository: %v", err)
+		if comparison.Renamed && !o.followRenames {
+			errMsg += fmt.Sprintf(" (upstream renamed %s to %s; use --follow-renames to rename the fork's branch to match)", comparison.Branch, comparison.UpstreamBranch)
+		}
+		o.reportFreshness(ctx, client, plan, fork, comparison.Branch, behindBy)
+		return errorResult(ctx, fork.Name, errMsg, err)
+	}
+
+	result = SyncResult{
+		Name:           fork.Name,
+		Status:         "synced",
+		Behind:         behindBy,
+		BehindCapped:   comparison.BehindCapped,
+		UpstreamBranch: upstreamBranch,
+		Warnings:       warnings,
+
+		WorkflowChanges: workflows,
+		WorkflowRisks:   risks,
+	}
+	remaining := 0
+	if o.verifySync {
+		result, remaining = o.verifyFork(ctx, client, fork, result)
+	}
+
+	// Keep workflows pulled in from upstream from running on the fork
+	if o.actionsDisabled(repoConfig) {
+		if changed, err := client.DisableActions(ctx, fork); err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+		} else if changed {
+			log.Infof("Disabled GitHub Actions on %s", fork.FullName)
+		}
+	}
+
+	o.reportFreshness(ctx, client, plan, fork, comparison.Branch, remaining)
+	return result
+}
+
+// describeCIState describes the CI state of an upstream commit, naming up to
+// three of the statuses or check runs that failed or are still running.
+func describeCIState(state string, names []string) string {
+	var what string
+	switch state {
+	case github.CIFailing:
+		what = "failing"
+	case github.CIPending:
+		what = "still running"
+	case github.CINone:
+		return "not reported; the commit has no statuses or check runs"
+	default:
+		return "green"
+	}
+	if len(names) > 3 {
+		return fmt.Sprintf("%s: %s and %d more", what, strings.Join(names[:3], ", "), len(names)-3)
+	}
+	return fmt.Sprintf("%s: %s", what, strings.Join(names, ", "))
+}
+
+// syncReview describes why a sync is routed through a pull request for review
+// instead of being merged.
+type syncReview struct {
+	reason    string // Why, in a form that fits "... and the pull request could not be opened"
+	title     string
+	body      string
+	oversized bool // The sync exceeds --max-files or --max-lines
+}
+
+// needsReview returns why the incoming changes should be reviewed in a pull
+// request before they reach the fork, or nil if they can be merged: they
+// change workflows and --block-workflow-changes is set, or they change more
+// files or lines than --max-files or --max-lines allow.
+func (o *syncOptions) needsReview(fork github.Repository, workflows []string, changes []github.FileChange, behindBy int) *syncReview {
+	if o.blockWorkflowChanges && len(workflows) > 0 {
+		return &syncReview{
+			reason: "upstream changes workflows",
+			title:  fmt.Sprintf("Sync with %s/%s (includes workflow changes)", fork.ParentOwner, fork.ParentName),
+			body: fmt.Sprintf("Furca held back this sync because the %d upstream commits change GitHub Actions workflows:\n\n- %s\n\nReview the workflow changes before merging.",
+				behindBy, strings.Join(workflows, "\n- ")),
+		}
+	}
+
+	lines := 0
+	for _, change := range changes {
+		lines += change.Additions + change.Deletions
+	}
+	// GitHub stops listing files at the limit, so a full list is only a lower bound
+	atLeast := ""
+	if len(changes) >= github.IncomingFileLimit {
+		atLeast = "at least "
+	}
+	var exceeded []string
+	if o.maxFiles > 0 && len(changes) > o.maxFiles {
+		exceeded = append(exceeded, fmt.Sprintf("%s%d files (--max-files %d)", atLeast, len(changes), o.maxFiles))
+	}
+	if o.maxLines > 0 && (lines > o.maxLines || atLeast != "") {
+		exceeded = append(exceeded, fmt.Sprintf("%s%d lines (--max-lines %d)", atLeast, lines, o.maxLines))
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	what := strings.Join(exceeded, " and ")
+	return &syncReview{
+		reason: "upstream changes " + what,
+		title:  fmt.Sprintf("Sync with %s/%s (large change)", fork.ParentOwner, fork.ParentName),
+		body: fmt.Sprintf("Furca held back this sync because the %d upstream commits change %s, more than it merges without review.\n\nReview the changes before merging.",
+			behindBy, what),
+		oversized: true,
+	}
+}
+
+// syncRepository syncs a fork's branch with upstream, with retries, and
+// records the change in the audit log.
+func (o *syncOptions) syncRepository(ctx context.Context, client *github.Client, fork github.Repository) error {
+	before := o.audit.head(ctx, client, fork, fork.Branch)
+	if err := syncRepositoryWithRetries(ctx, client, fork, o.maxRetries, o.retryDelay, o.retryMerges); err != nil {
+		return err
+	}
+	action := "merge_upstream"
+	if fork.UpstreamRef != "" {
+		action = "fast_forward"
+	}
+	o.audit.record(ctx, client, fork, fork.Branch, action, before)
+	return nil
+}
+
+// recordSync adds the sync a dry run would make to the result, with the commits
+// it starts from and leads to, so that furca apply can make exactly that sync.
+// Syncs that need a branch rename or a pull request are left to furca sync.
+func (o *syncOptions) recordSync(ctx context.Context, client *github.Client, fork github.Repository, comparison *github.Comparison, review *syncReview, result *SyncResult) {
+	log := logger.FromContext(ctx)
+	switch {
+	case review != nil:
+		log.Warnf("Leaving %s out of the plan: %s, so furca sync routes it through a pull request", fork.FullName, review.reason)
+		return
+	case comparison.Renamed:
+		log.Warnf("Leaving %s out of the plan: upstream renamed %s to %s", fork.FullName, comparison.Branch, comparison.UpstreamBranch)
+		return
+	}
+
+	planned := PlannedSync{
+		Fork:        fork.FullName,
+		Branch:      comparison.Branch,
+		Upstream:    fork.ParentOwner + "/" + fork.ParentName,
+		UpstreamRef: comparison.UpstreamBranch,
+		Action:      "merge_upstream",
+		BehindBy:    comparison.BehindBy,
+	}
+	if fork.UpstreamRef != "" {
+		planned.UpstreamRef = fork.UpstreamRef
+		planned.Action = "fast_forward"
+	}
+
+	var err error
+	if planned.ForkSHA, err = client.HeadSHA(ctx, fork.Owner, fork.Name, planned.Branch); err == nil {
+		planned.UpstreamSHA, err = client.HeadSHA(ctx, fork.ParentOwner, fork.ParentName, planned.UpstreamRef)
+	}
+	if err != nil {
+		*result = errorResult(ctx, fork.Name, fmt.Sprintf("failed to record the planned sync: %v", err), err)
+		return
+	}
+	result.Sync = &planned
+}
+
+// actionsDisabled reports whether GitHub Actions should be turned off on a fork
+// after syncing it, as set by --disable-actions or the fork's repository config.
+func (o *syncOptions) actionsDisabled(repoConfig *github.RepoConfig) bool {
+	if repoConfig.DisableActions != nil {
+		return *repoConfig.DisableActions
+	}
+	return o.disableActions
+}
+
+// syncForkWithTimeout runs syncFork, giving up after the per-repository timeout.
+func (o *syncOptions) syncForkWithTimeout(ctx context.Context, client *github.Client, policy *github.Policy, fork github.Repository) SyncResult {
+	result, ok := runWithTimeout(ctx, o.repoTimeout, func(ctx context.Context) SyncResult {
+		return o.syncFork(ctx, client, policy, fork)
+	})
+	if !ok {
+		result = SyncResult{
+			Name:   fork.Name,
+			Status: "timed_out",
+			Error:  fmt.Sprintf("check and sync did not finish within %s; the outcome of any in-flight sync is unknown", o.repoTimeout),
+		}
+	}
+	return result
+}
+
+// syncBranches syncs every branch of the fork that matches --branch-pattern with
+// the upstream branch of the same name, returning one result per branch named
+// fork:branch. Matching branches that upstream does not have are skipped.
+func (o *syncOptions) syncBranches(ctx context.Context, client *github.Client, policy *github.Policy, fork github.Repository) []SyncResult {
+	shared, forkOnly, err := client.MatchingBranches(ctx, fork, o.branchPattern)
+	if err != nil {
+		return []SyncResult{errorResult(ctx, fork.Name, err.Error(), err)}
+	}
+	if len(shared) == 0 && len(forkOnly) == 0 {
+		return []SyncResult{{
+			Name:   fork.Name,
+			Status: "skipped",
+			Reason: fmt.Sprintf("no branches match %s", o.branchPattern),
+		}}
+	}
+
+	var results []SyncResult
+	for _, branch := range forkOnly {
+		results = append(results, SyncResult{
+			Name:   fork.Name + ":" + branch,
+			Status: "skipped",
+			Reason: fmt.Sprintf("upstream %s/%s has no branch %s", fork.ParentOwner, fork.ParentName, branch),
+		})
+	}
+	for _, branch := range shared {
+		branchFork := fork
+		branchFork.Branch = branch
+		result := o.syncForkWithTimeout(ctx, client, policy, branchFork)
+		result.Name = fork.Name + ":" + branch
+		results = append(results, result)
+	}
+	return results
+}
+
+// errorResult returns the error result for a failed repository operation. If
+// the failure came from a GitHub API response, its request ID is recorded in the
+// result and the log so that the failure can be traced by GitHub support.
+// Failures caused by a token not authorized for SAML SSO are reported as
+// sso_required instead, since they need the user to act rather than a retry.
+func errorResult(ctx context.Context, name, message string, err error) SyncResult {
+	if url, ok := github.SSORequired(err); ok {
+		return SyncResult{
+			Name:   name,
+			Status: "sso_required",
+			Reason: ssoReason(url),
+		}
+	}
+
+	result := SyncResult{
+		Name:   name,
+		Status: "error",
+		Error:  message,
+	}
+	if id := github.RequestID(err); id != "" {
+		result.RequestID = id
+		result.Error += fmt.Sprintf(" (GitHub request ID %s)", id)
+		logger.FromContext(ctx).With("github_request_id", id).Warnf("Failed to process %s: %s", name, message)
+	}
+	return result
+}
+
+// ssoReason explains a failure caused by a token not authorized for SAML SSO,
+// pointing to the authorization URL when GitHub provided one.
+func ssoReason(url string) string {
+	if url == "" {
+		return "token is not authorized for the organization's SAML single sign-on"
+	}
+	return "token is not authorized for the organization's SAML single sign-on; authorize it at " + url
+}
+
+// verifyFork compares a freshly synced fork with its upstream again to confirm
+// that it is no longer behind, for example because upstream moved again or the
+// merge silently failed. With --verify-retry, a fork that is still behind is
+// synced once more before giving up. It returns the updated result and the
+// number of commits the fork is still behind.
+func (o *syncOptions) verifyFork(ctx context.Context, client *github.Client, fork github.Repository, result SyncResult) (SyncResult, int) {
+	log := logger.FromContext(ctx)
+
+	comparison, err := checkRepositoryWithRetries(ctx, client, fork, o.maxRetries, o.retryDelay)
+	if err == nil && comparison.BehindBy > 0 && o.verifyRetry {
+		log.Infof("%s is still behind upstream by %d commits after sync; retrying once", fork.FullName, comparison.BehindBy)
+		if err = o.syncRepository(ctx, client, fork); err == nil {
+			comparison, err = checkRepositoryWithRetries(ctx, client, fork, o.maxRetries, o.retryDelay)
+		}
+	}
+
+	switch {
+	case err != nil:
+		result.Status = "verify_failed"
+		result.Verification = "failed"
+		result.Error = fmt.Sprintf("failed to verify sync: %v", err)
+		if result.RequestID = github.RequestID(err); result.RequestID != "" {
+			result.Error += fmt.Sprintf(" (GitHub request ID %s)", result.RequestID)
+		}
+		return result, result.Behind
+	case comparison.BehindBy > 0:
+		result.Status = "verify_failed"
+		result.Verification = "failed"
+		result.Error = fmt.Sprintf("still behind upstream by %d commits after sync", comparison.BehindBy)
+		return result, comparison.BehindBy
+	default:
+		result.Verification = "verified"
+		return result, 0
+	}
+}
+
+// reportFreshness sets the furca/sync commit status on the fork's branch when
+// --set-status is enabled. Dry runs add the status to the plan instead, and failures
+// are only logged.
+func (o *syncOptions) reportFreshness(ctx context.Context, client *github.Client, plan *actionPlan, fork github.Repository, branch string, behindBy int) {
+	if !o.setStatus {
+		return
+	}
+	if o.dryRun {
+		plan.addStatus(branch, behindBy)
+		return
+	}
+	if err := client.SetFreshnessStatus(ctx, fork, branch, behindBy); err != nil {
+		logger.FromContext(ctx).Warnf("Failed to set commit status on %s: %v", fork.FullName, err)
+	}
 }
 
-// checkRepositoryWithRetries checks if a repository is behind its upstream with retries.
-// It attempts to check the repository's status up to maxRetries times, with a delay of
+// checkRepositoryWithRetries compares a repository with its upstream with retries.
+// It attempts to compare the repository up to maxRetries times, with a delay of
 // retryDelay seconds between attempts.
-func checkRepositoryWithRetries(ctx context.Context, client *github.Client, repo github.Repository, maxRetries, retryDelay int) (bool, int, error) {
+func checkRepositoryWithRetries(ctx context.Context, client *github.Client, repo github.Repository, maxRetries, retryDelay int) (*github.Comparison, error) {
 	var err error
-	var behind bool
-	var behindBy int
+	var comparison *github.Comparison
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(time.Duration(retryDelay) * time.Second)
+			if err := sleepContext(ctx, time.Duration(retryDelay)*time.Second); err != nil {
+				return nil, err
+			}
 		}
 
-		behind, behindBy, err = client.IsRepositoryBehindUpstream(ctx, repo)
+		comparison, err = client.CompareWithUpstream(ctx, repo)
 		if err == nil {
-			return behind, behindBy, nil
+			return comparison, nil
+		}
+		if _, ok := github.SSORequired(err); ok {
+			return nil, err
 		}
 
 		// Log retry attempt
 		if attempt < maxRetries {
-			logger.GetLogger().Debugf("Retry %d/%d: checking if %s is behind upstream", attempt+1, maxRetries, repo.Name)
+			logger.FromContext(ctx).Debugf("Retry %d/%d: checking if %s is behind upstream", attempt+1, maxRetries, repo.Name)
 		}
 	}
 
-	return false, 0, err
+	return nil, err
 }
 
 // syncRepositoryWithRetries syncs a repository with its upstream with retries.
 // It attempts to sync the repository up to maxRetries times, with a delay of
 // retryDelay seconds between attempts.
-func syncRepositoryWithRetries(ctx context.Context, client *github.Client, repo github.Repository, maxRetries, retryDelay int) error {
-	var err error
-
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(retryDelay) * time.Second)
-		}
+//
+// Only fast-forwards to a pinned upstream ref are idempotent: they move the
+// branch to a fixed commit. A merge that failed may still have gone through
+// on GitHub's side, so the fork is compared with upstream again before another
+// attempt, and the merge is only retried if the fork is still behind and
+// retryMerges is set.
+func syncRepositoryWithRetries(ctx context.Context, client *github.Client, repo github.Repository, maxRetries, retryDelay int, retryMerges bool) error {
+	log := logger.FromContext(ctx)
+	idempotent := repo.UpstreamRef != ""
 
-		err = client.SyncRepositoryWithUpstream(ctx, repo)
+	for attempt := 0; ; attempt++ {
+		err := client.SyncRepositoryWithUpstream(ctx, repo)
 		if err == nil {
 			return nil
 		}
+		if _, ok := github.SSORequired(err); ok {
+			return err
+		}
+		if attempt >= maxRetries {
+			return err
+		}
+		if err := sleepContext(ctx, time.Duration(retryDelay)*time.Second); err != nil {
+			return err
+		}
 
-		// Log retry attempt
-		if attempt < maxRetries {
-			logger.GetLogger().Debugf("Retry %d/%d: syncing %s with upstream", attempt+1, maxRetries, repo.Name)
+		// A failed merge may still have gone through
+		if !idempotent {
+			done, verifyErr := mergeWentThrough(ctx, client, repo)
+			switch {
+			case verifyErr != nil:
+				log.Debugf("Not retrying the merge into %s: could not compare with upstream to see whether it went through: %v", repo.Name, verifyErr)
+				return err
+			case done:
+				log.Debugf("Verified before retrying: %s is no longer behind upstream, so the failed merge went through", repo.Name)
+				return nil
+			case !retryMerges:
+				log.Debugf("Not retrying the merge into %s: it is still behind upstream, but --retry-merges is off", repo.Name)
+				return err
+			}
+			log.Debugf("Verified before retrying: %s is still behind upstream, so the merge did not go through", repo.Name)
 		}
+
+		// Log retry attempt
+		log.Debugf("Retry %d/%d: syncing %s with upstream", attempt+1, maxRetries, repo.Name)
 	}
+}
 
-	return err
+// mergeWentThrough compares the fork with upstream again after a merge call
+// failed, and reports whether the fork has caught up regardless.
+func mergeWentThrough(ctx context.Context, client *github.Client, repo github.Repository) (bool, error) {
+	comparison, err := client.CompareWithUpstream(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	log := logger.FromContext(ctx)
+	log.Debugf("Re-compared %s with upstream: behind by %d, ahead by %d", repo.Name, comparison.BehindBy, comparison.AheadBy)
+	return comparison.BehindBy == 0, nil
 }
 
 func init() {
@@ -294,22 +1319,127 @@ func init() {
 
 	// Add flags with default values from environment variables
 	defaultDryRun := viper.GetBool("DRY_RUN")
-	syncCmd.Flags().BoolVar(&dryRun, "dry-run", defaultDryRun, "Preview which repositories would be synced without making changes")
+	syncCmd.Flags().Bool("dry-run", defaultDryRun, "Preview which repositories would be synced without making changes")
+
+	// Results file with default from environment
+	defaultOutFile := viper.GetString("OUT_FILE")
+	syncCmd.Flags().String("out", defaultOutFile, "Also write JSON results to this file")
+	syncCmd.Flags().Bool("append", false, "Append results to the --out file as JSON Lines instead of replacing it")
+
+	addSyncFlags(syncCmd)
+}
+
+// addSyncFlags adds the flags shared by sync and plan to a command.
+func addSyncFlags(cmd *cobra.Command) {
+	addDiscoveryFlags(cmd)
+	addStableOutputFlag(cmd)
+	addConcurrencyFlag(cmd)
 
 	// JSON output flag with default from environment
 	defaultJsonOutput := viper.GetBool("JSON_OUTPUT")
-	syncCmd.Flags().BoolVar(&jsonOutput, "json", defaultJsonOutput, "Output results in JSON format")
+	cmd.Flags().Bool("json", defaultJsonOutput, "Output results in JSON format")
+	cmd.Flags().String("fields", "", "Only output these comma-separated JSON fields, e.g. synced,errors (implies --json)")
+
+	// Read-only fork reporting with default from environment
+	defaultIncludeReadOnly := viper.GetBool("INCLUDE_READ_ONLY")
+	cmd.Flags().Bool("include-read-only", defaultIncludeReadOnly, "Still check drift of forks the token cannot push to")
 
 	// Retry configuration with defaults from environment
 	defaultMaxRetries := viper.GetInt("MAX_RETRIES")
 	if defaultMaxRetries == 0 {
 		defaultMaxRetries = 2 // Default if not set in environment
 	}
-	syncCmd.Flags().IntVar(&maxRetries, "max-retries", defaultMaxRetries, "Maximum number of retry attempts for API operations")
+	cmd.Flags().Int("max-retries", defaultMaxRetries, "Maximum number of retry attempts for API operations")
 
 	defaultRetryDelay := viper.GetInt("RETRY_DELAY")
 	if defaultRetryDelay == 0 {
 		defaultRetryDelay = 3 // Default if not set in environment
 	}
-	syncCmd.Flags().IntVar(&retryDelay, "retry-delay", defaultRetryDelay, "Delay in seconds between retry attempts")
+	cmd.Flags().Int("retry-delay", defaultRetryDelay, "Delay in seconds between retry attempts")
+
+	// Merges are only retried after confirming the failed attempt did not go through
+	defaultRetryMerges := !viper.IsSet("RETRY_MERGES") || viper.GetBool("RETRY_MERGES")
+	cmd.Flags().Bool("retry-merges", defaultRetryMerges, "Retry a failed merge once comparing with upstream shows it did not go through")
+
+	// Repository group to limit the run to
+	cmd.Flags().String("group", "", "Only process the forks in this group from the config")
+
+	// Canary forks to sync before the rest
+	defaultCanary := viper.GetString("CANARY")
+	cmd.Flags().String("canary", defaultCanary, "Sync this share or number of forks first (e.g. 10% or 3) and the rest only if they stay healthy")
+	cmd.Flags().String("canary-group", "", "Sync the forks in this group from the config first, as canaries")
+	defaultCanarySoak := viper.GetDuration("CANARY_SOAK")
+	cmd.Flags().Duration("canary-soak", defaultCanarySoak, "Wait this long after syncing the canary forks before syncing the rest")
+	defaultCanaryCheck := viper.GetString("CANARY_CHECK")
+	cmd.Flags().String("canary-check", defaultCanaryCheck, "Command that must exit 0, or URL that must answer 2xx, before the rest of the forks are synced")
+
+	// Blackout windows are honored unless overridden
+	cmd.Flags().Bool("ignore-blackout", false, "Sync even during a blackout window from the config")
+
+	// Upstream CI gate with default from environment
+	defaultRequireGreen := viper.GetBool("REQUIRE_UPSTREAM_GREEN")
+	cmd.Flags().Bool("require-upstream-green", defaultRequireGreen, "Skip forks whose upstream head commit has failing or unfinished statuses or check runs")
+
+	// Size limits for unreviewed syncs with defaults from environment
+	defaultMaxFiles := viper.GetInt("MAX_FILES")
+	cmd.Flags().Int("max-files", defaultMaxFiles, "Open a pull request instead of syncing when upstream changes more files than this (0 for no limit)")
+	defaultMaxLines := viper.GetInt("MAX_LINES")
+	cmd.Flags().Int("max-lines", defaultMaxLines, "Open a pull request instead of syncing when upstream changes more lines than this (0 for no limit)")
+
+	// Activity window with default from environment
+	defaultSince := viper.GetString("SINCE")
+	cmd.Flags().String("since", defaultSince, "Only check forks whose upstream was pushed to within this window (e.g. 7d, 2w, 36h)")
+
+	// Alerting on long-failing forks with default from environment
+	defaultAlertAfter := viper.GetDuration("ALERT_AFTER")
+	cmd.Flags().Duration("alert-after", defaultAlertAfter, "Alert PagerDuty or Opsgenie when a fork has been failing for this long (0 disables)")
+
+	// Release branches to sync with default from environment
+	defaultBranchPattern := viper.GetString("BRANCH_PATTERN")
+	cmd.Flags().String("branch-pattern", defaultBranchPattern, "Sync every fork branch matching this glob (e.g. 'release/*') with the same-named upstream branch")
+
+	// Path filter for syncs with default from environment
+	defaultOnlyIfPaths := viper.GetString("ONLY_IF_PATHS")
+	cmd.Flags().String("only-if-paths", defaultOnlyIfPaths, "Skip forks whose incoming changes touch none of these comma-separated globs (e.g. 'src/**')")
+
+	// Shard of the fork list with default from environment
+	defaultShard := viper.GetString("SHARD")
+	cmd.Flags().String("shard", defaultShard, "Only process shard i of n (for example 2/4), splitting forks across runners by name")
+
+	// Per-repository time limit with default from environment
+	defaultRepoTimeout := viper.GetDuration("REPO_TIMEOUT")
+	cmd.Flags().Duration("repo-timeout", defaultRepoTimeout, "Maximum time to spend checking and syncing a single repository (0 for no limit)")
+
+	// Commit status reporting with default from environment
+	defaultSetStatus := viper.GetBool("SET_STATUS")
+	cmd.Flags().Bool("set-status", defaultSetStatus, "Set a furca/sync commit status on each fork's branch head")
+
+	// Post-sync verification with defaults from environment
+	defaultVerify := !viper.IsSet("VERIFY_SYNC") || viper.GetBool("VERIFY_SYNC")
+	cmd.Flags().Bool("verify", defaultVerify, "Compare with upstream again after each sync to confirm the fork caught up")
+	defaultVerifyRetry := viper.GetBool("VERIFY_RETRY")
+	cmd.Flags().Bool("verify-retry", defaultVerifyRetry, "Sync once more if a fork is still behind upstream after syncing")
+
+	// Quarantine of repeatedly failing forks with default from environment
+	defaultQuarantineAfter := 3
+	if viper.IsSet("QUARANTINE_AFTER") {
+		defaultQuarantineAfter = viper.GetInt("QUARANTINE_AFTER")
+	}
+	cmd.Flags().Int("quarantine-after", defaultQuarantineAfter, "Skip forks that failed this many runs in a row (0 to never skip)")
+
+	// Workflow change review with default from environment
+	defaultBlockWorkflows := viper.GetBool("BLOCK_WORKFLOW_CHANGES")
+	cmd.Flags().Bool("block-workflow-changes", defaultBlockWorkflows, "Open a pull request instead of syncing when upstream changes GitHub Actions workflows")
+
+	// Actions shutdown after sync with default from environment
+	defaultDisableActions := viper.GetBool("DISABLE_ACTIONS")
+	cmd.Flags().Bool("disable-actions", defaultDisableActions, "Turn off GitHub Actions on each fork after syncing it")
+
+	// Upstream ref to track with default from environment
+	defaultUpstreamRef := viper.GetString("UPSTREAM_REF")
+	cmd.Flags().String("upstream-ref", defaultUpstreamRef, "Compare with and fast-forward to this upstream tag, branch, or SHA instead of the matching branch")
+
+	// Branch rename handling with default from environment
+	defaultFollowRenames := viper.GetBool("FOLLOW_RENAMES")
+	cmd.Flags().Bool("follow-renames", defaultFollowRenames, "Rename a fork's branch to match when upstream has renamed it")
 }
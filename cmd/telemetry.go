@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/TFMV/furca/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// telemetryFile is the state file holding the usage statistics gathered since
+// they were last sent.
+const telemetryFile = "telemetry.json"
+
+// usageStats are anonymous counters of how Furca is used, gathered locally
+// when TELEMETRY is enabled. They hold no fork, user, or host names.
+type usageStats struct {
+	// InstallID is generated randomly on first use, so that a platform team
+	// can tell installations apart without learning anything about them
+	InstallID string `json:"install_id"`
+
+	Version string         `json:"version"`
+	Since   time.Time      `json:"since"`  // When counting started, or the last send
+	Runs    map[string]int `json:"runs"`   // Runs by command
+	Repos   int            `json:"repos"`  // Forks processed, summed over all runs
+	Errors  map[string]int `json:"errors"` // Forks that failed, by category
+}
+
+// telemetryEnabled reports whether usage statistics may be gathered.
+func telemetryEnabled() bool {
+	return viper.GetBool("TELEMETRY")
+}
+
+// newUsageStats returns empty usage statistics of the installation, counting
+// from now.
+func newUsageStats(installID string) *usageStats {
+	return &usageStats{
+		InstallID: installID,
+		Version:   version,
+		Since:     time.Now().UTC().Truncate(time.Second),
+		Runs:      make(map[string]int),
+		Errors:    make(map[string]int),
+	}
+}
+
+// loadUsageStats returns the saved usage statistics. The first time, it saves
+// empty ones with a new installation ID.
+func loadUsageStats() (*usageStats, error) {
+	stats := &usageStats{}
+	found, err := state.Load(telemetryFile, stats)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		stats.Version = version
+		return stats, nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate installation ID: %w", err)
+	}
+	stats = newUsageStats(hex.EncodeToString(id))
+	if err := state.Save(telemetryFile, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// runUsage counts the forks a run processes and the failures among them, to
+// be added to the usage statistics when the run ends.
+type runUsage struct {
+	command string
+	repos   int
+	errors  map[string]int
+}
+
+// newRunUsage starts counting the usage of a run of the command.
+func newRunUsage(command string) *runUsage {
+	return &runUsage{command: command, errors: make(map[string]int)}
+}
+
+// count records a processed fork with the status it ended in.
+func (u *runUsage) count(status string) {
+	u.repos++
+	switch status {
+	case "error", "timed_out", "verify_failed", "no_write_access", "quarantined", "sso_required":
+		u.errors[status]++
+	}
+}
+
+// save adds the run to the usage statistics if TELEMETRY is enabled, logging
+// any error.
+func (u *runUsage) save(ctx context.Context) {
+	if !telemetryEnabled() {
+		return
+	}
+	log := logger.FromContext(ctx)
+	stats, err := loadUsageStats()
+	if err != nil {
+		log.Warnf("Failed to load usage statistics: %v", err)
+		return
+	}
+	stats.Runs[u.command]++
+	stats.Repos += u.repos
+	for category, n := range u.errors {
+		stats.Errors[category] += n
+	}
+	if err := state.Save(telemetryFile, stats); err != nil {
+		log.Warnf("Failed to save usage statistics: %v", err)
+		return
+	}
+	log.Debugf("Recorded %s run with %d forks in the usage statistics", u.command, u.repos)
+}
+
+// telemetryCmd groups the commands that work with the usage statistics.
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show or send the anonymous usage statistics gathered locally",
+	Long: `With TELEMETRY enabled (it is disabled by default), Furca counts its sync
+and ci-check runs, the forks they process, and the failures among them by
+category, such as error or timed_out, in the state directory. The counts hold
+no fork, user, or host names, only a random installation ID.
+
+Nothing leaves the machine unless telemetry send is run, which posts the
+counts as JSON to TELEMETRY_URL, an endpoint of your own choosing, for
+example to measure adoption across an organization.`,
+}
+
+// telemetryShowCmd represents the telemetry show command
+var telemetryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the usage statistics gathered since they were last sent",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !telemetryEnabled() {
+			fmt.Printf("%s Telemetry is disabled; set TELEMETRY=true to gather usage statistics\n", infoIcon)
+			return nil
+		}
+		stats, err := loadUsageStats()
+		if err != nil {
+			return fmt.Errorf("failed to load usage statistics: %w", err)
+		}
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode usage statistics: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+// telemetrySendCmd represents the telemetry send command
+var telemetrySendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Post the usage statistics to TELEMETRY_URL and start counting anew",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !telemetryEnabled() {
+			return errors.New("telemetry is disabled; set TELEMETRY=true to gather and send usage statistics")
+		}
+		endpoint := viper.GetString("TELEMETRY_URL")
+		if endpoint == "" {
+			return errors.New("TELEMETRY_URL is not set")
+		}
+		if offline {
+			return errors.New("usage statistics cannot be sent offline")
+		}
+		stats, err := loadUsageStats()
+		if err != nil {
+			return fmt.Errorf("failed to load usage statistics: %w", err)
+		}
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("failed to encode usage statistics: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("invalid TELEMETRY_URL: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent())
+		resp, err := alertClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send usage statistics: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("failed to send usage statistics: %s answered %s", endpoint, resp.Status)
+		}
+
+		// Keep the installation ID, but count anew from here
+		if err := state.Save(telemetryFile, newUsageStats(stats.InstallID)); err != nil {
+			return fmt.Errorf("sent usage statistics, but failed to reset them: %w", err)
+		}
+		fmt.Printf("%s Sent usage statistics of %d runs to %s\n", successIcon, totalRuns(stats), endpoint)
+		return nil
+	},
+}
+
+// totalRuns returns the number of runs of all commands in the statistics.
+func totalRuns(stats *usageStats) int {
+	var total int
+	for _, n := range stats.Runs {
+		total += n
+	}
+	return total
+}
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryShowCmd)
+	telemetryCmd.AddCommand(telemetrySendCmd)
+}
@@ -0,0 +1,2 @@
+git -C 'widgets' remote add upstream 'https://github.com/acme/widgets.git'  # octocat/widgets
+git -C 'it'\''s' remote add upstream 'git@github.com:other/quoted.git'  # octocat/it's
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"time"
+)
+
+// runWithTimeout runs fn with a context that expires after timeout. If fn has not
+// returned by then, its context is canceled and runWithTimeout returns at once with
+// ok set to false, abandoning fn rather than waiting for it; this keeps one hung
+// operation from stalling the whole run. A non-positive timeout disables the limit.
+func runWithTimeout[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) T) (result T, ok bool) {
+	if timeout <= 0 {
+		return fn(ctx), true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan T, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case result = <-done:
+		return result, true
+	case <-ctx.Done():
+		return result, false
+	}
+}
+
+// sleepContext pauses for d, returning early with the context's error if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
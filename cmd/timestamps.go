@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts maps the named formats accepted by --time-format to Go layouts.
+var timeLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123Z,
+	"datetime":    time.DateTime,
+}
+
+// unixLayout formats timestamps as seconds since the Unix epoch.
+const unixLayout = "unix"
+
+// timestamps formats the timestamps in summaries, reports, and the audit log
+// in the layout and time zone given with --time-format and --timezone. The
+// zero value formats them as RFC 3339 in the system time zone.
+type timestamps struct {
+	layout string
+	zone   *time.Location
+}
+
+// parseTimeFormat returns the layout for a --time-format value: a named
+// format, "unix", or a Go reference layout such as "2006-01-02 15:04 MST".
+func parseTimeFormat(value string) (string, error) {
+	if value == "" {
+		return time.RFC3339, nil
+	}
+	if layout, ok := timeLayouts[strings.ToLower(value)]; ok {
+		return layout, nil
+	}
+	if strings.EqualFold(value, unixLayout) {
+		return unixLayout, nil
+	}
+	// A layout without reference elements formats every time the same way
+	if time.Unix(0, 0).UTC().Format(value) == value {
+		return "", fmt.Errorf("unknown time format %q: use rfc3339, rfc3339nano, rfc1123, datetime, unix, or a Go layout such as 2006-01-02T15:04:05Z07:00", value)
+	}
+	return value, nil
+}
+
+// parseTimeZone returns the location for a --timezone value: an IANA name such
+// as Europe/Berlin, UTC, or Local for the system time zone.
+func parseTimeZone(value string) (*time.Location, error) {
+	if value == "" || strings.EqualFold(value, "local") {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: use an IANA name such as Europe/Berlin, UTC, or Local", value)
+	}
+	return loc, nil
+}
+
+// newTimestamps returns the timestamps for --time-format and --timezone values.
+func newTimestamps(format, zone string) (timestamps, error) {
+	layout, err := parseTimeFormat(format)
+	if err != nil {
+		return timestamps{}, err
+	}
+	loc, err := parseTimeZone(zone)
+	if err != nil {
+		return timestamps{}, err
+	}
+	return timestamps{layout: layout, zone: loc}, nil
+}
+
+// location returns the time zone timestamps are written in.
+func (ts timestamps) location() *time.Location {
+	if ts.zone == nil {
+		return time.Local
+	}
+	return ts.zone
+}
+
+// format formats t in the configured format and time zone.
+func (ts timestamps) format(t time.Time) string {
+	switch ts.layout {
+	case "":
+		return t.In(ts.location()).Format(time.RFC3339)
+	case unixLayout:
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.In(ts.location()).Format(ts.layout)
+}
+
+// parse parses a timestamp written by format, or in RFC 3339 as written
+// before the format was configurable.
+func (ts timestamps) parse(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	switch ts.layout {
+	case "":
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+	case unixLayout:
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid Unix timestamp %q", value)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	return time.ParseInLocation(ts.layout, value, ts.location())
+}
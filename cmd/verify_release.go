@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/TFMV/furca/release"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// verifyReleaseOptions holds the flags of a single verify-release invocation.
+type verifyReleaseOptions struct {
+	checksums string
+	signature string
+	key       string
+}
+
+// newVerifyReleaseOptions reads the options of a verify-release invocation
+// from its flags.
+func newVerifyReleaseOptions(flags *pflag.FlagSet) (*verifyReleaseOptions, error) {
+	r := &flagReader{flags: flags}
+	o := &verifyReleaseOptions{
+		checksums: r.string("checksums"),
+		signature: r.string("signature"),
+		key:       r.string("key"),
+	}
+	return o, r.err
+}
+
+// verifyReleaseCmd represents the verify-release command
+var verifyReleaseCmd = &cobra.Command{
+	Use:   "verify-release ARCHIVE",
+	Short: "Check a downloaded Furca release against its checksums and signature",
+	Long: `The verify-release command checks that a downloaded release archive matches
+its entry in the release's checksums.txt and, with --key, that checksums.txt
+carries a valid cosign signature (checksums.txt.sig) made with that key.
+
+The checksums and signature are looked for next to the archive unless given
+with --checksums and --signature. The same checks are available to packagers
+as release.VerifyChecksum and release.VerifySignature.
+
+It exits with a non-zero status code if any check fails.`,
+	Example: `  furca verify-release furca_Linux_x86_64.tar.gz --key cosign.pub`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := newVerifyReleaseOptions(cmd.Flags())
+		if err != nil {
+			return fmt.Errorf("failed to read flags: %w", err)
+		}
+		archive := args[0]
+		checksums := o.checksums
+		if checksums == "" {
+			checksums = filepath.Join(filepath.Dir(archive), "checksums.txt")
+		}
+		signature := o.signature
+		if signature == "" {
+			signature = checksums + ".sig"
+		}
+
+		if o.key != "" {
+			if err := release.VerifySignature(checksums, signature, o.key); err != nil {
+				return err
+			}
+			fmt.Printf("%s %s is signed by the key in %s\n", successIcon, filepath.Base(checksums), o.key)
+		}
+		if err := release.VerifyChecksum(archive, checksums); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s matches its checksum in %s\n", successIcon, filepath.Base(archive), filepath.Base(checksums))
+		if o.key == "" {
+			fmt.Printf("%s The signature of %s was not checked; pass --key with the release public key to prove where the archive came from\n", warnIcon, filepath.Base(checksums))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyReleaseCmd)
+
+	verifyReleaseCmd.Flags().String("checksums", "", "Checksums file of the release (default checksums.txt next to the archive)")
+	verifyReleaseCmd.Flags().String("signature", "", "Cosign signature of the checksums file (default the checksums file with .sig appended)")
+	verifyReleaseCmd.Flags().String("key", "", "Cosign public key (PEM) of the release to check the signature with")
+}
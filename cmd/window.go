@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/github"
+)
+
+// parseWindow parses a time window such as "7d", "2w", or any duration
+// understood by time.ParseDuration (for example "36h").
+func parseWindow(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid time window %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid time window %q", s)
+	}
+	return d, nil
+}
+
+// filterActiveSince returns the forks whose upstream was pushed to at or after
+// cutoff, along with the number of forks that were left out.
+func filterActiveSince(forks []github.Repository, cutoff time.Time) ([]github.Repository, int) {
+	var active []github.Repository
+	for _, fork := range forks {
+		if !fork.ParentPushedAt.Before(cutoff) {
+			active = append(active, fork)
+		}
+	}
+	return active, len(forks) - len(active)
+}
@@ -0,0 +1,28 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// DisableActions turns off GitHub Actions for the fork, so workflows pulled in
+// from upstream (such as scheduled jobs) cannot run on it. It reports whether
+// Actions were enabled before the call.
+func (c *Client) DisableActions(ctx context.Context, repo Repository) (bool, error) {
+	permissions, _, err := c.writer().Repositories.GetActionsPermissions(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get Actions permissions: %w", err)
+	}
+	if !permissions.GetEnabled() {
+		return false, nil
+	}
+
+	if _, _, err := c.writer().Repositories.EditActionsPermissions(ctx, repo.Owner, repo.Name, github.ActionsPermissionsRepository{
+		Enabled: github.Bool(false),
+	}); err != nil {
+		return false, fmt.Errorf("failed to disable Actions: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// MaxActivityDays is how far back GitHub's commit activity statistics reach.
+const MaxActivityDays = 364
+
+// UpstreamCommitActivity returns the number of commits made to the default
+// branch of a fork's upstream in the given number of days before now, from
+// GitHub's weekly commit activity statistics. GitHub computes these statistics
+// in the background, so the first request for a repository may be polled for
+// up to the configured compare wait.
+func (c *Client) UpstreamCommitActivity(ctx context.Context, repo Repository, days int) (int, error) {
+	if days < 1 || days > MaxActivityDays {
+		return 0, fmt.Errorf("activity window must be between 1 and %d days, got %d", MaxActivityDays, days)
+	}
+
+	var weeks []*github.WeeklyCommitActivity
+	what := fmt.Sprintf("the commit activity of %s/%s", repo.ParentOwner, repo.ParentName)
+	err := c.pollAccepted(ctx, what, func() (err error) {
+		weeks, _, err = c.reader().Repositories.ListCommitActivity(ctx, repo.ParentOwner, repo.ParentName)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s: %w", what, err)
+	}
+
+	// Each week starts on a Sunday and breaks its total down by day
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	commits := 0
+	for _, week := range weeks {
+		for i, count := range week.Days {
+			day := week.GetWeek().Time.AddDate(0, 0, i)
+			if day.After(since) && !day.After(now) {
+				commits += count
+			}
+		}
+	}
+	return commits, nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fleetSizes are the numbers of forks the benchmarks simulate.
+var fleetSizes = []int{1000, 5000}
+
+func TestMain(m *testing.M) {
+	// Keep per-run log lines out of benchmark output
+	viper.Set("LOG_LEVEL", "error")
+	os.Exit(m.Run())
+}
+
+// benchmarkFleet runs the benchmark against a fake GitHub for every fleet
+// size, reporting requests and allocations per fork. Each iteration gets a
+// fresh client, so that nothing is cached from the one before.
+func benchmarkFleet(b *testing.B, run func(b *testing.B, c *Client, api *fakeGitHub)) {
+	for _, forks := range fleetSizes {
+		b.Run(fmt.Sprintf("forks=%d", forks), func(b *testing.B) {
+			api := newFakeGitHub(forks)
+			b.ReportAllocs()
+			var requests int64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				c := api.client(b)
+				before := api.requests.Load()
+				b.StartTimer()
+
+				run(b, c, api)
+				requests += api.requests.Load() - before
+			}
+			b.ReportMetric(float64(requests)/float64(b.N*forks), "requests/fork")
+		})
+	}
+}
+
+// BenchmarkDiscoverForks measures discovery by listing every repository and
+// fetching the details of each fork.
+func BenchmarkDiscoverForks(b *testing.B) {
+	benchmarkFleet(b, func(b *testing.B, c *Client, api *fakeGitHub) {
+		forks, err := c.DiscoverForks(context.Background(), &DiscoveryCursor{})
+		if err != nil || len(forks) != api.forks {
+			b.Fatalf("discovered %d of %d forks: %v", len(forks), api.forks, err)
+		}
+	})
+}
+
+// BenchmarkSearchForks measures discovery with the search API and batched
+// GraphQL queries, which finds at most SearchResultLimit forks.
+func BenchmarkSearchForks(b *testing.B) {
+	benchmarkFleet(b, func(b *testing.B, c *Client, api *fakeGitHub) {
+		forks, err := c.SearchForks(context.Background(), &DiscoveryCursor{})
+		if err != nil || len(forks) != min(api.forks, SearchResultLimit) {
+			b.Fatalf("found %d of %d forks: %v", len(forks), api.forks, err)
+		}
+	})
+}
+
+// BenchmarkPlanFleet measures planning: comparing every discovered fork with
+// its upstream to find those that are behind.
+func BenchmarkPlanFleet(b *testing.B) {
+	benchmarkFleet(b, func(b *testing.B, c *Client, api *fakeGitHub) {
+		b.StopTimer()
+		forks := api.fleet()
+		b.StartTimer()
+		planFleet(b, c, forks)
+	})
+}
+
+// planFleet compares every fork with its upstream.
+func planFleet(tb testing.TB, c *Client, forks []Repository) (behind int) {
+	for _, fork := range forks {
+		comparison, err := c.CompareWithUpstream(context.Background(), fork)
+		if err != nil {
+			tb.Fatalf("comparing %s: %v", fork.FullName, err)
+		}
+		if comparison.BehindBy > 0 {
+			behind++
+		}
+	}
+	return behind
+}
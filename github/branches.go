@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// RetargetDefaultBranch switches the fork's default branch from its current
+// name to newBranch. The new branch is created from the head of the old default
+// branch if it does not exist yet; if deleteOld is set, the old branch is deleted
+// once the default has been switched. It reports whether the new branch was created.
+func (c *Client) RetargetDefaultBranch(ctx context.Context, repo Repository, newBranch string, deleteOld bool) (bool, error) {
+	oldBranch := repo.DefaultBranch
+
+	created := false
+	if _, resp, err := c.writer().Git.GetRef(ctx, repo.Owner, repo.Name, "heads/"+newBranch); err != nil {
+		if !isNotFound(resp) {
+			return false, fmt.Errorf("failed to check for branch %s: %w", newBranch, err)
+		}
+
+		head, _, err := c.writer().Git.GetRef(ctx, repo.Owner, repo.Name, "heads/"+oldBranch)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve head of %s: %w", oldBranch, err)
+		}
+		ref := &github.Reference{
+			Ref:    github.String("refs/heads/" + newBranch),
+			Object: &github.GitObject{SHA: head.GetObject().SHA},
+		}
+		if _, _, err := c.writer().Git.CreateRef(ctx, repo.Owner, repo.Name, ref); err != nil {
+			return false, fmt.Errorf("failed to create branch %s: %w", newBranch, err)
+		}
+		created = true
+	}
+
+	edit := &github.Repository{DefaultBranch: github.String(newBranch)}
+	if _, _, err := c.writer().Repositories.Edit(ctx, repo.Owner, repo.Name, edit); err != nil {
+		return created, fmt.Errorf("failed to set default branch to %s: %w", newBranch, err)
+	}
+	c.metadata.forget(repo.Owner, repo.Name)
+
+	if deleteOld {
+		if _, err := c.writer().Git.DeleteRef(ctx, repo.Owner, repo.Name, "heads/"+oldBranch); err != nil {
+			return created, fmt.Errorf("default branch switched, but failed to delete %s: %w", oldBranch, err)
+		}
+	}
+
+	return created, nil
+}
+
+// MatchingBranches returns the fork's branches whose names match the glob
+// pattern (as in path.Match, so "release/*" matches "release/1.2"), split into
+// those that also exist upstream and those that do not.
+func (c *Client) MatchingBranches(ctx context.Context, repo Repository, pattern string) (shared, forkOnly []string, err error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, nil, fmt.Errorf("invalid branch pattern %q: %w", pattern, err)
+	}
+
+	forkBranches, err := c.listBranches(ctx, repo.Owner, repo.Name, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(forkBranches) == 0 {
+		return nil, nil, nil
+	}
+	upstreamBranches, err := c.listBranches(ctx, repo.ParentOwner, repo.ParentName, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upstream := make(map[string]bool, len(upstreamBranches))
+	for _, branch := range upstreamBranches {
+		upstream[branch] = true
+	}
+	for _, branch := range forkBranches {
+		if upstream[branch] {
+			shared = append(shared, branch)
+		} else {
+			forkOnly = append(forkOnly, branch)
+		}
+	}
+	return shared, forkOnly, nil
+}
+
+// listBranches returns the names of a repository's branches that match the pattern.
+func (c *Client) listBranches(ctx context.Context, owner, name, pattern string) ([]string, error) {
+	var matching []string
+	opts := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		branches, resp, err := c.reader().Repositories.ListBranches(ctx, owner, name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branches of %s/%s: %w", owner, name, err)
+		}
+		for _, branch := range branches {
+			if ok, _ := path.Match(pattern, branch.GetName()); ok {
+				matching = append(matching, branch.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			return matching, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
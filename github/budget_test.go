@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// budgetFleet is the number of forks the performance budget is checked with.
+const budgetFleet = 1000
+
+// budget is the most a stage may cost per fork of a budgetFleet fleet served
+// by the fake GitHub. Requests and allocations are deterministic and always
+// checked; the time limit is generous so that slow CI machines pass, and is
+// skipped with -short. Run the benchmarks for the actual numbers:
+//
+//	go test ./github -run XXX -bench . -benchmem
+//
+// Keep the budgets in README.md in sync when changing them.
+type budget struct {
+	name     string
+	requests float64       // API requests per fork
+	allocs   float64       // Allocations per fork, including the fake's
+	time     time.Duration // Wall time for the whole fleet
+	run      func(t *testing.T, c *Client, api *fakeGitHub)
+}
+
+var budgets = []budget{
+	{
+		name: "list discovery", requests: 1.02, allocs: 175, time: 2 * time.Second,
+		run: func(t *testing.T, c *Client, api *fakeGitHub) {
+			if forks, err := c.DiscoverForks(context.Background(), &DiscoveryCursor{}); err != nil || len(forks) != api.forks {
+				t.Fatalf("discovered %d of %d forks: %v", len(forks), api.forks, err)
+			}
+		},
+	},
+	{
+		name: "search discovery", requests: 0.04, allocs: 75, time: time.Second,
+		run: func(t *testing.T, c *Client, api *fakeGitHub) {
+			if forks, err := c.SearchForks(context.Background(), &DiscoveryCursor{}); err != nil || len(forks) != api.forks {
+				t.Fatalf("found %d of %d forks: %v", len(forks), api.forks, err)
+			}
+		},
+	},
+	{
+		name: "planning", requests: 1, allocs: 125, time: time.Second,
+		run: func(t *testing.T, c *Client, api *fakeGitHub) {
+			planFleet(t, c, api.fleet())
+		},
+	},
+}
+
+// TestPerformanceBudget checks that discovery and planning stay within their
+// budget of API requests, allocations, and time per fork.
+func TestPerformanceBudget(t *testing.T) {
+	api := newFakeGitHub(budgetFleet)
+	for _, b := range budgets {
+		t.Run(b.name, func(t *testing.T) {
+			c := api.client(t)
+			requestsBefore := api.requests.Load()
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+
+			b.run(t, c, api)
+
+			elapsed := time.Since(start)
+			runtime.ReadMemStats(&after)
+			requests := float64(api.requests.Load()-requestsBefore) / budgetFleet
+			allocs := float64(after.Mallocs-before.Mallocs) / budgetFleet
+
+			if requests > b.requests {
+				t.Errorf("%.3f API requests per fork, over the budget of %.3f", requests, b.requests)
+			}
+			if allocs > b.allocs {
+				t.Errorf("%.0f allocations per fork, over the budget of %.0f", allocs, b.allocs)
+			}
+			if !testing.Short() && elapsed > b.time {
+				t.Errorf("took %s for %d forks, over the budget of %s", elapsed, budgetFleet, b.time)
+			}
+			t.Logf("%.3f requests and %.0f allocations per fork, %s in all", requests, allocs, elapsed)
+		})
+	}
+}
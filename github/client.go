@@ -9,41 +9,112 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/TFMV/furca/logger"
 	"github.com/google/go-github/v60/github"
-	"golang.org/x/oauth2"
 )
 
 // Repository represents a GitHub repository with information about its owner,
 // name, and parent repository (for forks).
 type Repository struct {
-	Owner       string // Owner's username
-	Name        string // Repository name
-	FullName    string // Full repository name (owner/name)
-	ParentOwner string // Parent repository owner (for forks)
-	ParentName  string // Parent repository name (for forks)
+	Owner          string    `json:"owner"`                  // Owner's username
+	Name           string    `json:"name"`                   // Repository name
+	FullName       string    `json:"full_name"`              // Full repository name (owner/name)
+	ParentOwner    string    `json:"parent_owner"`           // Parent repository owner (for forks)
+	ParentName     string    `json:"parent_name"`            // Parent repository name (for forks)
+	ParentPushedAt time.Time `json:"parent_pushed_at"`       // When the parent repository was last pushed to
+	Branch         string    `json:"branch,omitempty"`       // Branch to compare and sync (the default branch when empty)
+	UpstreamRef    string    `json:"upstream_ref,omitempty"` // Upstream tag, branch, or SHA to track instead of the matching branch
+
+	DefaultBranch       string `json:"default_branch,omitempty"`        // Fork's default branch
+	ParentDefaultBranch string `json:"parent_default_branch,omitempty"` // Parent's default branch
+
+	// CanPush reports whether the primary token has push access to the fork.
+	// Use Client.WriteAccess to learn whether the fork can be changed.
+	CanPush bool `json:"can_push"`
+
+	Visibility string   `json:"visibility,omitempty"` // public, private, or internal
+	Language   string   `json:"language,omitempty"`   // Primary language detected by GitHub
+	Topics     []string `json:"topics,omitempty"`
+
+	Stars          int       `json:"stars"`                     // Stargazers of the fork
+	Archived       bool      `json:"archived,omitempty"`        // Fork is archived and read-only
+	PushedAt       time.Time `json:"pushed_at"`                 // When the fork was last pushed to
+	ParentLanguage string    `json:"parent_language,omitempty"` // Primary language of the parent
+	ParentStars    int       `json:"parent_stars"`              // Stargazers of the parent
+	ParentArchived bool      `json:"parent_archived,omitempty"` // Parent is archived and read-only
+
+	// Detached is set when the upstream comes from a manual mapping rather
+	// than from a fork relationship known to GitHub
+	Detached bool `json:"detached,omitempty"`
+}
+
+// Comparison describes how a fork's branch relates to its upstream branch.
+type Comparison struct {
+	Branch         string // Fork branch that was compared
+	UpstreamBranch string // Upstream branch it was compared against
+	Renamed        bool   // Upstream renamed Branch to UpstreamBranch
+	Mapped         bool   // Branch was compared with the upstream default branch of a different name
+	BehindBy       int    // Number of upstream commits missing from the fork
+	BehindCapped   bool   // BehindBy is a lower bound because GitHub stopped counting
+	AheadBy        int    // Number of fork commits not present upstream
 }
 
 // Client is a wrapper around the GitHub API client that provides
 // methods for interacting with GitHub repositories, particularly for
 // synchronizing forked repositories with their upstream sources.
 type Client struct {
-	client *github.Client // The underlying GitHub API client
+	client *github.Client // The underlying GitHub API client for the primary token
+	write  *github.Client // Client for changes to forks, if separate from the primary token
 	user   *github.User   // The authenticated user
+	pool   []*tokenClient // Clients for all tokens, used for read-only calls
+	next   atomic.Uint64  // Round-robin offset into pool
+	tokens []*tokenClient // Clients for every token including the write token, for RateLimits
+
+	upstreams   map[string]string // Manual upstream mapping keyed by lowercased owner/name
+	compareWait time.Duration     // How long to poll comparisons GitHub is still computing
+	exactCounts bool              // Count behind-by exactly when GitHub may have capped it
+
+	metadata metadataCache // Repository details fetched during this run
+
+	capabilities Capabilities // API features of the GitHub instance
+}
+
+// NewClient creates a new GitHub client with the provided tokens.
+// It authenticates with GitHub using the first token, which is used for
+// discovery and, unless WithWriteToken is given, for all write operations.
+// When more than one token is given, read-only calls are spread across all of
+// them based on their remaining quota.
+func NewClient(tokens ...string) (*Client, error) {
+	return NewClientWithOptions(tokens)
 }
 
-// NewClient creates a new GitHub client with the provided token.
-// It authenticates with GitHub using the token and returns a Client
-// that can be used to interact with the GitHub API.
-func NewClient(token string) (*Client, error) {
+// NewClientWithOptions creates a new GitHub client like NewClient, applying the
+// given options (such as a custom User-Agent or transport middleware).
+func NewClientWithOptions(tokens []string, opts ...Option) (*Client, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no GitHub token provided")
+	}
+
+	options := &clientOptions{userAgent: DefaultUserAgent, hosts: []string{DefaultAPIHost}, compareWait: DefaultCompareWait}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.baseURL != "" {
+		if _, err := github.NewClient(nil).WithEnterpriseURLs(options.baseURL, options.baseURL); err != nil {
+			return nil, fmt.Errorf("invalid GitHub Enterprise Server URL: %w", err)
+		}
+	}
+
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	var pool []*tokenClient
+	for i, token := range tokens {
+		pool = append(pool, newTokenClient(ctx, token, tokenLabel(i), options))
+	}
+	client := pool[0].client
 
 	// Get authenticated user
 	user, _, err := client.Users.Get(ctx, "")
@@ -51,146 +122,387 @@ func NewClient(token string) (*Client, error) {
 		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
 	}
 
-	return &Client{
-		client: client,
-		user:   user,
-	}, nil
+	c := &Client{
+		client:      client,
+		user:        user,
+		upstreams:   options.upstreams,
+		compareWait: options.compareWait,
+		exactCounts: options.exactCounts,
+		tokens:      pool,
+
+		capabilities: detectCapabilities(ctx, client, options.baseURL != ""),
+	}
+	if len(pool) > 1 {
+		c.pool = pool
+	}
+	if options.writeToken != "" {
+		write := newTokenClient(ctx, options.writeToken, "write", options)
+		c.write = write.client
+		c.tokens = append(c.tokens, write)
+	}
+	return c, nil
 }
 
 // GetForkedRepositories returns a list of repositories that are forks.
 // It fetches all repositories for the authenticated user and filters
 // out those that are not forks or don't have parent information.
 func (c *Client) GetForkedRepositories(ctx context.Context) ([]Repository, error) {
-	log := logger.GetLogger()
-	// First, get all repositories for the authenticated user
+	return c.DiscoverForks(ctx, &DiscoveryCursor{})
+}
+
+// DiscoveryCursor records progress through fork discovery, so that a listing
+// interrupted by a rate limit or network failure can be resumed later.
+type DiscoveryCursor struct {
+	User  string       `json:"user"`  // Login the listing belongs to
+	Page  int          `json:"page"`  // Next listing page to fetch (0 for the first)
+	Forks []Repository `json:"forks"` // Forks discovered on earlier pages
+
+	// Source is "search" for a cursor of SearchForks and empty for DiscoverForks
+	Source string `json:"source,omitempty"`
+}
+
+// User returns the login of the authenticated user.
+func (c *Client) User() string {
+	return c.user.GetLogin()
+}
+
+// DiscoverForks returns the forks of the authenticated user, continuing from
+// the given cursor. The cursor is advanced after each listing page; if listing
+// fails, the error is returned together with the forks found so far, and the
+// cursor can be passed to a later call to continue where this one stopped.
+func (c *Client) DiscoverForks(ctx context.Context, cursor *DiscoveryCursor) ([]Repository, error) {
+	log := logger.FromContext(ctx)
+
+	if cursor.User != c.User() || cursor.Source != "" {
+		*cursor = DiscoveryCursor{User: c.User()}
+	}
+	if cursor.Page > 0 {
+		log.Infof("Resuming fork discovery at page %d with %d forks already found", cursor.Page, len(cursor.Forks))
+	}
+
+	// Get all repositories for the authenticated user, one page at a time
 	opts := &github.RepositoryListByAuthenticatedUserOptions{
-		ListOptions: github.ListOptions{PerPage: 100},
+		ListOptions: github.ListOptions{PerPage: 100, Page: cursor.Page},
 		// Get all repositories regardless of visibility
 		Visibility: "all",
 		// Include all repositories regardless of affiliation
 		Affiliation: "owner,collaborator,organization_member",
 	}
 
-	var allRepos []*github.Repository
+	var totalRepos int
+	var warnedSSO bool
 	for {
 		repos, resp, err := c.client.Repositories.ListByAuthenticatedUser(ctx, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list repositories: %w", err)
+			return cursor.Forks, fmt.Errorf("failed to list repositories: %w", err)
 		}
-		allRepos = append(allRepos, repos...)
+		totalRepos += len(repos)
+		if orgs, partial := partialSSOResults(resp.Header.Get(SSOHeader)); partial && !warnedSSO {
+			warnedSSO = true
+			log.Warnf("Repositories of %d organizations were left out because the token is not authorized for their SAML single sign-on (organization IDs: %s)", len(orgs), strings.Join(orgs, ", "))
+		}
+
+		// Identify which ones are forks. The page only counts as done once
+		// every fork on it has been hydrated, so that resuming repeats it
+		// instead of leaving out the forks after the one that failed.
+		var forks []Repository
+		for _, repo := range repos {
+			fork, ok, err := c.hydrateFork(ctx, repo)
+			if err != nil {
+				return cursor.Forks, err
+			}
+			if ok {
+				forks = append(forks, fork)
+			}
+		}
+		cursor.Forks = append(cursor.Forks, forks...)
+
 		if resp.NextPage == 0 {
 			break
 		}
 		opts.Page = resp.NextPage
+		cursor.Page = resp.NextPage
 	}
 
-	log.Infof("Found %d total repositories", len(allRepos))
+	log.Infof("Found %d total repositories", totalRepos)
+	log.Infof("Identified %d forks with parent information", len(cursor.Forks))
+	return cursor.Forks, nil
+}
 
-	// Now identify which ones are forks
-	var forks []Repository
-	var forkCount int
-	for _, repo := range allRepos {
-		// Check if this is a fork
-		if repo.GetFork() {
-			forkCount++
-			log.Debugf("Processing fork #%d: %s", forkCount, repo.GetFullName())
+// GetFork returns a single fork of the authenticated user by owner and name,
+// with the same details as the forks returned by DiscoverForks.
+func (c *Client) GetFork(ctx context.Context, owner, name string) (Repository, error) {
+	repo, _, err := c.client.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to get repository %s/%s: %w", owner, name, err)
+	}
+	c.metadata.put(owner, name, repo)
+	fork, ok, err := c.hydrateFork(ctx, repo)
+	if err != nil {
+		return Repository{}, err
+	}
+	if !ok {
+		return Repository{}, fmt.Errorf("%s is not a fork with parent information and has no upstream mapped in config", repo.GetFullName())
+	}
+	return fork, nil
+}
 
-			// For each fork, we need to get the full repository details to access parent info
-			fullRepo, _, err := c.client.Repositories.Get(ctx, repo.GetOwner().GetLogin(), repo.GetName())
-			if err != nil {
-				log.Warnf("Error getting details for %s: %v", repo.GetFullName(), err)
-				continue
-			}
+// hydrateFork fetches the full details of a listed repository and returns it
+// as a Repository if it is a fork with parent information. Failing to fetch
+// the details, for example on a rate limit, is an error rather than a reason
+// to skip the fork, so that discovery does not quietly leave it out.
+func (c *Client) hydrateFork(ctx context.Context, repo *github.Repository) (Repository, bool, error) {
+	log := logger.FromContext(ctx)
 
-			// Check if parent information is available
-			parent := fullRepo.GetParent()
-			if parent != nil {
-				forks = append(forks, Repository{
-					Owner:       fullRepo.GetOwner().GetLogin(),
-					Name:        fullRepo.GetName(),
-					FullName:    fullRepo.GetFullName(),
-					ParentOwner: parent.GetOwner().GetLogin(),
-					ParentName:  parent.GetName(),
-				})
-				log.Debugf("Added fork: %s (parent: %s)", fullRepo.GetFullName(), parent.GetFullName())
-			} else {
-				log.Warnf("Warning: Fork %s has no parent information", fullRepo.GetFullName())
-			}
-		}
+	// A manual mapping takes precedence over the fork relationship
+	if upstream, ok := c.upstreams[strings.ToLower(repo.GetFullName())]; ok {
+		fork, ok := c.hydrateDetached(ctx, repo, upstream)
+		return fork, ok, nil
+	}
+
+	// Check if this is a fork
+	if !repo.GetFork() {
+		return Repository{}, false, nil
+	}
+	log.Debugf("Processing fork: %s", repo.GetFullName())
+
+	// For each fork, we need to get the full repository details to access parent info
+	fullRepo, err := c.getRepository(ctx, repo.GetOwner().GetLogin(), repo.GetName())
+	if err != nil {
+		return Repository{}, false, fmt.Errorf("failed to get details of %s: %w", repo.GetFullName(), err)
 	}
 
-	log.Infof("Identified %d forks with parent information", len(forks))
-	return forks, nil
+	// Check if parent information is available
+	parent := fullRepo.GetParent()
+	if parent == nil {
+		log.Warnf("Warning: Fork %s has no parent information", fullRepo.GetFullName())
+		return Repository{}, false, nil
+	}
+
+	log.Debugf("Added fork: %s (parent: %s)", fullRepo.GetFullName(), parent.GetFullName())
+	return Repository{
+		Owner:          fullRepo.GetOwner().GetLogin(),
+		Name:           fullRepo.GetName(),
+		FullName:       fullRepo.GetFullName(),
+		ParentOwner:    parent.GetOwner().GetLogin(),
+		ParentName:     parent.GetName(),
+		ParentPushedAt: parent.GetPushedAt().Time,
+
+		DefaultBranch:       fullRepo.GetDefaultBranch(),
+		ParentDefaultBranch: parent.GetDefaultBranch(),
+
+		Visibility: fullRepo.GetVisibility(),
+		Language:   fullRepo.GetLanguage(),
+		Topics:     fullRepo.Topics,
+
+		Stars:          fullRepo.GetStargazersCount(),
+		Archived:       fullRepo.GetArchived(),
+		PushedAt:       fullRepo.GetPushedAt().Time,
+		ParentLanguage: parent.GetLanguage(),
+		ParentStars:    parent.GetStargazersCount(),
+		ParentArchived: parent.GetArchived(),
+
+		// Permissions come from the listing, which reflects the primary token,
+		// rather than from the pooled details request
+		CanPush: repo.GetPermissions()["push"],
+	}, true, nil
 }
 
 // IsRepositoryBehindUpstream checks if a forked repository is behind its upstream.
 // It compares the fork with its parent repository and returns whether the fork
 // is behind, how many commits it's behind by, and any error encountered.
 func (c *Client) IsRepositoryBehindUpstream(ctx context.Context, repo Repository) (bool, int, error) {
-	comparison, _, err := c.client.Repositories.CompareCommits(
+	comparison, err := c.CompareWithUpstream(ctx, repo)
+	if err != nil {
+		return false, 0, err
+	}
+	return comparison.BehindBy > 0, comparison.BehindBy, nil
+}
+
+// CompareWithUpstream compares a fork with its parent repository and reports how far
+// the fork is behind and ahead. The repository's explicit branch is used if set, then
+// its default branch; if neither is known, main is tried first, followed by master.
+//
+// If the branch no longer exists upstream but the parent's default branch has a
+// different name, the upstream is assumed to have renamed it (for example master to
+// main) and the comparison is retargeted to the parent's default branch.
+//
+// If no branch is set explicitly and the fork's default branch differs from the
+// parent's (for example main and develop), the two default branches are compared
+// with each other. If upstream also lacks a branch named like the fork's default,
+// this is reported as a rename; otherwise as a mapping between the two branches.
+//
+// If the repository is pinned to an upstream ref, it is compared with that ref instead.
+func (c *Client) CompareWithUpstream(ctx context.Context, repo Repository) (*Comparison, error) {
+	if repo.Detached {
+		return c.compareDetached(ctx, repo)
+	}
+	if repo.UpstreamRef != "" {
+		return c.CompareWithUpstreamRef(ctx, repo, repo.UpstreamRef)
+	}
+	if repo.Branch == "" && repo.DefaultBranch != "" && repo.ParentDefaultBranch != "" && repo.DefaultBranch != repo.ParentDefaultBranch {
+		return c.compareDefaultBranches(ctx, repo)
+	}
+
+	var err error
+	for _, branch := range candidateBranches(repo) {
+		var comparison *Comparison
+		var resp *github.Response
+		comparison, resp, err = c.compareBranches(ctx, repo, branch, branch)
+		if err == nil {
+			return comparison, nil
+		}
+
+		renamedTo := repo.ParentDefaultBranch
+		if isNotFound(resp) && renamedTo != "" && renamedTo != branch {
+			logger.FromContext(ctx).Infof("Upstream %s/%s has no branch %s; comparing against its default branch %s",
+				repo.ParentOwner, repo.ParentName, branch, renamedTo)
+			comparison, _, err = c.compareBranches(ctx, repo, branch, renamedTo)
+			if err == nil {
+				comparison.Renamed = true
+				return comparison, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("failed to compare commits: %w", err)
+}
+
+// compareDefaultBranches compares the fork's default branch with the parent's
+// default branch of a different name.
+func (c *Client) compareDefaultBranches(ctx context.Context, repo Repository) (*Comparison, error) {
+	comparison, _, err := c.compareBranches(ctx, repo, repo.DefaultBranch, repo.ParentDefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare commits: %w", err)
+	}
+
+	// Tell a rename upstream apart from deliberately different default branches
+	_, resp, err := c.reader().Repositories.GetBranch(ctx, repo.ParentOwner, repo.ParentName, repo.DefaultBranch, 1)
+	switch {
+	case isNotFound(resp):
+		comparison.Renamed = true
+		logger.FromContext(ctx).Infof("Upstream %s/%s has no branch %s; comparing against its default branch %s",
+			repo.ParentOwner, repo.ParentName, repo.DefaultBranch, repo.ParentDefaultBranch)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get upstream branch %s: %w", repo.DefaultBranch, err)
+	default:
+		comparison.Mapped = true
+		logger.FromContext(ctx).Infof("Comparing %s branch %s with upstream default branch %s",
+			repo.FullName, repo.DefaultBranch, repo.ParentDefaultBranch)
+	}
+	return comparison, nil
+}
+
+// compareBranches compares the fork's branch with the given upstream branch.
+func (c *Client) compareBranches(ctx context.Context, repo Repository, branch, upstreamBranch string) (*Comparison, *github.Response, error) {
+	comparison, resp, err := c.compareCommits(
 		ctx,
 		repo.Owner,
 		repo.Name,
-		fmt.Sprintf("%s:%s", repo.ParentOwner, "main"),
-		"main",
+		fmt.Sprintf("%s:%s", repo.ParentOwner, upstreamBranch),
+		branch,
 		&github.ListOptions{},
 	)
 	if err != nil {
-		// Try with master branch if main fails
-		comparison, _, err = c.client.Repositories.CompareCommits(
-			ctx,
-			repo.Owner,
-			repo.Name,
-			fmt.Sprintf("%s:%s", repo.ParentOwner, "master"),
-			"master",
-			&github.ListOptions{},
-		)
-		if err != nil {
-			return false, 0, fmt.Errorf("failed to compare commits: %w", err)
-		}
+		return nil, resp, err
 	}
 
 	// If AheadBy > 0, the fork has commits that the upstream doesn't
 	// If BehindBy > 0, the fork is behind the upstream
-	behindBy := comparison.GetBehindBy()
-	return behindBy > 0, behindBy, nil
+	result := &Comparison{
+		Branch:         branch,
+		UpstreamBranch: upstreamBranch,
+		BehindBy:       comparison.GetBehindBy(),
+		AheadBy:        comparison.GetAheadBy(),
+	}
+
+	// Count the upstream's commits in the opposite direction if needed
+	upstream := fmt.Sprintf("%s:%s", repo.ParentOwner, upstreamBranch)
+	if err := c.checkBehindCap(ctx, result, repo.Owner, repo.Name, branch, upstream); err != nil {
+		return nil, resp, err
+	}
+	return result, resp, nil
+}
+
+// candidateBranches returns the branches to try for a repository, in order.
+func candidateBranches(repo Repository) []string {
+	if repo.Branch != "" {
+		return []string{repo.Branch}
+	}
+	if repo.DefaultBranch != "" {
+		return []string{repo.DefaultBranch}
+	}
+	return []string{"main", "master"}
+}
+
+// RenameBranch renames a branch of the fork, for example to follow an upstream
+// rename of its default branch. GitHub updates the default branch if it is renamed.
+func (c *Client) RenameBranch(ctx context.Context, repo Repository, from, to string) error {
+	if _, _, err := c.writer().Repositories.RenameBranch(ctx, repo.Owner, repo.Name, from, to); err != nil {
+		return fmt.Errorf("failed to rename branch %s to %s: %w", from, to, err)
+	}
+	// The cached details may name the old branch as the default
+	c.metadata.forget(repo.Owner, repo.Name)
+	return nil
 }
 
 // SyncRepositoryWithUpstream syncs a forked repository with its upstream.
-// It attempts to merge changes from the upstream repository into the fork.
+// It attempts to merge changes from the upstream repository into the fork, and
+// returns ErrDetached for repositories whose upstream is mapped manually.
+// Repositories pinned to an upstream ref are fast-forwarded to exactly that ref.
 func (c *Client) SyncRepositoryWithUpstream(ctx context.Context, repo Repository) error {
-	log := logger.GetLogger()
+	log := logger.FromContext(ctx)
 
-	// Get current commit SHA before sync for audit logging
-	repoInfo, _, err := c.client.Repositories.Get(ctx, repo.Owner, repo.Name)
-	if err != nil {
-		return fmt.Errorf("failed to get repository info: %w", err)
+	if repo.Detached {
+		return ErrDetached
 	}
-	beforeSHA := repoInfo.GetDefaultBranch()
-
-	// Try with main branch first
-	err = c.syncBranch(ctx, repo, "main")
-	if err != nil {
-		// Try with master branch if main fails
-		err = c.syncBranch(ctx, repo, "master")
+	if repo.UpstreamRef != "" {
+		branch := candidateBranches(repo)[0]
+		sha, err := c.FastForwardToUpstreamRef(ctx, repo, branch, repo.UpstreamRef)
 		if err != nil {
 			return fmt.Errorf("failed to sync repository: %w", err)
 		}
+		log.Infof("Fast-forwarded %s branch %s to upstream %s (%s)", repo.FullName, branch, repo.UpstreamRef, sha)
+		return nil
+	}
+
+	// Without merge-upstream, fall back to moving the branch
+	syncBranch := c.syncBranch
+	if !c.capabilities.MergeUpstream {
+		syncBranch = c.fastForwardBranch
+	}
+
+	// Try each candidate branch in turn, resolving its head before the sync
+	// for audit logging. Heads are read from GitHub rather than the metadata
+	// cache, which does not follow the branch.
+	var err error
+	var synced, beforeSHA string
+	for _, branch := range candidateBranches(repo) {
+		if beforeSHA, err = c.HeadSHA(ctx, repo.Owner, repo.Name, branch); err != nil {
+			beforeSHA = "unknown"
+		}
+		if err = syncBranch(ctx, repo, branch); err == nil {
+			synced = branch
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync repository: %w", err)
 	}
 
 	// Get updated commit SHA after sync for audit logging
-	repoInfo, _, err = c.client.Repositories.Get(ctx, repo.Owner, repo.Name)
+	afterSHA, err := c.HeadSHA(ctx, repo.Owner, repo.Name, synced)
 	if err != nil {
 		// Log but don't fail if we can't get the updated SHA
-		log.Warnf("Failed to get updated repository info for %s: %v", repo.FullName, err)
+		log.Warnf("Failed to get updated head of %s: %v", repo.FullName, err)
 		return nil
 	}
-	afterSHA := repoInfo.GetDefaultBranch()
 
 	// Log the sync operation with commit SHAs
-	log.Infof("%s | Synced %s | from commit SHA %s → %s",
+	log.Infof("%s | Synced %s branch %s | from commit SHA %s → %s",
 		time.Now().Format(time.RFC3339),
 		repo.FullName,
+		synced,
 		beforeSHA,
 		afterSHA)
 
@@ -209,12 +521,12 @@ func (c *Client) syncBranch(ctx context.Context, repo Repository, branch string)
 		Branch: branch,
 	}
 
-	resp, err := c.client.NewRequest(http.MethodPost, url, req)
+	resp, err := c.writer().NewRequest(http.MethodPost, url, req)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	_, err = c.client.Do(ctx, resp, nil)
+	_, err = c.writer().Do(ctx, resp, nil)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/google/go-github/v60/github"
+)
+
+// DefaultCompareWait is how long a comparison that GitHub is still computing
+// is polled for when no other limit is configured.
+const DefaultCompareWait = 30 * time.Second
+
+// CompareCommitLimit is the number of commits GitHub lists in a comparison.
+// Behind-by counts at or above it may be capped, so they are only a lower
+// bound unless counted exactly (see WithExactCounts).
+const CompareCommitLimit = 250
+
+// compareCommits compares two commits in the given repository. For large
+// comparisons GitHub may answer 202 Accepted with an empty body while it
+// computes the result in the background; such responses are polled until the
+// result is ready, rather than being read as a comparison with no commits.
+func (c *Client) compareCommits(ctx context.Context, owner, repo, base, head string, opts *github.ListOptions) (*github.CommitsComparison, *github.Response, error) {
+	var comparison *github.CommitsComparison
+	var resp *github.Response
+	err := c.pollAccepted(ctx, fmt.Sprintf("the comparison of %s...%s in %s/%s", base, head, owner, repo), func() (err error) {
+		comparison, resp, err = c.reader().Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+		return err
+	})
+	return comparison, resp, err
+}
+
+// pollAccepted calls fetch until GitHub stops answering 202 Accepted, which it
+// does while computing a result (such as a large comparison or repository
+// statistics) in the background. Calls are repeated with exponential backoff
+// until the configured compare wait is over; what describes the result for
+// logs and errors.
+func (c *Client) pollAccepted(ctx context.Context, what string, fetch func() error) error {
+	deadline := time.Now().Add(c.compareWait)
+	delay := time.Second
+	for {
+		err := fetch()
+
+		var accepted *github.AcceptedError
+		if !errors.As(err, &accepted) {
+			return err
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("GitHub was still computing %s after %s", what, c.compareWait)
+		}
+
+		logger.FromContext(ctx).Debugf("GitHub is still computing %s; retrying in %s", what, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, 8*time.Second)
+	}
+}
+
+// checkBehindCap handles a comparison whose behind-by count reached
+// CompareCommitLimit. With exact counts enabled, the commits head has and base
+// lacks are counted page by page; otherwise the count is marked as capped.
+func (c *Client) checkBehindCap(ctx context.Context, comparison *Comparison, owner, repo, base, head string) error {
+	if comparison.BehindBy < CompareCommitLimit {
+		return nil
+	}
+	if !c.exactCounts {
+		comparison.BehindCapped = true
+		return nil
+	}
+
+	count := 0
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := c.compareCommits(ctx, owner, repo, base, head, opts)
+		if err != nil {
+			return fmt.Errorf("failed to count upstream commits: %w", err)
+		}
+		count += len(page.Commits)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	comparison.BehindBy = max(comparison.BehindBy, count)
+	return nil
+}
@@ -0,0 +1,286 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// complianceFiles are the base names (case-insensitive, ignoring extensions)
+// of files whose upstream changes may have legal or ownership consequences.
+var complianceFiles = []string{"license", "licence", "copying", "notice", "codeowners"}
+
+// IncomingFileLimit is the number of changed files GitHub lists per comparison.
+// A comparison listing this many files may have been truncated.
+const IncomingFileLimit = 300
+
+// FileChange describes a file changed by the upstream commits a fork is missing.
+type FileChange struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"` // added, modified, removed, renamed, ...
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+
+	// Patch is the unified diff of the file, empty when GitHub omits it,
+	// for example for binary files or very large diffs
+	Patch string `json:"-"`
+}
+
+// IncomingFiles returns the files touched by the upstream commits the fork is
+// missing, according to the given comparison. GitHub lists at most
+// IncomingFileLimit changed files per comparison, so changes beyond that are
+// not included.
+func (c *Client) IncomingFiles(ctx context.Context, repo Repository, comparison *Comparison) ([]string, error) {
+	changes, err := c.IncomingChanges(ctx, repo, comparison)
+	if err != nil {
+		return nil, err
+	}
+	return ChangedPaths(changes), nil
+}
+
+// ChangedPaths returns the paths of the changed files.
+func ChangedPaths(changes []FileChange) []string {
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.Path)
+	}
+	return files
+}
+
+// IncomingChanges is like IncomingFiles, but also reports how each file changed.
+func (c *Client) IncomingChanges(ctx context.Context, repo Repository, comparison *Comparison) ([]FileChange, error) {
+	if repo.Detached {
+		return nil, ErrDetached
+	}
+
+	// Compare in the opposite direction to list the upstream's changes
+	incoming, _, err := c.compareCommits(
+		ctx,
+		repo.Owner,
+		repo.Name,
+		comparison.Branch,
+		fmt.Sprintf("%s:%s", repo.ParentOwner, comparison.UpstreamBranch),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream changes: %w", err)
+	}
+
+	changes := make([]FileChange, 0, len(incoming.Files))
+	for _, file := range incoming.Files {
+		changes = append(changes, FileChange{
+			Path:      file.GetFilename(),
+			Status:    file.GetStatus(),
+			Additions: file.GetAdditions(),
+			Deletions: file.GetDeletions(),
+			Patch:     file.GetPatch(),
+		})
+	}
+	return changes, nil
+}
+
+// ComplianceFiles returns the files that govern licensing or code ownership,
+// such as LICENSE, COPYING, NOTICE, or CODEOWNERS, in any directory.
+func ComplianceFiles(files []string) []string {
+	var matched []string
+	for _, name := range files {
+		base := strings.ToLower(path.Base(name))
+		base = strings.TrimSuffix(base, path.Ext(base))
+		for _, prefix := range complianceFiles {
+			if base == prefix || strings.HasPrefix(base, prefix+"-") || strings.HasPrefix(base, prefix+"_") {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// WorkflowFiles returns the GitHub Actions workflow files, which can start
+// running jobs in the fork as soon as they are merged.
+func WorkflowFiles(files []string) []string {
+	var matched []string
+	for _, name := range files {
+		if path.Dir(name) == ".github/workflows" {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+// firstPartyActionOwners are the owners of actions maintained by GitHub itself.
+var firstPartyActionOwners = []string{"actions", "github"}
+
+// usesPattern matches the action or reusable workflow a workflow step or job uses.
+var usesPattern = regexp.MustCompile(`^(?:-\s+)?uses:\s*["']?([^"'\s#]+)`)
+
+// WorkflowRisks reviews the incoming changes to GitHub Actions workflows and
+// describes those that change what automation may do in the fork: edits to
+// permissions blocks, and new uses of actions from outside GitHub's own
+// organizations. Only the lines in each diff are reviewed, so an action that
+// is already used elsewhere in the file may still be reported as new.
+func WorkflowRisks(changes []FileChange) []string {
+	var risks []string
+	for _, change := range changes {
+		if path.Dir(change.Path) != ".github/workflows" || change.Status == "removed" {
+			continue
+		}
+		if change.Patch == "" {
+			if change.Additions > 0 {
+				risks = append(risks, fmt.Sprintf("%s: GitHub did not provide the diff, so permission and action changes could not be reviewed", change.Path))
+			}
+			continue
+		}
+
+		permissions, grants, actions := reviewWorkflowPatch(change.Patch)
+		if permissions {
+			risk := fmt.Sprintf("%s: changes workflow permissions", change.Path)
+			if len(grants) > 0 {
+				risk += fmt.Sprintf(" (grants %s)", strings.Join(grants, ", "))
+			}
+			risks = append(risks, risk)
+		}
+		if len(actions) > 0 {
+			risks = append(risks, fmt.Sprintf("%s: adds third-party actions %s", change.Path, strings.Join(actions, ", ")))
+		}
+	}
+	return risks
+}
+
+// reviewWorkflowPatch scans the diff of a workflow file. It reports whether
+// lines of a permissions block were added or removed, the write permissions
+// granted by added lines, and the third-party actions used by added lines
+// that removed lines did not already use at another version.
+func reviewWorkflowPatch(patch string) (permissions bool, grants, actions []string) {
+	removed := make(map[string]bool)
+	var added []string
+	blockIndent := -1 // Indentation of the permissions key whose block we are in
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			blockIndent = -1
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		marker, text := line[0], line[1:]
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+
+		inBlock := blockIndent >= 0 && indent > blockIndent
+		if !inBlock {
+			blockIndent = -1
+		}
+		if key, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "- "), ":"); key == "permissions" {
+			blockIndent = indent
+			inBlock = true
+		}
+
+		switch marker {
+		case '+':
+			if inBlock {
+				permissions = true
+				if strings.Contains(trimmed, "write") {
+					grants = append(grants, trimmed)
+				}
+			}
+			if match := usesPattern.FindStringSubmatch(trimmed); match != nil && isThirdPartyAction(match[1]) {
+				added = append(added, match[1])
+			}
+		case '-':
+			if inBlock {
+				permissions = true
+			}
+			if match := usesPattern.FindStringSubmatch(trimmed); match != nil {
+				removed[actionName(match[1])] = true
+			}
+		}
+	}
+
+	for _, action := range added {
+		if !removed[actionName(action)] && !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	return permissions, grants, actions
+}
+
+// isThirdPartyAction reports whether a uses: value refers to an action or
+// reusable workflow outside the repository and GitHub's own organizations.
+func isThirdPartyAction(uses string) bool {
+	if strings.HasPrefix(uses, "./") {
+		return false
+	}
+	if strings.HasPrefix(uses, "docker://") {
+		return true
+	}
+	owner, _, _ := strings.Cut(uses, "/")
+	return !slices.Contains(firstPartyActionOwners, strings.ToLower(owner))
+}
+
+// actionName returns a uses: value without its version.
+func actionName(uses string) string {
+	name, _, _ := strings.Cut(uses, "@")
+	return strings.ToLower(name)
+}
+
+// MatchingFiles returns the files that match any of the patterns (see MatchPath).
+func MatchingFiles(files, patterns []string) []string {
+	var matched []string
+	for _, name := range files {
+		for _, pattern := range patterns {
+			if MatchPath(pattern, name) {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// MatchPath reports whether a slash-separated file path matches a glob pattern.
+// Each path segment is matched as in path.Match, and a "**" segment matches any
+// number of segments, so "src/**" matches every file under src and "**/*.md"
+// matches Markdown files in any directory.
+func MatchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// ValidatePathPattern reports whether a file path glob pattern, as in
+// MatchPath, is well-formed. MatchPath never matches a malformed pattern.
+func ValidatePathPattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchSegments matches path segments against pattern segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,40 @@
+package github
+
+import (
+	"context"
+	"testing"
+)
+
+// TestDiscoverForksResume checks that failing to get the details of a fork
+// stops discovery where it can be resumed, instead of leaving the fork out.
+func TestDiscoverForksResume(t *testing.T) {
+	api := newFakeGitHub(250)
+	api.unavailable = map[string]bool{"fork-00150": true}
+
+	cursor := &DiscoveryCursor{}
+	forks, err := api.client(t).DiscoverForks(context.Background(), cursor)
+	if err == nil {
+		t.Fatalf("discovered %d forks despite an unavailable fork", len(forks))
+	}
+	// fork-00150 is on the second page of 100 repositories, after the 80
+	// forks on the first
+	if cursor.Page != 2 || len(cursor.Forks) != 80 || len(forks) != 80 {
+		t.Fatalf("stopped at page %d with %d forks (%d returned), want page 2 with 80", cursor.Page, len(cursor.Forks), len(forks))
+	}
+
+	delete(api.unavailable, "fork-00150")
+	forks, err = api.client(t).DiscoverForks(context.Background(), cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, fork := range forks {
+		if seen[fork.Name] {
+			t.Errorf("%s discovered twice", fork.Name)
+		}
+		seen[fork.Name] = true
+	}
+	if len(seen) != api.forks {
+		t.Errorf("discovered %d of %d forks after resuming", len(seen), api.forks)
+	}
+}
@@ -0,0 +1,110 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/google/go-github/v60/github"
+)
+
+// MergeUpstreamVersion is the first GitHub Enterprise Server release with the
+// merge-upstream API used to sync forks.
+const MergeUpstreamVersion = "3.3"
+
+// Capabilities describes the API features of the GitHub instance the client
+// talks to, as detected when the client is created.
+type Capabilities struct {
+	Enterprise    bool   // GitHub Enterprise Server rather than github.com
+	Version       string // Installed GitHub Enterprise Server version, if reported
+	MergeUpstream bool   // The merge-upstream API is available to sync forks
+}
+
+// WithEnterpriseURL points the client at the API of a GitHub Enterprise Server,
+// such as https://ghe.example.com/api/v3/, and allows requests to its host.
+func WithEnterpriseURL(apiURL string) Option {
+	return func(o *clientOptions) {
+		o.baseURL = apiURL
+		if u, err := url.Parse(apiURL); err == nil && u.Hostname() != "" {
+			o.hosts = append(o.hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+}
+
+// Capabilities returns the API features detected for the GitHub instance.
+func (c *Client) Capabilities() Capabilities {
+	return c.capabilities
+}
+
+// detectCapabilities finds out which API features a GitHub Enterprise Server
+// offers from the version it reports. github.com offers them all. If the
+// version cannot be determined, the features are assumed to be available.
+func detectCapabilities(ctx context.Context, client *github.Client, enterprise bool) Capabilities {
+	caps := Capabilities{Enterprise: enterprise, MergeUpstream: true}
+	if !enterprise {
+		return caps
+	}
+
+	req, err := client.NewRequest(http.MethodGet, "meta", nil)
+	if err != nil {
+		return caps
+	}
+	var meta struct {
+		InstalledVersion string `json:"installed_version"`
+	}
+	if _, err := client.Do(ctx, req, &meta); err != nil {
+		logger.FromContext(ctx).Warnf("Failed to detect the GitHub Enterprise Server version; assuming merge-upstream is available: %v", err)
+		return caps
+	}
+	caps.Version = meta.InstalledVersion
+	if caps.Version != "" {
+		caps.MergeUpstream = versionAtLeast(caps.Version, MergeUpstreamVersion)
+	}
+	return caps
+}
+
+// versionAtLeast reports whether a dotted version such as 3.2.14 is at least
+// the major.minor version min.
+func versionAtLeast(version, min string) bool {
+	parse := func(v string) (int, int) {
+		major, rest, _ := strings.Cut(v, ".")
+		minor, _, _ := strings.Cut(rest, ".")
+		a, _ := strconv.Atoi(major)
+		b, _ := strconv.Atoi(minor)
+		return a, b
+	}
+	major, minor := parse(version)
+	wantMajor, wantMinor := parse(min)
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+// fastForwardBranch syncs a fork branch without the merge-upstream API by
+// moving it to the head of the upstream branch of the same name. The update is
+// never forced, so it fails if the fork has commits of its own.
+func (c *Client) fastForwardBranch(ctx context.Context, repo Repository, branch string) error {
+	sha, err := c.HeadSHA(ctx, repo.ParentOwner, repo.ParentName, branch)
+	if err != nil {
+		return err
+	}
+	update := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+	if _, _, err := c.writer().Git.UpdateRef(ctx, repo.Owner, repo.Name, update, false); err != nil {
+		return fmt.Errorf("failed to fast-forward %s to upstream %s; without merge-upstream, forks with commits of their own cannot be synced: %w", branch, shortSHA(sha), err)
+	}
+	logger.FromContext(ctx).Infof("Synced %s branch %s by fast-forwarding it to upstream %s, since GitHub Enterprise Server %s lacks merge-upstream", repo.FullName, branch, shortSHA(sha), c.capabilities.Version)
+	return nil
+}
+
+// shortSHA abbreviates a commit SHA for messages.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
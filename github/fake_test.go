@@ -0,0 +1,241 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// fakeAPIURL is the GitHub Enterprise Server API URL clients of a fakeGitHub
+// are pointed at. Requests never leave the process.
+const fakeAPIURL = "https://ghe.test/api/v3/"
+
+// fakeGitHub is an in-memory GitHub API holding one user's fleet of forks,
+// for benchmarks that must measure Furca rather than the network. Every fifth
+// repository the user owns is not a fork, so that discovery has some to skip.
+// Repository details, comparisons, and GraphQL nodes are encoded once up
+// front, so that the fake adds little to the allocations measured.
+type fakeGitHub struct {
+	forks    int
+	repos    []*github.Repository       // Everything the user owns, in listing order
+	details  map[string][]byte          // Encoded repository details by name
+	nodes    map[string]json.RawMessage // Encoded GraphQL fork nodes by name
+	compares map[string][]byte          // Encoded comparisons by fork name
+
+	requests atomic.Int64 // Requests served
+
+	// unavailable names the repositories whose details fail with 502 Bad
+	// Gateway, as in an outage
+	unavailable map[string]bool
+}
+
+// newFakeGitHub returns a fake GitHub with a fleet of the given number of
+// forks, named fork-00000 on, of upstreams spread over 50 organizations.
+func newFakeGitHub(forks int) *fakeGitHub {
+	f := &fakeGitHub{
+		forks:    forks,
+		details:  make(map[string][]byte),
+		nodes:    make(map[string]json.RawMessage),
+		compares: make(map[string][]byte),
+	}
+	pushed := github.Timestamp{Time: time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC)}
+	owner := &github.User{Login: github.String("octocat")}
+	for i := 0; i < forks; i++ {
+		if i%4 == 3 {
+			name := fmt.Sprintf("repo-%05d", i)
+			f.repos = append(f.repos, &github.Repository{
+				Name: github.String(name), FullName: github.String("octocat/" + name), Owner: owner,
+				Fork: github.Bool(false), DefaultBranch: github.String("main"),
+			})
+		}
+
+		name := fmt.Sprintf("fork-%05d", i)
+		parent := &github.Repository{
+			Name: github.String(name), FullName: github.String(fmt.Sprintf("upstream-%02d/%s", i%50, name)),
+			Owner: &github.User{Login: github.String(fmt.Sprintf("upstream-%02d", i%50))}, DefaultBranch: github.String("main"),
+			Language: github.String("Go"), StargazersCount: github.Int(i), PushedAt: &pushed,
+		}
+		repo := &github.Repository{
+			Name: github.String(name), FullName: github.String("octocat/" + name), Owner: owner,
+			Fork: github.Bool(true), DefaultBranch: github.String("main"), Visibility: github.String("public"),
+			Language: github.String("Go"), Topics: []string{"furca-managed"}, PushedAt: &pushed,
+			Permissions: map[string]bool{"admin": true, "push": true, "pull": true},
+		}
+		f.repos = append(f.repos, repo)
+
+		full := *repo
+		full.Parent = parent
+		f.details[name] = mustJSON(&full)
+		f.nodes[name] = mustJSON(map[string]any{
+			"viewerPermission": "ADMIN",
+			"parent": map[string]any{
+				"name": name, "owner": map[string]any{"login": parent.GetOwner().GetLogin()}, "pushedAt": pushed.Time,
+				"defaultBranchRef": map[string]any{"name": "main"}, "primaryLanguage": map[string]any{"name": "Go"},
+				"stargazerCount": i, "isArchived": false,
+			},
+		})
+		f.compares[name] = mustJSON(&github.CommitsComparison{
+			Status: github.String("behind"), BehindBy: github.Int(i % 7), AheadBy: github.Int(0),
+		})
+	}
+	return f
+}
+
+// fleet returns the forks of the fake as discovery finds them.
+func (f *fakeGitHub) fleet() []Repository {
+	var forks []Repository
+	for _, repo := range f.repos {
+		if !repo.GetFork() {
+			continue
+		}
+		forks = append(forks, Repository{
+			Owner: "octocat", Name: repo.GetName(), FullName: repo.GetFullName(),
+			ParentOwner: fmt.Sprintf("upstream-%02d", len(forks)%50), ParentName: repo.GetName(),
+			DefaultBranch: "main", ParentDefaultBranch: "main", CanPush: true,
+		})
+	}
+	return forks
+}
+
+// mustJSON encodes v, which the fake builds itself and can always encode.
+func mustJSON(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// client returns a client of the fake, as NewClientWithOptions would for
+// GitHub Enterprise Server with the given further options.
+func (f *fakeGitHub) client(tb testing.TB, opts ...Option) *Client {
+	tb.Helper()
+	serve := func(http.RoundTripper) http.RoundTripper { return f }
+	opts = append([]Option{WithEnterpriseURL(fakeAPIURL), WithMiddleware(serve)}, opts...)
+	c, err := NewClientWithOptions([]string{"token"}, opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+// RoundTrip serves the request in process.
+func (f *fakeGitHub) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests.Add(1)
+	rec := httptest.NewRecorder()
+	f.serve(rec, req)
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// serve answers the endpoints discovery and comparisons use.
+func (f *fakeGitHub) serve(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.TrimPrefix(req.URL.Path, "/api/v3/")
+	switch {
+	case path == "user":
+		io.WriteString(w, `{"login":"octocat"}`)
+	case path == "meta":
+		io.WriteString(w, `{"installed_version":"3.14.0"}`)
+	case path == "user/repos":
+		f.page(w, req, f.repos, false)
+	case path == "search/repositories":
+		f.search(w, req)
+	case req.URL.Path == "/api/graphql":
+		f.graphQL(w, req)
+	case strings.HasPrefix(path, "repos/octocat/"):
+		name, rest, _ := strings.Cut(strings.TrimPrefix(path, "repos/octocat/"), "/")
+		body, ok := f.details[name]
+		if f.unavailable[name] {
+			http.Error(w, `{"message":"Server Error"}`, http.StatusBadGateway)
+			return
+		}
+		if rest != "" {
+			body, ok = f.compares[name], ok && strings.HasPrefix(rest, "compare/")
+		}
+		if !ok {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	default:
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}
+}
+
+// page writes the requested page of repositories, as a listing or as search
+// results, linking to the next page.
+func (f *fakeGitHub) page(w http.ResponseWriter, req *http.Request, all []*github.Repository, search bool) {
+	query := req.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	page = max(page, 1)
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+
+	start := min((page-1)*perPage, len(all))
+	end := min(start+perPage, len(all))
+	if end < len(all) {
+		next := *req.URL
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+
+	if search {
+		json.NewEncoder(w).Encode(&github.RepositoriesSearchResult{
+			Total: github.Int(f.forks), IncompleteResults: github.Bool(false), Repositories: all[start:end],
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(all[start:end])
+}
+
+// search answers fork:only searches with the forks of the fleet, of which
+// GitHub returns at most SearchResultLimit.
+func (f *fakeGitHub) search(w http.ResponseWriter, req *http.Request) {
+	var forks []*github.Repository
+	for _, repo := range f.repos {
+		if repo.GetFork() && len(forks) < SearchResultLimit {
+			forks = append(forks, repo)
+		}
+	}
+	f.page(w, req, forks, true)
+}
+
+// graphQL answers the batched fork queries of queryForkParents.
+func (f *fakeGitHub) graphQL(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, `{"message":"Problems parsing JSON"}`, http.StatusBadRequest)
+		return
+	}
+	data := make(map[string]json.RawMessage)
+	for key, name := range body.Variables {
+		if !strings.HasPrefix(key, "n") {
+			continue
+		}
+		node, ok := f.nodes[name]
+		if !ok {
+			node = json.RawMessage("null")
+		}
+		data["r"+key[1:]] = node
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
@@ -0,0 +1,28 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// DefaultAPIHost is the only host the client contacts unless others are allowed
+// with WithAllowedHosts.
+const DefaultAPIHost = "api.github.com"
+
+// hostGuard is an http.RoundTripper that refuses requests to any host that is
+// not explicitly allowed. It sits directly above the network, beneath
+// authentication and any middleware, so no request can bypass it.
+type hostGuard struct {
+	base  http.RoundTripper
+	hosts []string
+}
+
+// RoundTrip sends the request if its host is allowed and fails otherwise.
+func (g *hostGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !slices.Contains(g.hosts, strings.ToLower(req.URL.Hostname())) {
+		return nil, fmt.Errorf("refusing request to %s: only %s may be contacted", req.URL.Host, strings.Join(g.hosts, ", "))
+	}
+	return g.base.RoundTrip(req)
+}
@@ -0,0 +1,84 @@
+package github
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+// matchSeeds are patterns and names to start fuzzing from, including
+// malformed patterns such as unclosed classes and trailing escapes.
+var matchSeeds = []struct{ pattern, name string }{
+	{"archive-*", "archive-2019"},
+	{"octocat/*", "widgets"},
+	{"*", "anything"},
+	{"src/**", "src/a/b.go"},
+	{"**/*.md", "docs/README.md"},
+	{"[a-c]?", "b1"},
+	{"[^x]", "y"},
+	{"[a-", "a"},
+	{"[]", "x"},
+	{"[", ""},
+	{"a\\", "a"},
+	{"x/[/y", "x/a/y"},
+	{"**/[", "a/b"},
+}
+
+// FuzzMatchesAny checks that malformed repository patterns are rejected by
+// ValidatePattern and never match, and that patterns ValidatePattern accepts
+// can be matched against any name without error.
+func FuzzMatchesAny(f *testing.F) {
+	for _, seed := range matchSeeds {
+		f.Add(seed.pattern, seed.name)
+	}
+	f.Fuzz(func(t *testing.T, pattern, name string) {
+		repo := Repository{Owner: "octocat", Name: name, FullName: "octocat/" + name}
+		matched := MatchesAny([]string{pattern}, repo)
+
+		if err := ValidatePattern(pattern); err != nil {
+			if matched {
+				t.Fatalf("malformed pattern %q (%v) matched %q", pattern, err, repo.FullName)
+			}
+			return
+		}
+		for _, candidate := range []string{repo.Name, repo.FullName} {
+			if _, err := path.Match(pattern, candidate); err != nil {
+				t.Fatalf("ValidatePattern accepted %q, but matching it against %q fails: %v", pattern, candidate, err)
+			}
+		}
+		if !strings.ContainsAny(pattern, `*?[\`) && matched != (pattern == repo.Name || pattern == repo.FullName) {
+			t.Fatalf("literal pattern %q matched %q: %v", pattern, repo.FullName, matched)
+		}
+	})
+}
+
+// FuzzMatchPath checks the same for file path patterns, and that "**"
+// matches every path.
+func FuzzMatchPath(f *testing.F) {
+	for _, seed := range matchSeeds {
+		f.Add(seed.pattern, seed.name)
+	}
+	f.Fuzz(func(t *testing.T, pattern, name string) {
+		matched := MatchPath(pattern, name)
+
+		if err := ValidatePathPattern(pattern); err != nil {
+			if matched {
+				t.Fatalf("malformed pattern %q (%v) matched %q", pattern, err, name)
+			}
+			return
+		}
+		for _, segment := range strings.Split(pattern, "/") {
+			for _, part := range strings.Split(name, "/") {
+				if _, err := path.Match(segment, part); err != nil {
+					t.Fatalf("ValidatePathPattern accepted %q, but matching segment %q against %q fails: %v", pattern, segment, part, err)
+				}
+			}
+		}
+		if !strings.ContainsAny(pattern, `*?[\`) && matched != (pattern == name) {
+			t.Fatalf("literal pattern %q matched %q: %v", pattern, name, matched)
+		}
+		if !MatchPath("**", name) {
+			t.Fatalf(`"**" did not match %q`, name)
+		}
+	})
+}
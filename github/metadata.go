@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// metadataCache holds repository details fetched during the lifetime of a
+// Client, so that each repository is fetched once per run however many times
+// discovery, comparison, and syncing need it.
+type metadataCache struct {
+	mu    sync.Mutex
+	repos map[string]*github.Repository // Keyed by lowercased owner/name
+}
+
+func metadataKey(owner, name string) string {
+	return strings.ToLower(owner + "/" + name)
+}
+
+// get returns the cached details of a repository, if any.
+func (m *metadataCache) get(owner, name string) (*github.Repository, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	repo, ok := m.repos[metadataKey(owner, name)]
+	return repo, ok
+}
+
+// put records the details of a repository.
+func (m *metadataCache) put(owner, name string, repo *github.Repository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.repos == nil {
+		m.repos = make(map[string]*github.Repository)
+	}
+	m.repos[metadataKey(owner, name)] = repo
+}
+
+// forget drops the cached details of a repository, after a change to it that
+// they would no longer reflect, such as a new default branch.
+func (m *metadataCache) forget(owner, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.repos, metadataKey(owner, name))
+}
+
+// getRepository returns the details of a repository, fetching them with a
+// pooled token the first time they are needed in this run. Callers must not
+// rely on the permissions in the result, which depend on the token used.
+func (c *Client) getRepository(ctx context.Context, owner, name string) (*github.Repository, error) {
+	if repo, ok := c.metadata.get(owner, name); ok {
+		return repo, nil
+	}
+	repo, _, err := c.reader().Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+	c.metadata.put(owner, name, repo)
+	return repo, nil
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent sent when none is configured.
+const DefaultUserAgent = "furca"
+
+// Middleware wraps the HTTP transport used for GitHub API calls, allowing
+// library users to observe or modify every request and response (for example,
+// to route through an audit proxy or record custom metrics).
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Option configures a Client created by NewClientWithOptions.
+type Option func(*clientOptions)
+
+// clientOptions holds the settings applied by Options.
+type clientOptions struct {
+	userAgent   string
+	middlewares []Middleware
+	upstreams   map[string]string
+	hosts       []string
+	compareWait time.Duration
+	exactCounts bool
+	writeToken  string
+	baseURL     string // GitHub Enterprise Server API URL, empty for github.com
+}
+
+// WithUserAgent sets the User-Agent header sent with every API request.
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithMiddleware registers transport middleware. Middlewares are applied in the
+// order given, so the first one sees each request first.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *clientOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithUpstreams maps repositories (owner/name) to the upstream repository
+// (owner/name) they track. Mapped repositories are discovered even if GitHub no
+// longer lists them as forks, and the mapping takes precedence over the parent
+// reported by the API.
+func WithUpstreams(upstreams map[string]string) Option {
+	return func(o *clientOptions) {
+		if o.upstreams == nil {
+			o.upstreams = make(map[string]string)
+		}
+		for repo, upstream := range upstreams {
+			o.upstreams[strings.ToLower(repo)] = upstream
+		}
+	}
+}
+
+// WithAllowedHosts replaces the hosts the client may contact, which by default
+// is only DefaultAPIHost. Requests to any other host fail before they reach
+// the network, whatever middleware is registered.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(o *clientOptions) {
+		o.hosts = nil
+		for _, host := range hosts {
+			o.hosts = append(o.hosts, strings.ToLower(host))
+		}
+	}
+}
+
+// WithCompareWait sets how long to keep polling a comparison or statistics that
+// GitHub is still computing (a 202 Accepted response) before giving up. The default is
+// DefaultCompareWait; 0 gives up immediately.
+func WithCompareWait(wait time.Duration) Option {
+	return func(o *clientOptions) {
+		o.compareWait = wait
+	}
+}
+
+// WithExactCounts makes comparisons count the commits a fork is missing page by
+// page when GitHub's count reaches CompareCommitLimit and may be capped. This
+// costs extra API calls for very stale forks; without it, such counts are
+// reported with Comparison.BehindCapped set.
+func WithExactCounts(exact bool) Option {
+	return func(o *clientOptions) {
+		o.exactCounts = exact
+	}
+}
+
+// wrapTransport applies the configured middlewares around the transport.
+func (o *clientOptions) wrapTransport(transport http.RoundTripper) http.RoundTripper {
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		transport = o.middlewares[i](transport)
+	}
+	return transport
+}
+
+// WithWriteToken sets a separate token for the calls that change forks, such as
+// merging upstream changes, moving or renaming branches, and setting statuses.
+// The tokens passed to NewClientWithOptions are then only used to read, so they
+// can be limited to read access.
+func WithWriteToken(token string) Option {
+	return func(o *clientOptions) {
+		o.writeToken = token
+	}
+}
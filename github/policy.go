@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyPath is the location of the policy file within an organization's policy repository.
+const PolicyPath = "policy.yaml"
+
+// Policy is an organization-wide Furca policy, fetched from a central repository
+// so that every runner in the organization treats the organization's forks the same way.
+type Policy struct {
+	Org      string   `yaml:"-"`        // Organization the policy applies to
+	Include  []string `yaml:"include"`  // Glob patterns of forks to manage (all when empty)
+	Exclude  []string `yaml:"exclude"`  // Glob patterns of forks to leave alone
+	Strategy string   `yaml:"strategy"` // Default sync strategy for forks without their own
+	SLA      SLA      `yaml:"sla"`      // Freshness thresholds
+}
+
+// SLA defines the freshness thresholds that forks are expected to meet.
+type SLA struct {
+	MaxBehind int `yaml:"max_behind"` // Maximum number of commits a fork may lag behind upstream
+}
+
+// GetPolicy fetches and parses policy.yaml from the given owner/name policy repository.
+// The policy applies to forks owned by the policy repository's owner.
+func (c *Client) GetPolicy(ctx context.Context, policyRepo string) (*Policy, error) {
+	owner, name, ok := strings.Cut(policyRepo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("invalid policy repository %q, expected owner/name", policyRepo)
+	}
+
+	content, err := c.getFileContent(ctx, Repository{Owner: owner, Name: name}, PolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%s not found in %s", PolicyPath, policyRepo)
+	}
+
+	policy := &Policy{}
+	if err := yaml.Unmarshal([]byte(content), policy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", PolicyPath, err)
+	}
+	policy.Org = owner
+
+	switch policy.Strategy {
+	case "", StrategyMerge, StrategyFastForward:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q in %s", policy.Strategy, PolicyPath)
+	}
+	for _, pattern := range append(policy.Include, policy.Exclude...) {
+		if err := ValidatePattern(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %w", pattern, PolicyPath, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// AppliesTo reports whether the policy governs the given repository.
+// A nil policy governs nothing.
+func (p *Policy) AppliesTo(repo Repository) bool {
+	return p != nil && strings.EqualFold(repo.Owner, p.Org)
+}
+
+// Allows reports whether the policy's include/exclude patterns permit Furca to
+// manage the repository. Patterns are matched against both the repository name
+// and its full owner/name.
+func (p *Policy) Allows(repo Repository) bool {
+	if !p.AppliesTo(repo) {
+		return true
+	}
+	if len(p.Include) > 0 && !MatchesAny(p.Include, repo) {
+		return false
+	}
+	return !MatchesAny(p.Exclude, repo)
+}
+
+// StrategyFor returns the policy's default strategy for the repository, or an
+// empty string if the policy does not govern it or sets no strategy.
+func (p *Policy) StrategyFor(repo Repository) string {
+	if !p.AppliesTo(repo) {
+		return ""
+	}
+	return p.Strategy
+}
+
+// BreachesSLA reports whether a fork that is behindBy commits behind upstream
+// violates the policy's freshness thresholds.
+func (p *Policy) BreachesSLA(repo Repository, behindBy int) bool {
+	return p.AppliesTo(repo) && p.SLA.MaxBehind > 0 && behindBy > p.SLA.MaxBehind
+}
+
+// ValidatePattern reports whether a repository glob pattern is well-formed.
+// MatchesAny never matches a malformed pattern, such as "[a-" with an
+// unclosed class, so patterns from users should be checked first.
+func ValidatePattern(pattern string) error {
+	_, err := path.Match(pattern, "")
+	return err
+}
+
+// MatchesAny reports whether any of the glob patterns match the repository's
+// name or its full owner/name.
+func MatchesAny(patterns []string, repo Repository) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, repo.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, repo.FullName); ok {
+			return true
+		}
+	}
+	return false
+}
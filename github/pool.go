@@ -0,0 +1,172 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"golang.org/x/oauth2"
+)
+
+// tokenClient is a GitHub API client bound to a single token, together with
+// the rate limit state last reported by GitHub for that token.
+type tokenClient struct {
+	client    *github.Client
+	label     string       // Role of the token, such as primary or write, for reporting
+	limit     atomic.Int64 // Requests allowed per window, -1 if unknown
+	remaining atomic.Int64 // Requests left in the current window, -1 if unknown
+	reset     atomic.Int64 // Unix time at which the window resets
+}
+
+// RateLimit is the API quota GitHub last reported for one of the client's
+// tokens.
+type RateLimit struct {
+	Token     string    // Role of the token: primary, read-2 and on for further read tokens, or write
+	Limit     int64     // Requests allowed per window
+	Remaining int64     // Requests left in the current window
+	Reset     time.Time // When the window resets
+}
+
+// newTokenClient creates a client for the token that records rate limit
+// headers from every response and applies the configured options.
+func newTokenClient(ctx context.Context, token, label string, opts *clientOptions) *tokenClient {
+	tc := &tokenClient{label: label}
+	tc.limit.Store(-1)
+	tc.remaining.Store(-1)
+
+	// Route all traffic through the host guard, beneath the token source
+	guarded := &http.Client{Transport: &hostGuard{base: http.DefaultTransport, hosts: opts.hosts}}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, guarded)
+
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	httpClient.Transport = opts.wrapTransport(&rateTransport{base: httpClient.Transport, tc: tc})
+	tc.client = github.NewClient(httpClient)
+	if opts.baseURL != "" {
+		// The URL was checked by NewClientWithOptions
+		tc.client, _ = tc.client.WithEnterpriseURLs(opts.baseURL, opts.baseURL)
+	}
+	tc.client.UserAgent = opts.userAgent
+	return tc
+}
+
+// available returns the number of requests the token can still make, treating
+// unknown or already-reset windows as fully available.
+func (tc *tokenClient) available() int64 {
+	remaining := tc.remaining.Load()
+	if remaining < 0 || time.Now().Unix() >= tc.reset.Load() {
+		return 1<<63 - 1
+	}
+	return remaining
+}
+
+// rateTransport is an http.RoundTripper that tracks the rate limit headers
+// returned by GitHub for a token.
+type rateTransport struct {
+	base http.RoundTripper
+	tc   *tokenClient
+}
+
+// RoundTrip executes the request and records the rate limit state from the response.
+func (t *rateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	// Search and GraphQL have quotas of their own, which must not be
+	// mistaken for the core quota that reads are balanced by
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return resp, nil
+	}
+
+	if limit, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Limit"), 10, 64); err == nil {
+		t.tc.limit.Store(limit)
+	}
+	if remaining, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Remaining"), 10, 64); err == nil {
+		t.tc.remaining.Store(remaining)
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.tc.reset.Store(reset)
+	}
+	return resp, nil
+}
+
+// reader returns the API client with the most remaining quota, used to spread
+// read-only calls across all configured tokens. Ties are broken round-robin.
+func (c *Client) reader() *github.Client {
+	if len(c.pool) == 0 {
+		return c.client
+	}
+
+	start := int(c.next.Add(1)) % len(c.pool)
+	best := c.pool[start]
+	for i := 1; i < len(c.pool); i++ {
+		tc := c.pool[(start+i)%len(c.pool)]
+		if tc.available() > best.available() {
+			best = tc
+		}
+	}
+	return best.client
+}
+
+// RateLimits returns the quota GitHub last reported for each token the client
+// has made requests with.
+func (c *Client) RateLimits() []RateLimit {
+	var limits []RateLimit
+	for _, tc := range c.tokens {
+		remaining := tc.remaining.Load()
+		if remaining < 0 {
+			continue
+		}
+		limits = append(limits, RateLimit{
+			Token:     tc.label,
+			Limit:     tc.limit.Load(),
+			Remaining: remaining,
+			Reset:     time.Unix(tc.reset.Load(), 0),
+		})
+	}
+	return limits
+}
+
+// tokenLabel names the token at index i of the tokens given to the client.
+func tokenLabel(i int) string {
+	if i == 0 {
+		return "primary"
+	}
+	return fmt.Sprintf("read-%d", i+1)
+}
+
+// TokenCount returns the number of tokens the client distributes calls across.
+func (c *Client) TokenCount() int {
+	if len(c.pool) == 0 {
+		return 1
+	}
+	return len(c.pool)
+}
+
+// WriteAccess reports whether the token that changes forks can push to the
+// fork. Without a write token, that is the primary token, whose access
+// discovery recorded. A write token's access is asked of GitHub, since the
+// primary token may well be read-only and see no push access at all.
+func (c *Client) WriteAccess(ctx context.Context, repo Repository) (bool, error) {
+	if c.write == nil {
+		return repo.CanPush, nil
+	}
+	full, _, err := c.write.Repositories.Get(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to check write access to %s: %w", repo.FullName, err)
+	}
+	return full.GetPermissions()["push"], nil
+}
+
+// writer returns the API client for calls that change forks: the write token's
+// client if one is configured, otherwise the primary token's.
+func (c *Client) writer() *github.Client {
+	if c.write != nil {
+		return c.write
+	}
+	return c.client
+}
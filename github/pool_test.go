@@ -0,0 +1,21 @@
+package github
+
+import (
+	"context"
+	"testing"
+)
+
+// TestWriteAccess checks that with a write token, push access is that of the
+// write token, not of the read-only primary token discovery listed with.
+func TestWriteAccess(t *testing.T) {
+	api := newFakeGitHub(1)
+	fork := api.fleet()[0]
+	fork.CanPush = false // As listed for a read-only primary token
+
+	if ok, err := api.client(t).WriteAccess(context.Background(), fork); err != nil || ok {
+		t.Errorf("without a write token: got %v, %v; want the listed access", ok, err)
+	}
+	if ok, err := api.client(t, WithWriteToken("write")).WriteAccess(context.Background(), fork); err != nil || !ok {
+		t.Errorf("with a write token: got %v, %v; want the write token's access", ok, err)
+	}
+}
@@ -0,0 +1,45 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// OpenSyncPullRequest opens a pull request in the fork that merges the upstream
+// branch into the fork's branch, for upstream changes that should be reviewed
+// rather than merged automatically. An open pull request for the same branches
+// is reused. It returns the pull request's URL.
+func (c *Client) OpenSyncPullRequest(ctx context.Context, repo Repository, comparison *Comparison, title, body string) (string, error) {
+	if repo.Detached {
+		return "", ErrDetached
+	}
+	if repo.UpstreamRef != "" {
+		return "", fmt.Errorf("cannot open a pull request for pinned upstream ref %s", repo.UpstreamRef)
+	}
+
+	head := fmt.Sprintf("%s:%s", repo.ParentOwner, comparison.UpstreamBranch)
+	existing, _, err := c.writer().PullRequests.List(ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+		State: "open",
+		Head:  head,
+		Base:  comparison.Branch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if len(existing) > 0 {
+		return existing[0].GetHTMLURL(), nil
+	}
+
+	pr, _, err := c.writer().PullRequests.Create(ctx, repo.Owner, repo.Name, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(head),
+		Base:  github.String(comparison.Branch),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return pr.GetHTMLURL(), nil
+}
@@ -0,0 +1,64 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// CompareWithUpstreamRef compares the fork's branch (its explicit or default branch)
+// with a specific upstream ref such as a release tag, branch, or commit SHA.
+func (c *Client) CompareWithUpstreamRef(ctx context.Context, repo Repository, ref string) (*Comparison, error) {
+	var err error
+	for _, branch := range candidateBranches(repo) {
+		var comparison *Comparison
+		comparison, _, err = c.compareBranches(ctx, repo, branch, ref)
+		if err == nil {
+			return comparison, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to compare with upstream %s: %w", ref, err)
+}
+
+// FastForwardToUpstreamRef moves the fork's branch to exactly the commit the
+// upstream ref points to. The update is never forced, so it fails if the fork
+// has commits that the upstream ref does not contain. It returns the new head SHA.
+func (c *Client) FastForwardToUpstreamRef(ctx context.Context, repo Repository, branch, ref string) (string, error) {
+	sha, _, err := c.reader().Repositories.GetCommitSHA1(ctx, repo.ParentOwner, repo.ParentName, ref, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve upstream %s: %w", ref, err)
+	}
+
+	update := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+	if _, _, err := c.writer().Git.UpdateRef(ctx, repo.Owner, repo.Name, update, false); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", branch, ref, err)
+	}
+	return sha, nil
+}
+
+// HeadSHA returns the SHA of the commit a branch, tag, or other ref of a
+// repository points to.
+func (c *Client) HeadSHA(ctx context.Context, owner, name, ref string) (string, error) {
+	sha, _, err := c.reader().Repositories.GetCommitSHA1(ctx, owner, name, ref, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s of %s/%s: %w", ref, owner, name, err)
+	}
+	return sha, nil
+}
+
+// ResetBranch force-moves a fork branch to the given commit, discarding any
+// commits the branch has on top of it.
+func (c *Client) ResetBranch(ctx context.Context, repo Repository, branch, sha string) error {
+	update := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+	if _, _, err := c.writer().Git.UpdateRef(ctx, repo.Owner, repo.Name, update, true); err != nil {
+		return fmt.Errorf("failed to reset %s to %s: %w", branch, sha, err)
+	}
+	return nil
+}
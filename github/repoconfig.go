@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v60/github"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RepoConfigPath is the location of the per-repository Furca configuration file in a fork.
+	RepoConfigPath = ".github/furca.yml"
+	// IgnoreFilePath is the location of a marker file that opts a fork out of automatic syncing.
+	IgnoreFilePath = ".furcaignore"
+)
+
+// Sync strategies that can be selected per repository.
+const (
+	StrategyMerge       = "merge"   // Merge upstream changes into the fork (default)
+	StrategyFastForward = "ff-only" // Only sync when the fork has no commits of its own
+)
+
+// RepoConfig holds the per-repository settings that fork owners can declare
+// in .github/furca.yml. A missing file yields the zero value, which keeps
+// the default behavior.
+type RepoConfig struct {
+	AutoSync *bool  `yaml:"auto_sync"` // Set to false to opt out of automatic syncing
+	Branch   string `yaml:"branch"`    // Preferred branch to compare and sync
+	Strategy string `yaml:"strategy"`  // Sync strategy (merge or ff-only)
+
+	// UpstreamRef pins the fork to an upstream tag, branch, or SHA instead of
+	// the upstream branch matching Branch
+	UpstreamRef string `yaml:"upstream_ref"`
+
+	// DisableActions turns GitHub Actions off after each sync, overriding
+	// the --disable-actions flag when set
+	DisableActions *bool `yaml:"disable_actions"`
+}
+
+// SyncEnabled reports whether the repository allows Furca to sync it automatically.
+func (rc *RepoConfig) SyncEnabled() bool {
+	return rc.AutoSync == nil || *rc.AutoSync
+}
+
+// GetRepoConfig reads the per-repository configuration of a fork via the contents API.
+// It honors both .github/furca.yml and a .furcaignore marker file; the presence of
+// .furcaignore disables automatic syncing regardless of the YAML settings.
+func (c *Client) GetRepoConfig(ctx context.Context, repo Repository) (*RepoConfig, error) {
+	cfg := &RepoConfig{}
+
+	content, err := c.getFileContent(ctx, repo, RepoConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		if err := yaml.Unmarshal([]byte(content), cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", RepoConfigPath, err)
+		}
+	}
+
+	switch cfg.Strategy {
+	case "", StrategyMerge, StrategyFastForward:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q in %s", cfg.Strategy, RepoConfigPath)
+	}
+
+	_, _, resp, err := c.reader().Repositories.GetContents(ctx, repo.Owner, repo.Name, IgnoreFilePath, nil)
+	if err != nil && !isNotFound(resp) {
+		return nil, fmt.Errorf("failed to check %s: %w", IgnoreFilePath, err)
+	}
+	if err == nil {
+		disabled := false
+		cfg.AutoSync = &disabled
+	}
+
+	return cfg, nil
+}
+
+// getFileContent returns the decoded content of a file in the repository,
+// or an empty string if the file does not exist.
+func (c *Client) getFileContent(ctx context.Context, repo Repository, path string) (string, error) {
+	file, _, resp, err := c.reader().Repositories.GetContents(ctx, repo.Owner, repo.Name, path, nil)
+	if err != nil {
+		if isNotFound(resp) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get %s: %w", path, err)
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s is not a file", path)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// isNotFound reports whether the response is a 404 Not Found.
+func isNotFound(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
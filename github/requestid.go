@@ -0,0 +1,39 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// RequestIDHeader is the response header in which GitHub identifies a request.
+const RequestIDHeader = "X-GitHub-Request-Id"
+
+// RequestID returns the GitHub request ID of the failed API response that caused
+// err, or an empty string if err did not come from an API response. GitHub
+// support can trace a request by this ID.
+func RequestID(err error) string {
+	resp := errorResponse(err)
+	if resp == nil {
+		return ""
+	}
+	return resp.Header.Get(RequestIDHeader)
+}
+
+// errorResponse returns the API response that caused err, or nil if err did not
+// come from an API response.
+func errorResponse(err error) *http.Response {
+	var errResp *github.ErrorResponse
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &errResp):
+		return errResp.Response
+	case errors.As(err, &rateErr):
+		return rateErr.Response
+	case errors.As(err, &abuseErr):
+		return abuseErr.Response
+	}
+	return nil
+}
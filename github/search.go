@@ -0,0 +1,294 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/google/go-github/v60/github"
+)
+
+// SearchResultLimit is the number of results GitHub returns for a search query
+// at most, however many match.
+const SearchResultLimit = 1000
+
+// hydrateBatchSize is the number of forks whose parents are fetched with a
+// single GraphQL query.
+const hydrateBatchSize = 50
+
+// maxSearchWait is the longest SearchForks waits for the search rate limit to
+// reset before giving up and leaving the rest for a resumed discovery.
+const maxSearchWait = 2 * time.Minute
+
+// SearchForks returns the forks of the authenticated user like DiscoverForks,
+// but finds them with the search API (fork:only user:LOGIN) instead of listing
+// every repository, and fetches their parents with batched GraphQL queries
+// instead of one request per fork. This is much faster for accounts with many
+// repositories that are not forks, but only finds forks the user owns, and
+// GitHub returns at most SearchResultLimit of them.
+//
+// Repositories mapped to an upstream with WithUpstreams are fetched directly
+// if the search does not return them.
+func (c *Client) SearchForks(ctx context.Context, cursor *DiscoveryCursor) ([]Repository, error) {
+	log := logger.FromContext(ctx)
+
+	if cursor.User != c.User() || cursor.Source != "search" {
+		*cursor = DiscoveryCursor{User: c.User(), Source: "search"}
+	}
+	if cursor.Page > 0 {
+		log.Infof("Resuming fork search at page %d with %d forks already found", cursor.Page, len(cursor.Forks))
+	}
+
+	query := fmt.Sprintf("fork:only user:%s", c.User())
+	opts := &github.SearchOptions{
+		Sort:        "updated",
+		ListOptions: github.ListOptions{PerPage: 100, Page: cursor.Page},
+	}
+	for {
+		result, resp, err := c.searchRepositories(ctx, query, opts)
+		if err != nil {
+			return cursor.Forks, fmt.Errorf("failed to search repositories: %w", err)
+		}
+		if opts.Page <= 1 {
+			log.Infof("Search found %d forks", result.GetTotal())
+			if result.GetTotal() > SearchResultLimit {
+				log.Warnf("GitHub only returns the first %d of the %d forks found; use the default discovery to find all of them", SearchResultLimit, result.GetTotal())
+			}
+			if result.GetIncompleteResults() {
+				log.Warn("The search timed out on GitHub's side, so some forks may be missing")
+			}
+		}
+
+		forks, err := c.hydrateForks(ctx, result.Repositories)
+		if err != nil {
+			return cursor.Forks, err
+		}
+		cursor.Forks = append(cursor.Forks, forks...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+		cursor.Page = resp.NextPage
+	}
+
+	cursor.Forks = append(cursor.Forks, c.mappedRepositories(ctx, cursor.Forks)...)
+
+	log.Infof("Identified %d forks with parent information", len(cursor.Forks))
+	return cursor.Forks, nil
+}
+
+// searchRepositories runs a repository search with the primary token. The
+// search API allows far fewer requests than the rest of the API, so when its
+// rate limit is hit, the search waits for the limit to reset, unless that
+// takes longer than maxSearchWait.
+func (c *Client) searchRepositories(ctx context.Context, query string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	for {
+		result, resp, err := c.client.Search.Repositories(ctx, query, opts)
+
+		var wait time.Duration
+		var rateErr *github.RateLimitError
+		var abuseErr *github.AbuseRateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			wait = time.Until(rateErr.Rate.Reset.Time) + time.Second
+		case errors.As(err, &abuseErr) && abuseErr.RetryAfter != nil:
+			wait = *abuseErr.RetryAfter
+		default:
+			return result, resp, err
+		}
+		if wait > maxSearchWait {
+			return nil, resp, err
+		}
+
+		logger.FromContext(ctx).Infof("Search rate limit reached; waiting %s before fetching page %d", wait.Round(time.Second), max(opts.Page, 1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, resp, ctx.Err()
+		}
+	}
+}
+
+// mappedRepositories returns the repositories mapped to an upstream in config
+// that are not among the forks found, which the search misses when GitHub does
+// not consider them forks.
+func (c *Client) mappedRepositories(ctx context.Context, found []Repository) []Repository {
+	var mapped []Repository
+	for name, upstream := range c.upstreams {
+		owner, repoName, _ := strings.Cut(name, "/")
+		if !strings.EqualFold(owner, c.User()) || containsRepository(found, name) {
+			continue
+		}
+		repo, err := c.getRepository(ctx, owner, repoName)
+		if err != nil {
+			logger.FromContext(ctx).Warnf("Error getting details for %s: %v", name, err)
+			continue
+		}
+		if fork, ok := c.hydrateDetached(ctx, repo, upstream); ok {
+			mapped = append(mapped, fork)
+		}
+	}
+	return mapped
+}
+
+// containsRepository reports whether repos include the repository with the
+// given full name, ignoring case.
+func containsRepository(repos []Repository, fullName string) bool {
+	for _, repo := range repos {
+		if strings.EqualFold(repo.FullName, fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// hydrateForks turns search results into Repositories, fetching the parents
+// of hydrateBatchSize forks at a time with GraphQL. Forks mapped to an upstream
+// in config are hydrated like in DiscoverForks instead.
+func (c *Client) hydrateForks(ctx context.Context, repos []*github.Repository) ([]Repository, error) {
+	log := logger.FromContext(ctx)
+
+	var forks []Repository
+	var batch []*github.Repository
+	for _, repo := range repos {
+		if upstream, ok := c.upstreams[strings.ToLower(repo.GetFullName())]; ok {
+			if fork, ok := c.hydrateDetached(ctx, repo, upstream); ok {
+				forks = append(forks, fork)
+			}
+			continue
+		}
+		batch = append(batch, repo)
+	}
+
+	for start := 0; start < len(batch); start += hydrateBatchSize {
+		chunk := batch[start:min(start+hydrateBatchSize, len(batch))]
+		nodes, err := c.queryForkParents(ctx, chunk)
+		if err != nil {
+			return forks, err
+		}
+		for i, repo := range chunk {
+			node := nodes[i]
+			if node == nil {
+				log.Warnf("Error getting details for %s: not accessible through GraphQL", repo.GetFullName())
+				continue
+			}
+			if node.Parent == nil {
+				log.Warnf("Warning: Fork %s has no parent information", repo.GetFullName())
+				continue
+			}
+
+			log.Debugf("Added fork: %s (parent: %s/%s)", repo.GetFullName(), node.Parent.Owner.Login, node.Parent.Name)
+			forks = append(forks, Repository{
+				Owner:          repo.GetOwner().GetLogin(),
+				Name:           repo.GetName(),
+				FullName:       repo.GetFullName(),
+				ParentOwner:    node.Parent.Owner.Login,
+				ParentName:     node.Parent.Name,
+				ParentPushedAt: node.Parent.PushedAt,
+
+				DefaultBranch:       repo.GetDefaultBranch(),
+				ParentDefaultBranch: node.Parent.DefaultBranchRef.Name,
+
+				Visibility: repo.GetVisibility(),
+				Language:   repo.GetLanguage(),
+				Topics:     repo.Topics,
+
+				Stars:          repo.GetStargazersCount(),
+				Archived:       repo.GetArchived(),
+				PushedAt:       repo.GetPushedAt().Time,
+				ParentLanguage: node.Parent.PrimaryLanguage.Name,
+				ParentStars:    node.Parent.StargazerCount,
+				ParentArchived: node.Parent.IsArchived,
+
+				// The query runs with the primary token, like the listing
+				CanPush: node.ViewerPermission == "ADMIN" || node.ViewerPermission == "MAINTAIN" || node.ViewerPermission == "WRITE",
+			})
+		}
+	}
+	return forks, nil
+}
+
+// forkNode holds the details of a fork fetched with GraphQL.
+type forkNode struct {
+	ViewerPermission string `json:"viewerPermission"`
+	Parent           *struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+		PushedAt         time.Time `json:"pushedAt"`
+		DefaultBranchRef struct {
+			Name string `json:"name"`
+		} `json:"defaultBranchRef"`
+		PrimaryLanguage struct {
+			Name string `json:"name"`
+		} `json:"primaryLanguage"`
+		StargazerCount int  `json:"stargazerCount"`
+		IsArchived     bool `json:"isArchived"`
+	} `json:"parent"`
+}
+
+// queryForkParents fetches the parents of the repositories with a single
+// GraphQL query, returning their details in the same order. Repositories the
+// query could not resolve are nil.
+func (c *Client) queryForkParents(ctx context.Context, repos []*github.Repository) ([]*forkNode, error) {
+	var params, fields []string
+	variables := make(map[string]any)
+	for i, repo := range repos {
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		fields = append(fields, fmt.Sprintf("r%d: repository(owner: $o%d, name: $n%d) { ...fork }", i, i, i))
+		variables[fmt.Sprintf("o%d", i)] = repo.GetOwner().GetLogin()
+		variables[fmt.Sprintf("n%d", i)] = repo.GetName()
+	}
+	query := fmt.Sprintf(`query(%s) {
+%s
+}
+fragment fork on Repository {
+  viewerPermission
+  parent {
+    name owner { login } pushedAt defaultBranchRef { name }
+    primaryLanguage { name } stargazerCount isArchived
+  }
+}`, strings.Join(params, ", "), strings.Join(fields, "\n"))
+
+	var response struct {
+		Data   map[string]*forkNode `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.graphQL(ctx, query, variables, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch fork parents: %w", err)
+	}
+	// Repositories that cannot be resolved come back as null with a
+	// NOT_FOUND error each; any other error fails the whole batch
+	for _, e := range response.Errors {
+		if e.Type != "NOT_FOUND" {
+			return nil, fmt.Errorf("failed to fetch fork parents: %s", e.Message)
+		}
+	}
+
+	nodes := make([]*forkNode, len(repos))
+	for i := range repos {
+		nodes[i] = response.Data[fmt.Sprintf("r%d", i)]
+	}
+	return nodes, nil
+}
+
+// graphQL runs a GraphQL query with the primary token and decodes the
+// response into v. The GraphQL endpoint is resolved against the REST API URL:
+// /graphql on github.com and /api/graphql on GitHub Enterprise Server.
+func (c *Client) graphQL(ctx context.Context, query string, variables map[string]any, v any) error {
+	req, err := c.client.NewRequest(http.MethodPost, "../graphql", map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Do(ctx, req, v)
+	return err
+}
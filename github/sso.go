@@ -0,0 +1,48 @@
+package github
+
+import (
+	"strings"
+)
+
+// SSOHeader is the response header GitHub sets when a token has not been
+// authorized for an organization that enforces SAML single sign-on.
+const SSOHeader = "X-GitHub-SSO"
+
+// SSORequired reports whether err is a 403 caused by a token that has not been
+// authorized for SAML single sign-on, and returns the URL at which the token
+// can be authorized, if GitHub provided one. Retrying such errors is
+// pointless until the token is authorized.
+func SSORequired(err error) (url string, ok bool) {
+	resp := errorResponse(err)
+	if resp == nil {
+		return "", false
+	}
+
+	// The header has the form "required; url=https://github.com/orgs/..."
+	value := resp.Header.Get(SSOHeader)
+	directive, params, _ := strings.Cut(value, ";")
+	if strings.TrimSpace(directive) != "required" {
+		return "", false
+	}
+	for _, param := range strings.Split(params, ";") {
+		if u, found := strings.CutPrefix(strings.TrimSpace(param), "url="); found {
+			return u, true
+		}
+	}
+	return "", true
+}
+
+// partialSSOResults reports whether a listing omitted the resources of
+// organizations the token is not authorized for, and returns their IDs.
+func partialSSOResults(value string) ([]string, bool) {
+	directive, params, _ := strings.Cut(value, ";")
+	if strings.TrimSpace(directive) != "partial-results" {
+		return nil, false
+	}
+	for _, param := range strings.Split(params, ";") {
+		if orgs, found := strings.CutPrefix(strings.TrimSpace(param), "organizations="); found {
+			return strings.Split(orgs, ","), true
+		}
+	}
+	return nil, true
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// StatusContext is the commit status context under which fork freshness is reported.
+const StatusContext = "furca/sync"
+
+// SetFreshnessStatus sets a commit status on the head of the fork's branch, showing
+// in the repository's UI whether the fork is up to date with upstream or how far
+// behind it is.
+func (c *Client) SetFreshnessStatus(ctx context.Context, repo Repository, branch string, behindBy int) error {
+	ref, _, err := c.writer().Git.GetRef(ctx, repo.Owner, repo.Name, "heads/"+branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve head of %s: %w", branch, err)
+	}
+
+	status := &github.RepoStatus{
+		State:       github.String("success"),
+		Description: github.String("up-to-date"),
+		Context:     github.String(StatusContext),
+	}
+	if behindBy > 0 {
+		status.State = github.String("failure")
+		status.Description = github.String(fmt.Sprintf("behind by %d", behindBy))
+	}
+
+	if _, _, err := c.writer().Repositories.CreateStatus(ctx, repo.Owner, repo.Name, ref.GetObject().GetSHA(), status); err != nil {
+		return fmt.Errorf("failed to set commit status: %w", err)
+	}
+	return nil
+}
+
+// Results of UpstreamCIState.
+const (
+	CIPassing = "success" // Every status and check run passed
+	CIPending = "pending" // Some are still running and none failed
+	CIFailing = "failure" // Some failed
+	CINone    = "none"    // The commit has no statuses or check runs
+)
+
+// failedConclusions are the check run conclusions that count as failures.
+var failedConclusions = []string{"failure", "timed_out", "cancelled", "action_required", "startup_failure"}
+
+// UpstreamCIState summarizes the CI results of the commit an upstream ref
+// points to, from both its commit statuses and its check runs. For failing
+// and pending commits, it also returns the names of the failed or unfinished
+// statuses and check runs.
+func (c *Client) UpstreamCIState(ctx context.Context, repo Repository, ref string) (string, []string, error) {
+	var failed, pending []string
+	total := 0
+
+	combined, _, err := c.reader().Repositories.GetCombinedStatus(ctx, repo.ParentOwner, repo.ParentName, ref, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get commit statuses of upstream %s: %w", ref, err)
+	}
+	for _, status := range combined.Statuses {
+		total++
+		switch status.GetState() {
+		case "failure", "error":
+			failed = append(failed, status.GetContext())
+		case "pending":
+			pending = append(pending, status.GetContext())
+		}
+	}
+
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := c.reader().Checks.ListCheckRunsForRef(ctx, repo.ParentOwner, repo.ParentName, ref, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list check runs of upstream %s: %w", ref, err)
+		}
+		for _, run := range runs.CheckRuns {
+			total++
+			switch {
+			case run.GetStatus() != "completed":
+				pending = append(pending, run.GetName())
+			case slices.Contains(failedConclusions, run.GetConclusion()):
+				failed = append(failed, run.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	switch {
+	case len(failed) > 0:
+		return CIFailing, failed, nil
+	case len(pending) > 0:
+		return CIPending, pending, nil
+	case total == 0:
+		return CINone, nil, nil
+	default:
+		return CIPassing, nil, nil
+	}
+}
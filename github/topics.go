@@ -0,0 +1,24 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// AddTopic adds a topic to the fork, keeping its other topics. It reports
+// whether the topic was added, or false if the fork already had it.
+func (c *Client) AddTopic(ctx context.Context, repo Repository, topic string) (bool, error) {
+	topics, _, err := c.writer().Repositories.ListAllTopics(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to list topics: %w", err)
+	}
+	if slices.Contains(topics, topic) {
+		return false, nil
+	}
+
+	if _, _, err := c.writer().Repositories.ReplaceAllTopics(ctx, repo.Owner, repo.Name, append(topics, topic)); err != nil {
+		return false, fmt.Errorf("failed to add topic %s: %w", topic, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TFMV/furca/logger"
+	"github.com/google/go-github/v60/github"
+)
+
+// ErrDetached is returned when an operation needs a fork relationship that
+// GitHub does not know about, such as merging upstream changes into a
+// repository whose upstream was mapped manually.
+var ErrDetached = errors.New("repository is not a fork on GitHub; its upstream is mapped manually")
+
+// hydrateDetached returns a listed repository as a Repository tracking the
+// manually mapped upstream (owner/name), whether or not GitHub considers it a fork.
+func (c *Client) hydrateDetached(ctx context.Context, repo *github.Repository, upstream string) (Repository, bool) {
+	log := logger.FromContext(ctx)
+
+	owner, name, ok := strings.Cut(upstream, "/")
+	if !ok || owner == "" || name == "" {
+		log.Warnf("Ignoring upstream mapping for %s: %q is not in owner/name form", repo.GetFullName(), upstream)
+		return Repository{}, false
+	}
+
+	parent, err := c.getRepository(ctx, owner, name)
+	if err != nil {
+		log.Warnf("Error getting mapped upstream %s for %s: %v", upstream, repo.GetFullName(), err)
+		return Repository{}, false
+	}
+
+	// GitHub redirects requests for renamed or transferred repositories
+	if !strings.EqualFold(parent.GetFullName(), upstream) {
+		log.Warnf("Mapped upstream %s of %s was renamed or transferred to %s; update upstreams in the config", upstream, repo.GetFullName(), parent.GetFullName())
+	}
+
+	log.Debugf("Added repository: %s (mapped upstream: %s)", repo.GetFullName(), parent.GetFullName())
+	return Repository{
+		Owner:          repo.GetOwner().GetLogin(),
+		Name:           repo.GetName(),
+		FullName:       repo.GetFullName(),
+		ParentOwner:    parent.GetOwner().GetLogin(),
+		ParentName:     parent.GetName(),
+		ParentPushedAt: parent.GetPushedAt().Time,
+
+		DefaultBranch:       repo.GetDefaultBranch(),
+		ParentDefaultBranch: parent.GetDefaultBranch(),
+
+		Visibility: repo.GetVisibility(),
+		Language:   repo.GetLanguage(),
+		Topics:     repo.Topics,
+
+		Stars:          repo.GetStargazersCount(),
+		Archived:       repo.GetArchived(),
+		PushedAt:       repo.GetPushedAt().Time,
+		ParentLanguage: parent.GetLanguage(),
+		ParentStars:    parent.GetStargazersCount(),
+		ParentArchived: parent.GetArchived(),
+
+		CanPush:  repo.GetPermissions()["push"],
+		Detached: true,
+	}, true
+}
+
+// compareDetached compares a repository with a manually mapped upstream. GitHub
+// cannot compare branches across repository networks, so the comparison is made
+// in the upstream repository, from the fork's head commit to the upstream branch.
+// This only works while the fork's head commit also exists upstream, that is,
+// while the fork has no commits of its own.
+func (c *Client) compareDetached(ctx context.Context, repo Repository) (*Comparison, error) {
+	var err error
+	for _, branch := range candidateBranches(repo) {
+		var head *github.Branch
+		head, _, err = c.reader().Repositories.GetBranch(ctx, repo.Owner, repo.Name, branch, 1)
+		if err != nil {
+			continue
+		}
+		sha := head.GetCommit().GetSHA()
+
+		upstreamBranches := []string{branch}
+		if repo.UpstreamRef != "" {
+			upstreamBranches = []string{repo.UpstreamRef}
+		} else if repo.ParentDefaultBranch != "" && repo.ParentDefaultBranch != branch {
+			upstreamBranches = append(upstreamBranches, repo.ParentDefaultBranch)
+		}
+		for _, upstreamBranch := range upstreamBranches {
+			var comparison *github.CommitsComparison
+			var resp *github.Response
+			comparison, resp, err = c.compareCommits(ctx, repo.ParentOwner, repo.ParentName, sha, upstreamBranch, nil)
+			if err == nil {
+				result := &Comparison{
+					Branch:         branch,
+					UpstreamBranch: upstreamBranch,
+					Renamed:        upstreamBranch != branch && repo.UpstreamRef == "",
+					BehindBy:       comparison.GetAheadBy(),
+					AheadBy:        comparison.GetBehindBy(),
+				}
+				if err := c.checkBehindCap(ctx, result, repo.ParentOwner, repo.ParentName, sha, upstreamBranch); err != nil {
+					return nil, err
+				}
+				return result, nil
+			}
+			if isNotFound(resp) && upstreamBranch == upstreamBranches[len(upstreamBranches)-1] {
+				err = fmt.Errorf("head of %s (%s) is not in %s/%s; the repository has commits of its own",
+					branch, sha, repo.ParentOwner, repo.ParentName)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("failed to compare commits: %w", err)
+}
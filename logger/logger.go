@@ -1,14 +1,20 @@
 // Package logger provides structured logging functionality for Furca.
 //
 // It implements a singleton logger using the zap logging library, with support for
-// different log levels, structured output, and colorized console logging.
+// different log levels, structured output, colorized console logging, and an optional
+// rotating log file. Loggers carrying per-repository fields can be threaded through
+// a context with WithFields and FromContext.
 package logger
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -29,29 +35,74 @@ func GetLogger() *zap.SugaredLogger {
 		encoderConfig := zap.NewProductionEncoderConfig()
 		encoderConfig.TimeKey = "timestamp"
 		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 
-		// Create a console encoder
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-
-		// Get log level from environment or config
+		// Get log level and format from environment or config
 		logLevel := getLogLevel()
+		jsonFormat := strings.EqualFold(viper.GetString("LOG_FORMAT"), "json")
+
+		// Create a core that writes to stdout, colorized unless JSON was requested
+		// or color is disabled (NO_COLOR, --no-color, or a non-terminal stdout)
+		consoleConfig := encoderConfig
+		consoleConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		if color.NoColor {
+			consoleConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+		consoleEncoder := zapcore.NewConsoleEncoder(consoleConfig)
+		if jsonFormat {
+			consoleEncoder = zapcore.NewJSONEncoder(encoderConfig)
+		}
+		cores := []zapcore.Core{
+			zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), logLevel),
+		}
 
-		// Create a core that writes to stdout
-		core := zapcore.NewCore(
-			consoleEncoder,
-			zapcore.AddSync(os.Stdout),
-			logLevel,
-		)
+		// Optionally also write to a rotating log file
+		if core, err := newFileCore(encoderConfig, jsonFormat, logLevel); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: file logging disabled: %v\n", err)
+		} else if core != nil {
+			cores = append(cores, core)
+		}
 
 		// Create a logger
-		log := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+		log := zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 		logger = log.Sugar()
 	})
 
 	return logger
 }
 
+// newFileCore returns a core writing to the file named by LOG_FILE, or nil if
+// LOG_FILE is not set. The file is rotated when it exceeds LOG_MAX_SIZE megabytes
+// (default 100) or LOG_MAX_AGE days, keeping LOG_MAX_BACKUPS rotated files (default 5).
+func newFileCore(encoderConfig zapcore.EncoderConfig, jsonFormat bool, level zapcore.Level) (zapcore.Core, error) {
+	path := viper.GetString("LOG_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	maxSize := int64(100)
+	if viper.IsSet("LOG_MAX_SIZE") {
+		maxSize = viper.GetInt64("LOG_MAX_SIZE")
+	}
+	maxBackups := 5
+	if viper.IsSet("LOG_MAX_BACKUPS") {
+		maxBackups = viper.GetInt("LOG_MAX_BACKUPS")
+	}
+	maxAge := time.Duration(viper.GetInt("LOG_MAX_AGE")) * 24 * time.Hour
+
+	file, err := newRotatingFile(path, maxSize*1024*1024, maxAge, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log files are never colorized
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	encoder := zapcore.NewConsoleEncoder(encoderConfig)
+	if jsonFormat {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewCore(encoder, file, level), nil
+}
+
 // getLogLevel returns the appropriate log level based on the LOG_LEVEL environment variable.
 // It defaults to info level if no log level is specified or if the specified level is invalid.
 // Valid log levels are: debug, info, warn, error, dpanic, panic, fatal.
@@ -85,3 +136,22 @@ func getLogLevel() zapcore.Level {
 
 	return level
 }
+
+// ctxKey is the context key under which a field-carrying logger is stored.
+type ctxKey struct{}
+
+// WithFields returns a copy of ctx carrying a logger that adds the given
+// key-value pairs (such as repo, owner, branch, or run_id) to every entry.
+// Fields accumulate across nested calls.
+func WithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	return context.WithValue(ctx, ctxKey{}, FromContext(ctx).With(keysAndValues...))
+}
+
+// FromContext returns the logger carried by ctx, or the singleton logger if
+// ctx carries none.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if log, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger); ok {
+		return log
+	}
+	return GetLogger()
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a zapcore.WriteSyncer that writes to a log file and rotates it
+// once it exceeds a maximum size or age. Rotated files are renamed with a timestamp
+// suffix and the oldest ones are removed beyond the configured number of backups.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // Maximum size in bytes before rotating, 0 for unlimited
+	maxAge     time.Duration // Maximum age before rotating, 0 for unlimited
+	maxBackups int           // Number of rotated files to keep, 0 to keep all
+	file       *os.File
+	size       int64
+	startedAt  time.Time // When the current file was started, for maxAge
+}
+
+// newRotatingFile opens (or creates) the log file at path, appending to it.
+func newRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Write appends p to the log file, rotating first if the write would exceed the limits.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the log file to disk.
+func (rf *rotatingFile) Sync() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Sync()
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation.
+func (rf *rotatingFile) shouldRotate(n int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.maxSize > 0 && rf.size+n > rf.maxSize {
+		return true
+	}
+	return rf.maxAge > 0 && time.Since(rf.startedAt) > rf.maxAge
+}
+
+// startedPath is where the time the current log file was started is kept.
+// Each run reopens the file, and its modification time only says when it was
+// last written to, so runs more frequent than maxAge would otherwise keep it
+// from ever rotating by age. The name is hidden so that pruneBackups does not
+// take it for a rotated file.
+func (rf *rotatingFile) startedPath() string {
+	return filepath.Join(filepath.Dir(rf.path), "."+filepath.Base(rf.path)+".started")
+}
+
+// open opens the log file for appending and records its current size and when
+// it was started.
+func (rf *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	if rf.size > 0 {
+		if data, err := os.ReadFile(rf.startedPath()); err == nil {
+			if startedAt, err := time.Parse(time.RFC3339Nano, string(data)); err == nil {
+				rf.startedAt = startedAt
+				return nil
+			}
+		}
+	}
+
+	// A new file, or one written before start times were kept, starts now.
+	// Without a record of that, the next run starts it again, which only
+	// delays rotation by age, so logging goes on.
+	rf.startedAt = time.Now()
+	if err := os.WriteFile(rf.startedPath(), []byte(rf.startedAt.Format(time.RFC3339Nano)), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record log file start time: %v\n", err)
+	}
+	return nil
+}
+
+// rotate renames the current log file with a timestamp suffix, opens a fresh one,
+// and prunes old backups.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	backup := fmt.Sprintf("%s.%s", rf.path, time.Now().Format("20060102T150405.000"))
+	if err := os.Rename(rf.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.pruneBackups()
+	return nil
+}
+
+// pruneBackups removes the oldest rotated files beyond maxBackups.
+func (rf *rotatingFile) pruneBackups() {
+	if rf.maxBackups <= 0 {
+		return
+	}
+
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil || len(backups) <= rf.maxBackups {
+		return
+	}
+
+	// Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-rf.maxBackups] {
+		os.Remove(backup)
+	}
+}
@@ -6,7 +6,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/TFMV/furca/cmd"
@@ -23,8 +22,5 @@ func main() {
 	// Make version info available to commands
 	cmd.SetVersionInfo(version, commit, date)
 
-	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
-	}
+	os.Exit(cmd.Execute())
 }
@@ -0,0 +1,122 @@
+// Package release verifies downloaded Furca release archives.
+//
+// Releases are published with a checksums.txt file listing the SHA-256 of
+// every archive, and checksums.txt.sig, a cosign signature of that file made
+// with the release key. Verifying an archive against the checksums, and the
+// checksums against the signature, proves that the archive is the one the
+// release pipeline built.
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotListed is returned by VerifyChecksum when the checksums file has no
+// entry for the archive.
+var ErrNotListed = errors.New("archive is not listed in the checksums file")
+
+// VerifyChecksum checks that the SHA-256 of the archive matches its entry in
+// a checksums file in sha256sum format, looked up by the archive's file name.
+func VerifyChecksum(archivePath, checksumsPath string) error {
+	checksums, err := os.ReadFile(checksumsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read checksums: %w", err)
+	}
+	want, err := lookupChecksum(checksums, filepath.Base(archivePath))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", filepath.Base(archivePath), got, want)
+	}
+	return nil
+}
+
+// lookupChecksum returns the hex SHA-256 listed for name in a checksums file.
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sha256sum marks files read in binary mode with a leading asterisk
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrNotListed)
+}
+
+// VerifySignature checks a cosign signature of a file, as made by
+// "cosign sign-blob --key" and checked by "cosign verify-blob --key": the
+// signature file holds a base64 ECDSA signature of the file's SHA-256, and
+// the public key is a PEM file as written by "cosign generate-key-pair".
+func VerifySignature(path, signaturePath, publicKeyPath string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	encoded, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("signature is not base64: %w", err)
+	}
+	key, err := loadPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(key, digest[:], signature) {
+		return fmt.Errorf("signature of %s is not valid for the key in %s", filepath.Base(path), publicKeyPath)
+	}
+	return nil
+}
+
+// loadPublicKey reads an ECDSA public key in PEM form.
+func loadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s does not hold a PEM PUBLIC KEY block", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	public, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s must hold an ECDSA key as made by cosign, got %T", path, key)
+	}
+	return public, nil
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLocked is returned by Lock when another live process holds the lock.
+var ErrLocked = errors.New("another furca process is running against this state directory")
+
+// LockStaleAfter is the age after which a lock held from another host is
+// considered abandoned. Locks held on this host are stale as soon as their
+// process has exited.
+const LockStaleAfter = 12 * time.Hour
+
+// LockInfo describes the process holding a lock.
+type LockInfo struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+// Lock is an exclusive lock on the state directory, held as a file in it.
+type Lock struct {
+	path string
+}
+
+// AcquireLock takes the named lock for the given command. If the lock is held
+// by a process that has exited, or by another host for longer than
+// LockStaleAfter, it is taken over; otherwise AcquireLock fails with an error
+// wrapping ErrLocked that names the holder.
+func AcquireLock(name, command string) (*Lock, error) {
+	path, err := filePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{PID: os.Getpid(), Host: host, Command: command, Started: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lock: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, werr := file.Write(data)
+			if cerr := file.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file %s: %w", path, werr)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+		}
+
+		holder, stale, contents := readLock(path, host)
+		if !stale || attempt > 0 {
+			if holder == nil {
+				return nil, fmt.Errorf("%w (lock file %s)", ErrLocked, path)
+			}
+			return nil, fmt.Errorf("%w: %s (pid %d on %s) since %s (lock file %s)",
+				ErrLocked, holder.Command, holder.PID, holder.Host, holder.Started.Format(time.RFC3339), path)
+		}
+		if err := takeOver(path, host, contents); err != nil {
+			return nil, fmt.Errorf("failed to remove stale lock file %s: %w", path, err)
+		}
+	}
+}
+
+// takeOver removes the stale lock file at path, which held contents. Several
+// processes may find the same lock stale at once, and by the time one of them
+// removes it, another may already have replaced it with a lock of its own. So
+// takeovers are serialized through a guard file next to the lock, and the lock
+// is only removed if, with the guard held, it is still the stale lock that was
+// read. A process that finds the guard taken leaves the takeover to its holder.
+func takeOver(path, host string, contents []byte) error {
+	guard := path + ".takeover"
+	file, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		if info, serr := os.Stat(guard); serr == nil && time.Since(info.ModTime()) > time.Minute {
+			// Left behind by a process that crashed while taking over
+			os.Remove(guard)
+			file, err = os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Another process is taking over the lock
+			return nil
+		}
+		return err
+	}
+	file.Close()
+	defer os.Remove(guard)
+
+	if _, stale, current := readLock(path, host); !stale || !bytes.Equal(current, contents) {
+		// Taken over and released or taken again since it was read
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readLock reads the holder of an existing lock and reports whether the lock
+// is stale, along with the contents of the lock file. A lock file that cannot
+// be parsed is stale once it is a minute old, which leaves its writer ample
+// time to finish.
+func readLock(path, host string) (*LockInfo, bool, []byte) {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Released in the meantime
+		return nil, true, nil
+	}
+
+	var holder LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &holder) != nil {
+		return nil, time.Since(info.ModTime()) > time.Minute, data
+	}
+
+	if holder.Host == host {
+		return &holder, !processAlive(holder.PID), data
+	}
+	return &holder, time.Since(holder.Started) > LockStaleAfter, data
+}
+
+// Release removes the lock. Releasing a lock that is already gone is not an error.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file %s: %w", l.path, err)
+	}
+	return nil
+}
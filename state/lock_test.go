@@ -0,0 +1,61 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TestAcquireLockTakeOver checks that when several processes find the same
+// lock stale at once, exactly one of them takes it over.
+func TestAcquireLockTakeOver(t *testing.T) {
+	viper.Set("STATE_DIR", t.TempDir())
+	t.Cleanup(func() { viper.Set("STATE_DIR", "") })
+	// Let the processes' checks interleave even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	path, err := filePath("run.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for round := 0; round < 500; round++ {
+		// A lock left behind on another host long ago
+		abandoned, _ := json.Marshal(LockInfo{PID: 1, Host: "elsewhere", Command: "sync", Started: time.Now().Add(-2 * LockStaleAfter)})
+		if err := os.WriteFile(path, abandoned, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		locks := make(chan *Lock, 16)
+		for i := 0; i < cap(locks); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if lock, err := AcquireLock("run.lock", "sync"); err == nil {
+					locks <- lock
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		close(locks)
+
+		if len(locks) != 1 {
+			t.Fatalf("round %d: %d processes took over the stale lock, want 1", round, len(locks))
+		}
+		if err := (<-locks).Release(); err != nil {
+			t.Fatal(err)
+		}
+		if moved, _ := filepath.Glob(path + ".*"); len(moved) > 0 {
+			t.Fatalf("round %d: left %v behind", round, moved)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package state
+
+import "os"
+
+// processAlive reports whether a process with the given ID is running. On
+// Windows, FindProcess fails for processes that no longer exist.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -0,0 +1,107 @@
+// Package state persists Furca's working state between runs.
+//
+// State is stored as JSON files in a per-user directory (by default the "furca"
+// directory under os.UserConfigDir, overridable with STATE_DIR), so that
+// interrupted operations can be resumed by a later invocation.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// Dir returns the directory in which Furca keeps its state, creating it if needed.
+func Dir() (string, error) {
+	dir := viper.GetString("STATE_DIR")
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find user config directory: %w", err)
+		}
+		dir = filepath.Join(configDir, "furca")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Load reads the named state file into v. It reports false if the file does not exist.
+func Load(name string, v any) (bool, error) {
+	path, err := filePath(name)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Save writes v to the named state file atomically, so a crash never leaves a
+// partially written file behind.
+func Save(name string, v any) error {
+	path, err := filePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Remove deletes the named state file. Removing a missing file is not an error.
+func Remove(name string) error {
+	path, err := filePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// filePath returns the full path of the named state file.
+func filePath(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}